@@ -6,6 +6,7 @@ import (
 
 	"github.com/ilius/expr/builtin"
 	"github.com/ilius/expr/file"
+	"github.com/ilius/expr/vm/runtime"
 )
 
 // Node represents items of abstract syntax tree.
@@ -54,6 +55,7 @@ type IdentifierNode struct {
 	FieldIndex  []int
 	Method      bool // true if method, false if field
 	MethodIndex int  // index of method, set only if Method is true
+	Lazy        bool // true if value is a func, called on first access
 }
 
 type IntegerNode struct {
@@ -66,6 +68,12 @@ type FloatNode struct {
 	Value float64
 }
 
+// DecimalNode is a decimal literal, like 12.50d.
+type DecimalNode struct {
+	base
+	Value runtime.Decimal
+}
+
 type BoolNode struct {
 	base
 	Value bool
@@ -93,6 +101,9 @@ type BinaryNode struct {
 	Operator string
 	Left     Node
 	Right    Node
+	// Method is set by the checker if the operator is implemented by
+	// a method of the left operand.
+	Method *runtime.OperatorMethod
 }
 
 type ChainNode struct {
@@ -110,6 +121,7 @@ type MemberNode struct {
 	FieldIndex  []int
 	Method      bool
 	MethodIndex int
+	Lazy        bool // true if value is a func, called on first access
 }
 
 type SliceNode struct {
@@ -126,6 +138,8 @@ type CallNode struct {
 	Typed     int
 	Fast      bool
 	Func      *builtin.Function
+	// Definition is true if the callee is a function added with expr.Define.
+	Definition bool
 }
 
 type BuiltinNode struct {
@@ -141,6 +155,7 @@ type ClosureNode struct {
 
 type PointerNode struct {
 	base
+	Name string // Empty for #, "error" for #error of try handlers.
 }
 
 type ConditionalNode struct {
@@ -165,3 +180,16 @@ type PairNode struct {
 	Key   Node
 	Value Node
 }
+
+// ActionsNode is a list of assignments of expressions compiled with
+// expr.AsActions, like `score = score + 10; tier = "gold"`.
+type ActionsNode struct {
+	base
+	Nodes []Node // AssignNode
+}
+
+type AssignNode struct {
+	base
+	Name  string
+	Value Node
+}
@@ -12,6 +12,7 @@ func Walk(node *Node, v Visitor) {
 	case *IdentifierNode:
 	case *IntegerNode:
 	case *FloatNode:
+	case *DecimalNode:
 	case *BoolNode:
 	case *StringNode:
 	case *ConstantNode:
@@ -60,6 +61,12 @@ func Walk(node *Node, v Visitor) {
 	case *PairNode:
 		Walk(&n.Key, v)
 		Walk(&n.Value, v)
+	case *ActionsNode:
+		for i := range n.Nodes {
+			Walk(&n.Nodes[i], v)
+		}
+	case *AssignNode:
+		Walk(&n.Value, v)
 	default:
 		panic(fmt.Sprintf("undefined node type (%T)", node))
 	}
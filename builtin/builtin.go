@@ -2,13 +2,30 @@ package builtin
 
 import (
 	"fmt"
+	"math/big"
+	"net"
 	"reflect"
+	"regexp"
+
+	"github.com/ilius/expr/vm/runtime"
 )
 
 var (
-	anyType     = reflect.TypeOf(new(interface{})).Elem()
-	integerType = reflect.TypeOf(0)
-	floatType   = reflect.TypeOf(float64(0))
+	anyType      = reflect.TypeOf(new(interface{})).Elem()
+	integerType  = reflect.TypeOf(0)
+	floatType    = reflect.TypeOf(float64(0))
+	stringType   = reflect.TypeOf("")
+	jsonPathType = reflect.TypeOf(&runtime.JSONPath{})
+	regexpType   = reflect.TypeOf(&regexp.Regexp{})
+	ipType       = reflect.TypeOf(net.IP{})
+	cidrType     = reflect.TypeOf(&net.IPNet{})
+	versionType  = reflect.TypeOf(&runtime.Version{})
+	pathType     = reflect.TypeOf(&runtime.Path{})
+	boolType     = reflect.TypeOf(true)
+	setType      = reflect.TypeOf(runtime.Set{})
+	arrayType    = reflect.TypeOf([]interface{}{})
+	decimalType  = reflect.TypeOf(runtime.Decimal{})
+	bigIntType   = reflect.TypeOf(&big.Int{})
 )
 
 type Function struct {
@@ -17,6 +34,10 @@ type Function struct {
 	Opcode   int
 	Types    []reflect.Type
 	Validate func(args []reflect.Type) (reflect.Type, error)
+	// Precompile is called by the checker for every argument which is a
+	// string literal. A non-nil result replaces the argument with a constant,
+	// and an error is reported as a compile error.
+	Precompile func(i int, s string, args []reflect.Type) (interface{}, error)
 }
 
 const (
@@ -24,8 +45,41 @@ const (
 	Abs
 	Int
 	Float
+	ToJSON
+	FromJSON
+	JSONPath
+	FindAll
+	Capture
+	ReplaceRegex
+	Split
+	Type
+	IP
+	CIDR
+	Semver
+	SHA256
+	MD5
+	Hash
+	Bucket
+	Base64Encode
+	Base64Decode
+	HexEncode
+	HexDecode
+	URLEncode
+	URLDecode
+	Get
+	Has
+	Set
+	Take
+	Decimal
 )
 
+// IsCore reports whether the builtin of the opcode is one of the core
+// builtins len, abs, int and float, which take precedence over env variables
+// of the same name. Other builtins are shadowed by them.
+func IsCore(opcode int) bool {
+	return opcode >= Len && opcode <= Float
+}
+
 var Builtins = map[int]*Function{
 	Len: {
 		Name:   "len",
@@ -38,6 +92,9 @@ var Builtins = map[int]*Function{
 			case reflect.Array, reflect.Map, reflect.Slice, reflect.String, reflect.Interface:
 				return integerType, nil
 			}
+			if args[0].Implements(runtime.LenerType) {
+				return integerType, nil
+			}
 			return anyType, fmt.Errorf("invalid argument for len (type %s)", args[0])
 		},
 	},
@@ -48,6 +105,9 @@ var Builtins = map[int]*Function{
 			if len(args) != 1 {
 				return anyType, fmt.Errorf("invalid number of arguments for abs (expected 1, got %d)", len(args))
 			}
+			if args[0] == decimalType || args[0] == bigIntType {
+				return args[0], nil
+			}
 			switch args[0].Kind() {
 			case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Interface:
 				return args[0], nil
@@ -70,6 +130,9 @@ var Builtins = map[int]*Function{
 			case reflect.String:
 				return integerType, nil
 			}
+			if args[0] == decimalType || args[0] == bigIntType {
+				return integerType, nil
+			}
 			return anyType, fmt.Errorf("invalid argument for int (type %s)", args[0])
 		},
 	},
@@ -88,7 +151,314 @@ var Builtins = map[int]*Function{
 			case reflect.String:
 				return floatType, nil
 			}
+			if args[0] == decimalType || args[0] == bigIntType {
+				return floatType, nil
+			}
 			return anyType, fmt.Errorf("invalid argument for float (type %s)", args[0])
 		},
 	},
+	ToJSON: {
+		Name:   "toJSON",
+		Opcode: ToJSON,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 1 {
+				return anyType, fmt.Errorf("invalid number of arguments for toJSON (expected 1, got %d)", len(args))
+			}
+			return stringType, nil
+		},
+	},
+	FromJSON: {
+		Name:   "fromJSON",
+		Opcode: FromJSON,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 1 {
+				return anyType, fmt.Errorf("invalid number of arguments for fromJSON (expected 1, got %d)", len(args))
+			}
+			if !isStringOrBytes(args[0]) {
+				return anyType, fmt.Errorf("invalid argument for fromJSON (type %s)", args[0])
+			}
+			return anyType, nil
+		},
+	},
+	JSONPath: {
+		Name:   "jsonPath",
+		Opcode: JSONPath,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 2 {
+				return anyType, fmt.Errorf("invalid number of arguments for jsonPath (expected 2, got %d)", len(args))
+			}
+			switch args[0].Kind() {
+			case reflect.Map, reflect.Slice, reflect.String, reflect.Interface:
+			default:
+				return anyType, fmt.Errorf("invalid argument for jsonPath (type %s)", args[0])
+			}
+			if args[1] != jsonPathType && args[1].Kind() != reflect.String && args[1].Kind() != reflect.Interface {
+				return anyType, fmt.Errorf("invalid path for jsonPath (type %s)", args[1])
+			}
+			return anyType, nil
+		},
+		Precompile: func(i int, s string, _ []reflect.Type) (interface{}, error) {
+			if i != 1 {
+				return nil, nil
+			}
+			return runtime.ParseJSONPath(s)
+		},
+	},
+	FindAll:      regexpBuiltin("findAll", FindAll, 2, reflect.TypeOf([]string{})),
+	Capture:      regexpBuiltin("capture", Capture, 2, reflect.TypeOf(map[string]interface{}{})),
+	ReplaceRegex: regexpBuiltin("replaceRegex", ReplaceRegex, 3, stringType),
+	Split:        regexpBuiltin("split", Split, 2, reflect.TypeOf([]string{})),
+	Type: {
+		Name:   "type",
+		Opcode: Type,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 1 {
+				return anyType, fmt.Errorf("invalid number of arguments for type (expected 1, got %d)", len(args))
+			}
+			return stringType, nil
+		},
+	},
+	IP: {
+		Name:   "ip",
+		Opcode: IP,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 1 {
+				return anyType, fmt.Errorf("invalid number of arguments for ip (expected 1, got %d)", len(args))
+			}
+			if args[0] != ipType && args[0].Kind() != reflect.String && args[0].Kind() != reflect.Interface {
+				return anyType, fmt.Errorf("invalid argument for ip (type %s)", args[0])
+			}
+			return ipType, nil
+		},
+		Precompile: func(_ int, s string, _ []reflect.Type) (interface{}, error) {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", s)
+			}
+			return runtime.ToIP(ip), nil
+		},
+	},
+	CIDR: {
+		Name:   "cidr",
+		Opcode: CIDR,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 1 {
+				return anyType, fmt.Errorf("invalid number of arguments for cidr (expected 1, got %d)", len(args))
+			}
+			if args[0] != cidrType && args[0].Kind() != reflect.String && args[0].Kind() != reflect.Interface {
+				return anyType, fmt.Errorf("invalid argument for cidr (type %s)", args[0])
+			}
+			return cidrType, nil
+		},
+		Precompile: func(_ int, s string, _ []reflect.Type) (interface{}, error) {
+			_, n, err := net.ParseCIDR(s)
+			if err != nil {
+				return nil, err
+			}
+			return n, nil
+		},
+	},
+	Semver: {
+		Name:   "semver",
+		Opcode: Semver,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 1 {
+				return anyType, fmt.Errorf("invalid number of arguments for semver (expected 1, got %d)", len(args))
+			}
+			if args[0] != versionType && args[0].Kind() != reflect.String && args[0].Kind() != reflect.Interface {
+				return anyType, fmt.Errorf("invalid argument for semver (type %s)", args[0])
+			}
+			return versionType, nil
+		},
+		Precompile: func(_ int, s string, _ []reflect.Type) (interface{}, error) {
+			return runtime.ParseVersion(s)
+		},
+	},
+	SHA256:       bytesBuiltin("sha256", SHA256, stringType),
+	MD5:          bytesBuiltin("md5", MD5, stringType),
+	Hash:         keyBuiltin("hash", Hash, 1),
+	Bucket:       keyBuiltin("bucket", Bucket, 2),
+	Base64Encode: bytesBuiltin("base64Encode", Base64Encode, stringType),
+	Base64Decode: bytesBuiltin("base64Decode", Base64Decode, stringType),
+	HexEncode:    bytesBuiltin("hexEncode", HexEncode, stringType),
+	HexDecode:    bytesBuiltin("hexDecode", HexDecode, stringType),
+	URLEncode:    bytesBuiltin("urlEncode", URLEncode, stringType),
+	URLDecode:    bytesBuiltin("urlDecode", URLDecode, stringType),
+	Get:          pathBuiltin("get", Get, 2, 3, anyType),
+	Has:          pathBuiltin("has", Has, 2, 2, boolType),
+	Take: {
+		Name:   "take",
+		Opcode: Take,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 2 {
+				return anyType, fmt.Errorf("invalid number of arguments for take (expected 2, got %d)", len(args))
+			}
+			if !isInteger(args[1]) && args[1].Kind() != reflect.Interface {
+				return anyType, fmt.Errorf("invalid argument for take (type %s)", args[1])
+			}
+			switch args[0].Kind() {
+			case reflect.Interface:
+				return arrayType, nil
+			case reflect.Array, reflect.Slice:
+				return reflect.SliceOf(args[0].Elem()), nil
+			}
+			return anyType, fmt.Errorf("invalid argument for take (type %s)", args[0])
+		},
+	},
+	Set: {
+		Name:   "set",
+		Opcode: Set,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 1 {
+				return anyType, fmt.Errorf("invalid number of arguments for set (expected 1, got %d)", len(args))
+			}
+			switch args[0].Kind() {
+			case reflect.Array, reflect.Slice, reflect.Interface:
+				return setType, nil
+			}
+			if args[0] == setType {
+				return setType, nil
+			}
+			return anyType, fmt.Errorf("invalid argument for set (type %s)", args[0])
+		},
+	},
+	Decimal: {
+		Name:   "decimal",
+		Opcode: Decimal,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 1 {
+				return anyType, fmt.Errorf("invalid number of arguments for decimal (expected 1, got %d)", len(args))
+			}
+			switch args[0].Kind() {
+			case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.String, reflect.Interface:
+				return decimalType, nil
+			}
+			if args[0] == decimalType || args[0] == bigIntType {
+				return decimalType, nil
+			}
+			return anyType, fmt.Errorf("invalid argument for decimal (type %s)", args[0])
+		},
+		Precompile: func(_ int, s string, _ []reflect.Type) (interface{}, error) {
+			return runtime.ParseDecimal(s)
+		},
+	},
+}
+
+func regexpBuiltin(name string, opcode int, arity int, out reflect.Type) *Function {
+	return &Function{
+		Name:   name,
+		Opcode: opcode,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != arity {
+				return anyType, fmt.Errorf("invalid number of arguments for %v (expected %d, got %d)", name, arity, len(args))
+			}
+			for i, arg := range args {
+				if i == 1 && arg == regexpType {
+					continue
+				}
+				if arg.Kind() != reflect.String && arg.Kind() != reflect.Interface {
+					return anyType, fmt.Errorf("invalid argument for %v (type %s)", name, arg)
+				}
+			}
+			return out, nil
+		},
+		Precompile: func(i int, s string, _ []reflect.Type) (interface{}, error) {
+			if i != 1 {
+				return nil, nil
+			}
+			return regexp.Compile(s)
+		},
+	}
+}
+
+// pathBuiltin creates a function looking up a value by a path like "a.b[2].c",
+// optionally followed by a default value. Constant paths are parsed once,
+// and fields of structs known at compile time are resolved to indexes.
+func pathBuiltin(name string, opcode int, minArity, maxArity int, out reflect.Type) *Function {
+	return &Function{
+		Name:   name,
+		Opcode: opcode,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) < minArity || len(args) > maxArity {
+				if minArity == maxArity {
+					return anyType, fmt.Errorf("invalid number of arguments for %v (expected %d, got %d)", name, minArity, len(args))
+				}
+				return anyType, fmt.Errorf("invalid number of arguments for %v (expected %d or %d, got %d)", name, minArity, maxArity, len(args))
+			}
+			if args[1] != pathType && args[1].Kind() != reflect.String && args[1].Kind() != reflect.Interface {
+				return anyType, fmt.Errorf("invalid path for %v (type %s)", name, args[1])
+			}
+			return out, nil
+		},
+		Precompile: func(i int, s string, args []reflect.Type) (interface{}, error) {
+			if i != 1 {
+				return nil, nil
+			}
+			p, err := runtime.ParsePath(s)
+			if err != nil {
+				return nil, err
+			}
+			p.Resolve(args[0])
+			return p, nil
+		},
+	}
+}
+
+// bytesBuiltin creates a function of one string or []byte argument.
+func bytesBuiltin(name string, opcode int, out reflect.Type) *Function {
+	return &Function{
+		Name:   name,
+		Opcode: opcode,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != 1 {
+				return anyType, fmt.Errorf("invalid number of arguments for %v (expected 1, got %d)", name, len(args))
+			}
+			if !isStringOrBytes(args[0]) {
+				return anyType, fmt.Errorf("invalid argument for %v (type %s)", name, args[0])
+			}
+			return out, nil
+		},
+	}
+}
+
+// keyBuiltin creates a hashing function of a string, []byte or integer key,
+// optionally followed by an integer.
+func keyBuiltin(name string, opcode int, arity int) *Function {
+	return &Function{
+		Name:   name,
+		Opcode: opcode,
+		Validate: func(args []reflect.Type) (reflect.Type, error) {
+			if len(args) != arity {
+				return anyType, fmt.Errorf("invalid number of arguments for %v (expected %d, got %d)", name, arity, len(args))
+			}
+			if !isStringOrBytes(args[0]) && !isInteger(args[0]) {
+				return anyType, fmt.Errorf("invalid argument for %v (type %s)", name, args[0])
+			}
+			for _, arg := range args[1:] {
+				if !isInteger(arg) && arg.Kind() != reflect.Interface {
+					return anyType, fmt.Errorf("invalid argument for %v (type %s)", name, arg)
+				}
+			}
+			return integerType, nil
+		},
+	}
+}
+
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isStringOrBytes(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Interface:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
 }
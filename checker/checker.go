@@ -11,6 +11,7 @@ import (
 	"github.com/ilius/expr/file"
 	"github.com/ilius/expr/parser"
 	"github.com/ilius/expr/vm"
+	"github.com/ilius/expr/vm/runtime"
 )
 
 func Check(tree *parser.Tree, config *conf.Config) (t reflect.Type, err error) {
@@ -56,12 +57,15 @@ type visitor struct {
 	config      *conf.Config
 	collections []reflect.Type
 	parents     []ast.Node
+	narrowed    []map[string]narrowing
+	handlers    int // depth of try handlers, where #error can be used
 	err         *file.Error
 }
 
 type info struct {
 	method bool
 	fn     *builtin.Function
+	def    *vm.Definition
 }
 
 func (v *visitor) visit(node ast.Node) (reflect.Type, info) {
@@ -77,6 +81,8 @@ func (v *visitor) visit(node ast.Node) (reflect.Type, info) {
 		t, i = v.IntegerNode(n)
 	case *ast.FloatNode:
 		t, i = v.FloatNode(n)
+	case *ast.DecimalNode:
+		t, i = v.DecimalNode(n)
 	case *ast.BoolNode:
 		t, i = v.BoolNode(n)
 	case *ast.StringNode:
@@ -109,10 +115,19 @@ func (v *visitor) visit(node ast.Node) (reflect.Type, info) {
 		t, i = v.MapNode(n)
 	case *ast.PairNode:
 		t, i = v.PairNode(n)
+	case *ast.ActionsNode:
+		t, i = v.ActionsNode(n)
+	case *ast.AssignNode:
+		t, i = v.AssignNode(n)
 	default:
 		panic(fmt.Sprintf("undefined node type (%T)", node))
 	}
 	v.parents = v.parents[:len(v.parents)-1]
+	if isAny(t) && t.NumMethod() == 0 && i.fn == nil {
+		if nt, ok := v.narrowedType(node); ok {
+			t = nt
+		}
+	}
 	node.SetType(t)
 	return t, i
 }
@@ -132,7 +147,23 @@ func (v *visitor) NilNode(*ast.NilNode) (reflect.Type, info) {
 }
 
 func (v *visitor) IdentifierNode(node *ast.IdentifierNode) (reflect.Type, info) {
-	if fn, ok := v.config.Functions[node.Value]; ok {
+	if d := v.config.Definition; d != nil {
+		if i := d.Param(node.Value); i >= 0 {
+			return d.Type.In(i), info{}
+		}
+	}
+	if d, ok := v.config.Definitions[node.Value]; ok {
+		// Definitions are not values, they only can be called.
+		var call *ast.CallNode
+		if len(v.parents) > 1 {
+			call, _ = v.parents[len(v.parents)-2].(*ast.CallNode)
+		}
+		if call == nil || call.Callee != node {
+			return v.error(node, "%v is a function and must be called", node.Value)
+		}
+		return anyType, info{def: d}
+	}
+	if fn, ok := v.config.Functions[node.Value]; ok && !v.shadowed(fn) {
 		// Return anyType instead of func type as we don't know the arguments yet.
 		// The func type can be one of the fn.Types. The type will be resolved
 		// when the arguments are known in CallNode.
@@ -144,7 +175,12 @@ func (v *visitor) IdentifierNode(node *ast.IdentifierNode) (reflect.Type, info)
 		if t.Ambiguous {
 			return v.error(node, "ambiguous identifier %v", node.Value)
 		}
-		d, c := deref(t.Type)
+		typ := t.Type
+		if v.config.LazyValues && !t.Method && isLazy(typ) {
+			node.Lazy = true
+			typ = typ.Out(0)
+		}
+		d, c := deref(typ)
 		node.Deref = c
 		node.Method = t.Method
 		node.MethodIndex = t.MethodIndex
@@ -160,6 +196,18 @@ func (v *visitor) IdentifierNode(node *ast.IdentifierNode) (reflect.Type, info)
 	return anyType, info{}
 }
 
+// shadowed reports whether a builtin function is shadowed by a variable
+// of the same name in the environment. This way adding new builtins
+// does not break environments which already define such names. The core
+// builtins always take precedence.
+func (v *visitor) shadowed(fn *builtin.Function) bool {
+	if fn.Opcode == 0 || builtin.IsCore(fn.Opcode) || v.config.Types == nil {
+		return false
+	}
+	_, ok := v.config.Types[fn.Name]
+	return ok
+}
+
 func (v *visitor) IntegerNode(*ast.IntegerNode) (reflect.Type, info) {
 	return integerType, info{}
 }
@@ -168,6 +216,10 @@ func (v *visitor) FloatNode(*ast.FloatNode) (reflect.Type, info) {
 	return floatType, info{}
 }
 
+func (v *visitor) DecimalNode(*ast.DecimalNode) (reflect.Type, info) {
+	return decimalType, info{}
+}
+
 func (v *visitor) BoolNode(*ast.BoolNode) (reflect.Type, info) {
 	return boolType, info{}
 }
@@ -183,6 +235,16 @@ func (v *visitor) ConstantNode(node *ast.ConstantNode) (reflect.Type, info) {
 func (v *visitor) UnaryNode(node *ast.UnaryNode) (reflect.Type, info) {
 	t, _ := v.visit(node.Node)
 
+	// check operator overloading
+	if fns, ok := v.config.Operators.Functions(node.Operator); ok {
+		out, _, ok := conf.FindSuitableUnaryOverload(fns, v.config.Types, t)
+		if ok {
+			return out, info{}
+		}
+	}
+
+	v.strict(node.Node, t, "operator "+node.Operator)
+
 	switch node.Operator {
 
 	case "!", "not":
@@ -210,15 +272,54 @@ func (v *visitor) UnaryNode(node *ast.UnaryNode) (reflect.Type, info) {
 
 func (v *visitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, info) {
 	l, _ := v.visit(node.Left)
-	r, _ := v.visit(node.Right)
+	var r reflect.Type
+	switch node.Operator {
+	case "and", "&&":
+		done := v.narrow(node.Left)
+		r, _ = v.visit(node.Right)
+		done()
+	default:
+		r, _ = v.visit(node.Right)
+	}
 
 	// check operator overloading
-	if fns, ok := v.config.Operators[node.Operator]; ok {
+	if fns, ok := v.config.Operators.Functions(node.Operator); ok {
 		t, _, ok := conf.FindSuitableOperatorOverload(fns, v.config.Types, l, r)
 		if ok {
 			return t, info{}
 		}
 	}
+	if t, ok := v.operatorMethod(node, l, r); ok {
+		return t, info{}
+	}
+
+	switch node.Operator {
+	case "==", "!=", "is":
+		// Defined for values of any type.
+	case "in":
+		v.strict(node.Right, r, "operator in")
+	default:
+		v.strict(node.Left, l, "operator "+node.Operator)
+		v.strict(node.Right, r, "operator "+node.Operator)
+	}
+
+	switch node.Operator {
+	case "==", "!=", "<", ">", ">=", "<=":
+		if isVersion(l) {
+			r = v.precompileLiteral(&node.Right, r, parseVersion)
+		} else if isVersion(r) {
+			l = v.precompileLiteral(&node.Left, l, parseVersion)
+		}
+	case "in":
+		if isVersion(l) {
+			r = v.precompileLiteral(&node.Right, r, parseVersionConstraint)
+		}
+	case "+", "-", "*", "/", "%":
+		// Integer literals take the type of a sized integer operand, like
+		// untyped constants in Go, so the result keeps the sized type.
+		l = v.sizeLiteral(node.Operator, node.Left, l, r)
+		r = v.sizeLiteral(node.Operator, node.Right, r, l)
+	}
 
 	switch node.Operator {
 	case "==", "!=":
@@ -235,6 +336,13 @@ func (v *visitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, info) {
 			return boolType, info{}
 		}
 
+	case "is":
+		name := node.Right.(*ast.StringNode).Value
+		if _, ok := typeNames[name]; !ok {
+			return v.error(node.Right, "unknown type name %v", name)
+		}
+		return boolType, info{}
+
 	case "or", "||", "and", "&&":
 		if isBool(l) && isBool(r) {
 			return boolType, info{}
@@ -253,30 +361,53 @@ func (v *visitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, info) {
 		if isTime(l) && isTime(r) {
 			return boolType, info{}
 		}
-		if or(l, r, isNumber, isString, isTime) {
+		if isVersion(l) && isVersion(r) {
+			return boolType, info{}
+		}
+		if or(l, r, isNumber, isString, isTime, isVersion) {
 			return boolType, info{}
 		}
 
 	case "-":
 		if isNumber(l) && isNumber(r) {
-			return combined(l, r), info{}
+			return v.combined(node.Operator, l, r), info{}
 		}
 		if isTime(l) && isTime(r) {
 			return durationType, info{}
 		}
-		if or(l, r, isNumber, isTime) {
+		if isSet(l) && isSet(r) {
+			return setType, info{}
+		}
+		if or(l, r, isNumber, isTime, isSet) {
 			return anyType, info{}
 		}
 
-	case "/", "*":
+	case "/":
 		if isNumber(l) && isNumber(r) {
-			return combined(l, r), info{}
+			if isIntegral(l) && isIntegral(r) && !v.config.IntegerDivision {
+				return floatType, info{}
+			}
+			return v.combined(node.Operator, l, r), info{}
+		}
+		if or(l, r, isNumber) {
+			return anyType, info{}
+		}
+
+	case "*":
+		if isNumber(l) && isNumber(r) {
+			return v.combined(node.Operator, l, r), info{}
 		}
 		if or(l, r, isNumber) {
 			return anyType, info{}
 		}
 
 	case "**", "^":
+		if isDecimal(r) || isDecimal(l) && !isInteger(r) && !isAny(r) {
+			return v.error(node, "invalid operation: %v (decimal powers need an integer exponent)", node.Operator)
+		}
+		if isDecimal(l) {
+			return decimalType, info{}
+		}
 		if isNumber(l) && isNumber(r) {
 			return floatType, info{}
 		}
@@ -285,16 +416,16 @@ func (v *visitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, info) {
 		}
 
 	case "%":
-		if isInteger(l) && isInteger(r) {
-			return combined(l, r), info{}
+		if isIntegral(l) && isIntegral(r) {
+			return v.combined(node.Operator, l, r), info{}
 		}
-		if or(l, r, isInteger) {
+		if or(l, r, isIntegral) {
 			return anyType, info{}
 		}
 
 	case "+":
 		if isNumber(l) && isNumber(r) {
-			return combined(l, r), info{}
+			return v.combined(node.Operator, l, r), info{}
 		}
 		if isString(l) && isString(r) {
 			return stringType, info{}
@@ -310,13 +441,22 @@ func (v *visitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, info) {
 		}
 
 	case "in":
+		if anyOf(l, isIP, isString, isAny) && isCIDR(r) {
+			return boolType, info{}
+		}
+		if anyOf(l, isVersion, isAny) && isVersionConstraint(r) {
+			return boolType, info{}
+		}
+		if isVersion(l) && isString(r) {
+			return boolType, info{}
+		}
 		if (isString(l) || isAny(l)) && isStruct(r) {
 			return boolType, info{}
 		}
 		if isMap(r) {
 			return boolType, info{}
 		}
-		if isArray(r) {
+		if isArray(r) || isIterable(r) || isFetcher(r) {
 			return boolType, info{}
 		}
 		if isAny(l) && anyOf(r, isString, isArray, isMap) {
@@ -349,6 +489,22 @@ func (v *visitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, info) {
 			return boolType, info{}
 		}
 
+	case "|", "&":
+		if isSet(l) && isSet(r) {
+			return setType, info{}
+		}
+		if or(l, r, isSet) {
+			return setType, info{}
+		}
+
+	case "subsetOf", "supersetOf":
+		if isSet(l) && isSet(r) {
+			return boolType, info{}
+		}
+		if or(l, r, isSet) {
+			return boolType, info{}
+		}
+
 	case "..":
 		ret := reflect.SliceOf(integerType)
 		if isInteger(l) && isInteger(r) {
@@ -366,6 +522,32 @@ func (v *visitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, info) {
 	return v.error(node, `invalid operation: %v (mismatched types %v and %v)`, node.Operator, l, r)
 }
 
+// precompileLiteral replaces a string literal with the value parsed by
+// parse, so that invalid values are reported at compile time.
+func (v *visitor) precompileLiteral(node *ast.Node, t reflect.Type, parse func(string) (interface{}, error)) reflect.Type {
+	s, ok := (*node).(*ast.StringNode)
+	if !ok {
+		return t
+	}
+	value, err := parse(s.Value)
+	if err != nil {
+		v.error(s, "%v", err)
+		return t
+	}
+	constNode := &ast.ConstantNode{Value: value}
+	ast.Patch(node, constNode)
+	constNode.SetType(reflect.TypeOf(value))
+	return constNode.Type()
+}
+
+func parseVersion(s string) (interface{}, error) {
+	return runtime.ParseVersion(s)
+}
+
+func parseVersionConstraint(s string) (interface{}, error) {
+	return runtime.ParseVersionConstraint(s)
+}
+
 func (v *visitor) ChainNode(node *ast.ChainNode) (reflect.Type, info) {
 	return v.visit(node.Node)
 }
@@ -395,12 +577,34 @@ func (v *visitor) MemberNode(node *ast.MemberNode) (reflect.Type, info) {
 		}
 	}
 
+	// check overloading of index access
+	if fns, ok := v.config.Operators.Functions("[]"); ok {
+		t, _, ok := conf.FindSuitableOperatorOverload(fns, v.config.Types, base, prop)
+		if ok {
+			return t, info{}
+		}
+	}
+
+	// Dynamic fields of custom collections, and their elements, are of
+	// any type. Declared fields are checked as fields of other structs.
+	if isFetcher(base) && !hasField(base, node.Property) {
+		node.Deref = true
+		return anyType, info{}
+	}
+	if isIterable(base) && !isIndexable(base) && (isInteger(prop) || isAny(prop)) {
+		node.Deref = true
+		return anyType, info{}
+	}
+
 	if base.Kind() == reflect.Ptr {
 		base = base.Elem()
 	}
 
 	switch base.Kind() {
 	case reflect.Interface:
+		if !v.testedKind(node.Node, "map", "struct", "array") {
+			v.strict(node.Node, base, "member access")
+		}
 		node.Deref = true
 		return anyType, info{}
 
@@ -424,7 +628,12 @@ func (v *visitor) MemberNode(node *ast.MemberNode) (reflect.Type, info) {
 		if name, ok := node.Property.(*ast.StringNode); ok {
 			propertyName := name.Value
 			if field, ok := fetchField(base, propertyName); ok {
-				t, c := deref(field.Type)
+				typ := field.Type
+				if v.config.LazyValues && isLazy(typ) {
+					node.Lazy = true
+					typ = typ.Out(0)
+				}
+				t, c := deref(typ)
 				node.Deref = c
 				node.FieldIndex = field.Index
 				node.Name = propertyName
@@ -432,16 +641,48 @@ func (v *visitor) MemberNode(node *ast.MemberNode) (reflect.Type, info) {
 			}
 			if len(v.parents) > 1 {
 				if _, ok := v.parents[len(v.parents)-2].(*ast.CallNode); ok {
-					return v.error(node, "type %v has no method %v", base, propertyName)
+					return v.error(node, "%v has no method %v", describe(node.Node, base), propertyName)
 				}
 			}
-			return v.error(node, "type %v has no field %v", base, propertyName)
+			return v.error(node, "%v has no field %v", describe(node.Node, base), propertyName)
 		}
 	}
 
 	return v.error(node, "type %v[%v] is undefined", base, prop)
 }
 
+// describe returns a description of the value of node, of type t, for
+// errors. Unnamed structs, like objects of a conf.Schema, are described by
+// the path of the value, like "user.address", as their types are unreadable.
+func describe(node ast.Node, t reflect.Type) string {
+	if t.Name() == "" {
+		if p := valuePath(node); p != "" {
+			return p
+		}
+		return "object"
+	}
+	return fmt.Sprintf("type %v", t)
+}
+
+func valuePath(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		return n.Value
+	case *ast.ChainNode:
+		return valuePath(n.Node)
+	case *ast.MemberNode:
+		base := valuePath(n.Node)
+		if base == "" {
+			return ""
+		}
+		if s, ok := n.Property.(*ast.StringNode); ok {
+			return base + "." + s.Value
+		}
+		return base + "[]"
+	}
+	return ""
+}
+
 func (v *visitor) SliceNode(node *ast.SliceNode) (reflect.Type, info) {
 	t, _ := v.visit(node.Node)
 
@@ -471,6 +712,7 @@ func (v *visitor) SliceNode(node *ast.SliceNode) (reflect.Type, info) {
 
 func (v *visitor) CallNode(node *ast.CallNode) (reflect.Type, info) {
 	fn, fnInfo := v.visit(node.Callee)
+	fn = v.unlazy(node.Callee, fn)
 
 	if fnInfo.fn != nil {
 		f := fnInfo.fn
@@ -479,6 +721,25 @@ func (v *visitor) CallNode(node *ast.CallNode) (reflect.Type, info) {
 			args := make([]reflect.Type, len(node.Arguments))
 			for i, arg := range node.Arguments {
 				args[i], _ = v.visit(arg)
+				v.strictArgument(f.Name, arg, args[i])
+			}
+			if f.Precompile != nil {
+				for i, arg := range node.Arguments {
+					s, ok := arg.(*ast.StringNode)
+					if !ok {
+						continue
+					}
+					value, err := f.Precompile(i, s.Value, args)
+					if err != nil {
+						return v.error(arg, "%v", err)
+					}
+					if value != nil {
+						constNode := &ast.ConstantNode{Value: value}
+						ast.Patch(&node.Arguments[i], constNode)
+						args[i] = reflect.TypeOf(value)
+						constNode.SetType(args[i])
+					}
+				}
 			}
 			t, err := f.Validate(args)
 			if err != nil {
@@ -514,6 +775,11 @@ func (v *visitor) CallNode(node *ast.CallNode) (reflect.Type, info) {
 		}
 	}
 
+	if d := fnInfo.def; d != nil {
+		node.Definition = true
+		return v.checkDefinition(d, node)
+	}
+
 	fnName := "function"
 	if identifier, ok := node.Callee.(*ast.IdentifierNode); ok {
 		fnName = identifier.Value
@@ -525,6 +791,9 @@ func (v *visitor) CallNode(node *ast.CallNode) (reflect.Type, info) {
 	}
 	switch fn.Kind() {
 	case reflect.Interface:
+		if !v.testedKind(node.Callee, "func") {
+			v.strict(node.Callee, fn, "call")
+		}
 		return anyType, info{}
 	case reflect.Func:
 		inputParamsCount := 1 // for functions
@@ -559,6 +828,68 @@ func (v *visitor) CallNode(node *ast.CallNode) (reflect.Type, info) {
 	return v.error(node, "%v is not callable", fn)
 }
 
+// unlazy makes a lazy value called explicitly, like Now(), a func again,
+// unless the value is a func, which is called instead. It returns the type
+// of the callee.
+func (v *visitor) unlazy(node ast.Node, t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Func {
+		return t
+	}
+	var fn reflect.Type
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		if !n.Lazy {
+			return t
+		}
+		fn = v.config.Types[n.Value].Type
+		n.Lazy = false
+		n.Deref = false
+	case *ast.MemberNode:
+		if !n.Lazy {
+			return t
+		}
+		base := n.Node.Type()
+		if base.Kind() == reflect.Ptr {
+			base = base.Elem()
+		}
+		field, _ := fetchField(base, n.Name)
+		fn = field.Type
+		n.Lazy = false
+		n.Deref = false
+	default:
+		return t
+	}
+	node.SetType(fn)
+	return fn
+}
+
+// checkDefinition checks a call of a function added with expr.Define. Unlike
+// Go functions, parameters annotated as array or map accept any array or map.
+func (v *visitor) checkDefinition(d *vm.Definition, node *ast.CallNode) (reflect.Type, info) {
+	if len(node.Arguments) > len(d.Params) {
+		return v.error(node, "too many arguments to call %v", d.Name)
+	}
+	if len(node.Arguments) < len(d.Params) {
+		return v.error(node, "not enough arguments to call %v", d.Name)
+	}
+	for i, arg := range node.Arguments {
+		t, _ := v.visit(arg)
+		in := d.Type.In(i)
+		if isIntegerOrArithmeticOperation(arg) {
+			t = in
+			setTypeForIntegers(arg, t)
+		}
+		switch {
+		case t == nil, isAny(t), t.AssignableTo(in):
+		case in == arrayType && isArray(t):
+		case in == mapType && isMap(t):
+		default:
+			return v.error(arg, "cannot use %v as argument (type %v) to call %v", t, in, d.Name)
+		}
+	}
+	return d.Type.Out(0), info{}
+}
+
 func (v *visitor) checkFunc(name string, fn reflect.Type, method bool, node *ast.CallNode) (reflect.Type, *file.Error) {
 	if isAny(fn) {
 		return anyType, nil
@@ -632,6 +963,13 @@ func (v *visitor) checkFunc(name string, fn reflect.Type, method bool, node *ast
 			continue
 		}
 
+		if v.config.StrictTypes && isAny(t) && !isAny(in) {
+			return anyType, &file.Error{
+				Location: arg.Location(),
+				Message:  ambiguous(t, "argument of "+name),
+			}
+		}
+
 		if !t.AssignableTo(in) && t.Kind() != reflect.Interface {
 			return anyType, &file.Error{
 				Location: arg.Location(),
@@ -646,7 +984,7 @@ func (v *visitor) checkFunc(name string, fn reflect.Type, method bool, node *ast
 func (v *visitor) BuiltinNode(node *ast.BuiltinNode) (reflect.Type, info) {
 	switch node.Name {
 	case "all", "none", "any", "one":
-		collection, _ := v.visit(node.Arguments[0])
+		collection := v.collection(node)
 		if !isArray(collection) && !isAny(collection) {
 			return v.error(node.Arguments[0], "builtin %v takes only array (got %v)", node.Name, collection)
 		}
@@ -667,7 +1005,7 @@ func (v *visitor) BuiltinNode(node *ast.BuiltinNode) (reflect.Type, info) {
 		return v.error(node.Arguments[1], "closure should has one input and one output param")
 
 	case "filter":
-		collection, _ := v.visit(node.Arguments[0])
+		collection := v.collection(node)
 		if !isArray(collection) && !isAny(collection) {
 			return v.error(node.Arguments[0], "builtin %v takes only array (got %v)", node.Name, collection)
 		}
@@ -691,7 +1029,7 @@ func (v *visitor) BuiltinNode(node *ast.BuiltinNode) (reflect.Type, info) {
 		return v.error(node.Arguments[1], "closure should has one input and one output param")
 
 	case "map":
-		collection, _ := v.visit(node.Arguments[0])
+		collection := v.collection(node)
 		if !isArray(collection) && !isAny(collection) {
 			return v.error(node.Arguments[0], "builtin %v takes only array (got %v)", node.Name, collection)
 		}
@@ -709,7 +1047,7 @@ func (v *visitor) BuiltinNode(node *ast.BuiltinNode) (reflect.Type, info) {
 		return v.error(node.Arguments[1], "closure should has one input and one output param")
 
 	case "count":
-		collection, _ := v.visit(node.Arguments[0])
+		collection := v.collection(node)
 		if !isArray(collection) && !isAny(collection) {
 			return v.error(node.Arguments[0], "builtin %v takes only array (got %v)", node.Name, collection)
 		}
@@ -729,6 +1067,96 @@ func (v *visitor) BuiltinNode(node *ast.BuiltinNode) (reflect.Type, info) {
 		}
 		return v.error(node.Arguments[1], "closure should has one input and one output param")
 
+	case "first", "takeWhile":
+		collection := v.collection(node)
+		if !isArray(collection) && !isAny(collection) {
+			return v.error(node.Arguments[0], "builtin %v takes only array (got %v)", node.Name, collection)
+		}
+		if len(node.Arguments) == 1 {
+			if isAny(collection) {
+				return anyType, info{}
+			}
+			return collection.Elem(), info{}
+		}
+
+		v.collections = append(v.collections, collection)
+		closure, _ := v.visit(node.Arguments[1])
+		v.collections = v.collections[:len(v.collections)-1]
+
+		if isFunc(closure) &&
+			closure.NumOut() == 1 &&
+			closure.NumIn() == 1 && isAny(closure.In(0)) {
+
+			if !isBool(closure.Out(0)) && !isAny(closure.Out(0)) {
+				return v.error(node.Arguments[1], "closure should return boolean (got %v)", closure.Out(0).String())
+			}
+			if isAny(collection) {
+				if node.Name == "first" {
+					return anyType, info{}
+				}
+				return arrayType, info{}
+			}
+			if node.Name == "first" {
+				return collection.Elem(), info{}
+			}
+			return reflect.SliceOf(collection.Elem()), info{}
+		}
+		return v.error(node.Arguments[1], "closure should has one input and one output param")
+
+	case "sort", "sortDesc":
+		collection := v.collection(node)
+		if !isArray(collection) && !isAny(collection) {
+			return v.error(node.Arguments[0], "builtin %v takes only array (got %v)", node.Name, collection)
+		}
+		if isAny(collection) {
+			return arrayType, info{}
+		}
+		if !isOrdered(collection.Elem()) {
+			return v.error(node.Arguments[0], "cannot sort array of %v", collection.Elem())
+		}
+		return reflect.SliceOf(collection.Elem()), info{}
+
+	case "sortBy", "sortByDesc":
+		collection := v.collection(node)
+		if !isArray(collection) && !isAny(collection) {
+			return v.error(node.Arguments[0], "builtin %v takes only array (got %v)", node.Name, collection)
+		}
+
+		for _, arg := range node.Arguments[1:] {
+			v.collections = append(v.collections, collection)
+			closure, _ := v.visit(arg)
+			v.collections = v.collections[:len(v.collections)-1]
+
+			if !isFunc(closure) ||
+				closure.NumOut() != 1 ||
+				closure.NumIn() != 1 || !isAny(closure.In(0)) {
+				return v.error(arg, "closure should has one input and one output param")
+			}
+			if !isOrdered(closure.Out(0)) {
+				return v.error(arg, "cannot sort by %v", closure.Out(0))
+			}
+		}
+		if isAny(collection) {
+			return arrayType, info{}
+		}
+		return reflect.SliceOf(collection.Elem()), info{}
+
+	case "try":
+		t, _ := v.visit(node.Arguments[0])
+		var fallback reflect.Type
+		if _, ok := node.Arguments[1].(*ast.ClosureNode); ok {
+			v.handlers++
+			handler, _ := v.visit(node.Arguments[1])
+			v.handlers--
+			fallback = handler.Out(0)
+		} else {
+			fallback, _ = v.visit(node.Arguments[1])
+		}
+		if t == fallback {
+			return t, info{}
+		}
+		return anyType, info{}
+
 	default:
 		return v.error(node, "unknown builtin %v", node.Name)
 	}
@@ -740,6 +1168,12 @@ func (v *visitor) ClosureNode(node *ast.ClosureNode) (reflect.Type, info) {
 }
 
 func (v *visitor) PointerNode(node *ast.PointerNode) (reflect.Type, info) {
+	if node.Name == "error" {
+		if v.handlers == 0 {
+			return v.error(node, "cannot use #error outside try handler")
+		}
+		return stringType, info{}
+	}
 	if len(v.collections) == 0 {
 		return v.error(node, "cannot use pointer accessor outside closure")
 	}
@@ -756,11 +1190,14 @@ func (v *visitor) PointerNode(node *ast.PointerNode) (reflect.Type, info) {
 
 func (v *visitor) ConditionalNode(node *ast.ConditionalNode) (reflect.Type, info) {
 	c, _ := v.visit(node.Cond)
+	v.strict(node.Cond, c, "condition")
 	if !isBool(c) && !isAny(c) {
 		return v.error(node.Cond, "non-bool expression (type %v) used as condition", c)
 	}
 
+	done := v.narrow(node.Cond)
 	t1, _ := v.visit(node.Exp1)
+	done()
 	t2, _ := v.visit(node.Exp2)
 
 	if t1 == nil && t2 != nil {
@@ -838,3 +1275,57 @@ func (v *visitor) findTypedFunc(node *ast.CallNode, fn reflect.Type, method bool
 		}
 	}
 }
+
+func (v *visitor) ActionsNode(node *ast.ActionsNode) (reflect.Type, info) {
+	for _, assign := range node.Nodes {
+		v.visit(assign)
+	}
+	return mapType, info{}
+}
+
+func (v *visitor) AssignNode(node *ast.AssignNode) (reflect.Type, info) {
+	target, ok := v.config.Types[node.Name]
+	if !ok {
+		if v.config.Strict {
+			return v.error(node, "cannot assign to unknown name %v", node.Name)
+		}
+		t, _ := v.visit(node.Value)
+		return t, info{}
+	}
+	if target.Method || target.Ambiguous || isFunc(target.Type) {
+		return v.error(node, "cannot assign to %v", node.Name)
+	}
+
+	t, _ := v.visit(node.Value)
+	if value, ok := integerLiteral(node.Value); ok && isInteger(target.Type) && !fits(value, target.Type) {
+		return v.error(node, "cannot use %v as %v in assignment to %v", value, target.Type, node.Name)
+	}
+	// Integer literals of the value take the type of float, decimal and
+	// *big.Int targets. Integers are converted to the type of integer
+	// targets at runtime, which fails if they don't fit.
+	if isNumber(target.Type) && !isInteger(target.Type) && isIntegerOrArithmeticOperation(node.Value) {
+		t = target.Type
+		setTypeForIntegers(node.Value, t)
+	}
+	if t != nil && !isAny(t) && !assignable(t, target.Type) {
+		return v.error(node, "cannot use %v as %v in assignment to %v", t, target.Type, node.Name)
+	}
+	return target.Type, info{}
+}
+
+// assignable reports whether a value of type t can be assigned to a variable
+// of type to. Numbers are converted, except floats and decimals to integers,
+// and arrays are converted element by element at runtime.
+func assignable(t, to reflect.Type) bool {
+	switch {
+	case t.AssignableTo(to):
+		return true
+	case isInteger(t):
+		return isNumber(to)
+	case isFloat(t), isDecimal(t):
+		return isFloat(to) || isDecimal(to)
+	case isArray(t):
+		return isArray(to)
+	}
+	return false
+}
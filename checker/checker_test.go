@@ -482,6 +482,11 @@ cannot use int to get an element from map[string]interface {} (1:10)
 invalid operation: + (mismatched types int and string) (1:13)
  | 1 /* one */ + "2"
  | ............^
+
+replaceRegex(String, "x", 1)
+invalid argument for replaceRegex (type int) (1:1)
+ | replaceRegex(String, "x", 1)
+ | ^
 `
 
 func TestCheck_error(t *testing.T) {
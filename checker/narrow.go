@@ -0,0 +1,112 @@
+package checker
+
+import (
+	"reflect"
+
+	"github.com/ilius/expr/ast"
+	"github.com/ilius/expr/vm/runtime"
+)
+
+// narrowing is the result of an `x is name` test.
+type narrowing struct {
+	// t is the type of x, if the test is exact.
+	t reflect.Type
+	// kind is the name of other tests, like array or map.
+	kind string
+}
+
+// narrow records types implied by `x is T` tests in cond (possibly joined
+// with `and`), so nodes visited until the returned func is called see x as T.
+func (v *visitor) narrow(cond ast.Node) func() {
+	tests := make(map[string]narrowing)
+	collectNarrowing(cond, tests)
+	if len(tests) == 0 {
+		return func() {}
+	}
+	v.narrowed = append(v.narrowed, tests)
+	return func() {
+		v.narrowed = v.narrowed[:len(v.narrowed)-1]
+	}
+}
+
+func (v *visitor) tested(node ast.Node) (narrowing, bool) {
+	if len(v.narrowed) == 0 {
+		return narrowing{}, false
+	}
+	key, ok := narrowKey(node)
+	if !ok {
+		return narrowing{}, false
+	}
+	for i := len(v.narrowed) - 1; i >= 0; i-- {
+		if n, ok := v.narrowed[i][key]; ok {
+			return n, true
+		}
+	}
+	return narrowing{}, false
+}
+
+func (v *visitor) narrowedType(node ast.Node) (reflect.Type, bool) {
+	n, _ := v.tested(node)
+	return n.t, n.t != nil
+}
+
+// testedKind reports whether node is tested to be of the kind, like array.
+func (v *visitor) testedKind(node ast.Node, kinds ...string) bool {
+	n, _ := v.tested(node)
+	for _, kind := range kinds {
+		if n.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func collectNarrowing(node ast.Node, tests map[string]narrowing) {
+	n, ok := node.(*ast.BinaryNode)
+	if !ok {
+		return
+	}
+	switch n.Operator {
+	case "and", "&&":
+		collectNarrowing(n.Left, tests)
+		collectNarrowing(n.Right, tests)
+	case "is":
+		key, ok := narrowKey(n.Left)
+		if !ok {
+			return
+		}
+		name, ok := n.Right.(*ast.StringNode)
+		if !ok {
+			return
+		}
+		// Only exact tests narrow: `x is int` is false for a MyInt, but
+		// `x is array` is true for any slice, not only []interface{}.
+		if t, ok := runtime.ExactType(name.Value); ok {
+			tests[key] = narrowing{t: t}
+		} else {
+			tests[key] = narrowing{kind: name.Value}
+		}
+	}
+}
+
+// narrowKey returns a key identifying identifiers and member chains like
+// `a.b.c`, which can be narrowed.
+func narrowKey(node ast.Node) (string, bool) {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		return n.Value, true
+	case *ast.ChainNode:
+		return narrowKey(n.Node)
+	case *ast.MemberNode:
+		prop, ok := n.Property.(*ast.StringNode)
+		if !ok || n.Method {
+			return "", false
+		}
+		base, ok := narrowKey(n.Node)
+		if !ok {
+			return "", false
+		}
+		return base + "." + prop.Value, true
+	}
+	return "", false
+}
@@ -0,0 +1,66 @@
+package checker_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ilius/expr/checker"
+	"github.com/ilius/expr/conf"
+	"github.com/ilius/expr/parser"
+	"github.com/ilius/is/v2"
+)
+
+type narrowEnv struct {
+	Value interface{}
+	Meta  map[string]interface{}
+}
+
+func TestCheck_narrowing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`Value`, "interface {}"},
+		{`type(Value)`, "string"},
+		{`Value is int`, "bool"},
+		{`Value is int ? Value + 1 : 0`, "int"},
+		{`Value is float ? Value : 0.5`, "float64"},
+		{`Value is not int ? 0 : Value`, "int"},
+		{`Value is string && Value startsWith "a"`, "bool"},
+		{`Value is decimal ? Value : 0d`, "runtime.Decimal"},
+		{`Meta.x is float ? Meta.x * 2 : -1.0`, "float64"},
+		// Names other than exact types don't narrow.
+		{`Value is array ? Value : nil`, "interface {}"},
+		// Narrowing ends with the test.
+		{`(Value is int ? 1 : 2) + Value`, "interface {}"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		tree, err := parser.Parse(tt.input)
+		is.Msg(tt.input).NotErr(err)
+
+		typ, err := checker.Check(tree, conf.New(narrowEnv{}))
+		is.Msg(tt.input).NotErr(err)
+		is.Msg(tt.input).Equal(tt.want, typ.String())
+	}
+}
+
+func TestCheck_narrowing_error(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`Value is int && Value + "a" == ""`, "invalid operation: + (mismatched types int and string) (1:23)"},
+		{`Value is string ? Value - 1 : 0`, "invalid operation: - (mismatched types string and int) (1:25)"},
+		{`Value is number`, "unknown type name number (1:10)"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		tree, err := parser.Parse(tt.input)
+		is.Msg(tt.input).NotErr(err)
+
+		_, err = checker.Check(tree, conf.New(narrowEnv{}))
+		is.Msg(tt.input).Err(err)
+		is.Msg(tt.input).Equal(tt.err, strings.Split(err.Error(), "\n")[0])
+	}
+}
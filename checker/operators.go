@@ -0,0 +1,88 @@
+package checker
+
+import (
+	"reflect"
+
+	"github.com/ilius/expr/ast"
+	"github.com/ilius/expr/vm/runtime"
+)
+
+// operatorMethods are methods of the left operand, which implement binary
+// operators for its type, in order of preference.
+var operatorMethods = map[string][]string{
+	"+":  {"Add"},
+	"-":  {"Sub"},
+	"*":  {"Mul"},
+	"/":  {"Div"},
+	"%":  {"Mod"},
+	"==": {"Equal", "Compare"},
+	"!=": {"Equal", "Compare"},
+	"<":  {"Compare"},
+	">":  {"Compare"},
+	"<=": {"Compare"},
+	">=": {"Compare"},
+}
+
+// nativeTypes are named types which operators are implemented by the
+// runtime, even if they have methods like Add or Equal.
+var nativeTypes = map[reflect.Type]bool{
+	timeType:     true,
+	durationType: true,
+	ipType:       true,
+	cidrType:     true,
+	versionType:  true,
+	setType:      true,
+	decimalType:  true,
+	bigIntType:   true,
+}
+
+// operatorMethod resolves the operator of the node to a method of the left
+// operand, like `Add(other T) T` for +, `Equal(other T) bool` for == and
+// `Compare(other T) int` for comparisons. Only named types, which are not
+// handled by the runtime, are overloaded this way.
+func (v *visitor) operatorMethod(node *ast.BinaryNode, l, r reflect.Type) (reflect.Type, bool) {
+	node.Method = nil
+	if l == nil || r == nil || !isNamed(l) || nativeTypes[l] {
+		return nil, false
+	}
+	for _, name := range operatorMethods[node.Operator] {
+		m, ok := l.MethodByName(name)
+		if !ok || m.Type.NumIn() != 2 || m.Type.NumOut() != 1 {
+			continue
+		}
+		in, out := m.Type.In(1), m.Type.Out(0)
+		switch name {
+		case "Equal":
+			if out.Kind() != reflect.Bool {
+				continue
+			}
+			out = boolType
+		case "Compare":
+			if out.Kind() != reflect.Int {
+				continue
+			}
+			out = boolType
+		}
+		if isNumber(in) && isIntegerOrArithmeticOperation(node.Right) {
+			setTypeForIntegers(node.Right, in)
+		} else if !isAny(r) && !r.AssignableTo(in) {
+			continue
+		}
+		node.Method = &runtime.OperatorMethod{
+			Operator: node.Operator,
+			Name:     name,
+			Index:    m.Index,
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// isNamed reports whether t, or the type t points to, is a named type
+// declared in a package, which may have methods.
+func isNamed(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() != reflect.Interface && t.PkgPath() != ""
+}
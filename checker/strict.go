@@ -0,0 +1,47 @@
+package checker
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ilius/expr/ast"
+)
+
+// strict reports an error if StrictTypes is set and node, of type t, is
+// a value of unknown type used where its type must be known. Such values
+// should be converted, like int(x), or tested with the is operator first.
+func (v *visitor) strict(node ast.Node, t reflect.Type, use string) {
+	if v.config.StrictTypes && isAny(t) {
+		v.error(node, "%v", ambiguous(t, use))
+	}
+}
+
+// conversions are builtins which take values of any type, so their arguments
+// don't need to be converted or tested first.
+var conversions = map[string]bool{
+	"int":     true,
+	"float":   true,
+	"decimal": true,
+	"type":    true,
+}
+
+// strictArgument checks an argument of the builtin function.
+func (v *visitor) strictArgument(fn string, node ast.Node, t reflect.Type) {
+	if conversions[fn] || v.testedKind(node, "array", "map") {
+		return
+	}
+	v.strict(node, t, "argument of "+fn)
+}
+
+func ambiguous(t reflect.Type, use string) string {
+	return fmt.Sprintf("ambiguous use of %v value in %v (convert it, like int(x), or test its type with is)", t, use)
+}
+
+// collection visits the collection argument of a loop builtin.
+func (v *visitor) collection(node *ast.BuiltinNode) reflect.Type {
+	t, _ := v.visit(node.Arguments[0])
+	if !v.testedKind(node.Arguments[0], "array") {
+		v.strict(node.Arguments[0], t, "builtin "+node.Name)
+	}
+	return elements(t)
+}
@@ -0,0 +1,78 @@
+package checker_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ilius/expr/checker"
+	"github.com/ilius/expr/conf"
+	"github.com/ilius/expr/parser"
+	"github.com/ilius/is/v2"
+)
+
+type strictEnv struct {
+	Data  map[string]interface{}
+	Inc   func(int) int
+	Limit int
+}
+
+func strictConfig() *conf.Config {
+	config := conf.New(strictEnv{})
+	config.StrictTypes = true
+	return config
+}
+
+func TestCheck_StrictTypes(t *testing.T) {
+	tests := []string{
+		`int(Data.count) + 1`,
+		`Data.count is int and Data.count + 1 > 2`,
+		`Data.name is string ? Data.name + "!" : ""`,
+		`Data.count == 3 && Data.name != nil`,
+		`Inc(int(Data.count)) < Limit`,
+		`Data.items is array and all(Data.items, int(#) > 0)`,
+		`Data.count in [1, 2, 3]`,
+		`type(Data.count)`,
+		`abs(int(Data.count) - 5)`,
+		`Data.next is func ? Data.next() : 0`,
+	}
+	for _, input := range tests {
+		is := is.New(t)
+		tree, err := parser.Parse(input)
+		is.Msg(input).NotErr(err)
+
+		_, err = checker.Check(tree, strictConfig())
+		is.Msg(input).NotErr(err)
+	}
+}
+
+func TestCheck_StrictTypes_error(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`Data.count + 1`, "ambiguous use of interface {} value in operator + (convert it, like int(x), or test its type with is) (1:6)"},
+		{`-Data.count`, "ambiguous use of interface {} value in operator - (convert it, like int(x), or test its type with is) (1:7)"},
+		{`Data.items.first`, "ambiguous use of interface {} value in member access (convert it, like int(x), or test its type with is) (1:6)"},
+		{`Data.count ? 1 : 2`, "ambiguous use of interface {} value in condition (convert it, like int(x), or test its type with is) (1:6)"},
+		{`all(Data.items, # > 0)`, "ambiguous use of interface {} value in builtin all (convert it, like int(x), or test its type with is) (1:10)"},
+		{`Inc(Data.count)`, "ambiguous use of interface {} value in argument of Inc (convert it, like int(x), or test its type with is) (1:10)"},
+		{`1 in Data.items`, "ambiguous use of interface {} value in operator in (convert it, like int(x), or test its type with is) (1:11)"},
+		{`len(Data.items)`, "ambiguous use of interface {} value in argument of len (convert it, like int(x), or test its type with is) (1:10)"},
+		{`abs(Data.count)`, "ambiguous use of interface {} value in argument of abs (convert it, like int(x), or test its type with is) (1:10)"},
+		{`Data.next()`, "ambiguous use of interface {} value in call (convert it, like int(x), or test its type with is) (1:6)"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		tree, err := parser.Parse(tt.input)
+		is.Msg(tt.input).NotErr(err)
+
+		_, err = checker.Check(tree, strictConfig())
+		is.Msg(tt.input).Err(err)
+		is.Msg(tt.input).Equal(tt.err, strings.Split(err.Error(), "\n")[0])
+
+		tree, err = parser.Parse(tt.input)
+		is.Msg(tt.input).NotErr(err)
+		_, err = checker.Check(tree, conf.New(strictEnv{}))
+		is.Msg(tt.input).NotErr(err)
+	}
+}
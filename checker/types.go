@@ -1,11 +1,14 @@
 package checker
 
 import (
+	"math/big"
+	"net"
 	"reflect"
 	"time"
 
 	"github.com/ilius/expr/ast"
 	"github.com/ilius/expr/conf"
+	"github.com/ilius/expr/vm/runtime"
 )
 
 var (
@@ -21,18 +24,154 @@ var (
 	durationType = reflect.TypeOf(time.Duration(0))
 	functionType = reflect.TypeOf(new(func(...interface{}) (interface{}, error))).Elem()
 	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	ipType       = reflect.TypeOf(net.IP{})
+	cidrType     = reflect.TypeOf(&net.IPNet{})
+
+	versionType           = reflect.TypeOf(&runtime.Version{})
+	versionConstraintType = reflect.TypeOf(&runtime.VersionConstraint{})
+	setType               = reflect.TypeOf(runtime.Set{})
+	decimalType           = reflect.TypeOf(runtime.Decimal{})
+	bigIntType            = reflect.TypeOf(&big.Int{})
 )
 
-func combined(a, b reflect.Type) reflect.Type {
-	if a.Kind() == b.Kind() {
-		return a
+// typeNames maps names accepted by the `is` operator to the type of
+// parameters annotated with them. Nil means the name is not a parameter type.
+var typeNames = map[string]reflect.Type{
+	"nil":      nil,
+	"bool":     boolType,
+	"int":      integerType,
+	"int8":     reflect.TypeOf(int8(0)),
+	"int16":    reflect.TypeOf(int16(0)),
+	"int32":    reflect.TypeOf(int32(0)),
+	"int64":    reflect.TypeOf(int64(0)),
+	"uint":     reflect.TypeOf(uint(0)),
+	"uint8":    reflect.TypeOf(uint8(0)),
+	"uint16":   reflect.TypeOf(uint16(0)),
+	"uint32":   reflect.TypeOf(uint32(0)),
+	"uint64":   reflect.TypeOf(uint64(0)),
+	"float32":  reflect.TypeOf(float32(0)),
+	"float":    floatType,
+	"string":   stringType,
+	"array":    arrayType,
+	"map":      mapType,
+	"struct":   nil,
+	"func":     nil,
+	"time":     timeType,
+	"duration": durationType,
+	"decimal":  decimalType,
+	"bigint":   bigIntType,
+}
+
+// TypeByName returns the type of a parameter annotation, like `int` in
+// the signature of a function added with expr.Define.
+func TypeByName(name string) (reflect.Type, bool) {
+	if name == "any" {
+		return anyType, true
 	}
-	if isFloat(a) || isFloat(b) {
+	t, ok := typeNames[name]
+	return t, ok && t != nil
+}
+
+// combined returns the type of an arithmetic operation on numbers of types
+// a and b, following the numeric tower of the runtime:
+//
+//	decimal with any number   -> decimal
+//	float with any number     -> float64
+//	*big.Int with any integer -> *big.Int
+//	integers of the same type -> the same type, if the operation can't
+//	                             overflow or is checked
+//	other integers            -> int
+//
+// The runtime does operations in int with unsigned operands larger than the
+// maximum int with *big.Int instead, so they don't wrap around.
+func (v *visitor) combined(op string, a, b reflect.Type) reflect.Type {
+	switch {
+	case isDecimal(a) || isDecimal(b):
+		return decimalType
+	case isFloat(a) || isFloat(b):
 		return floatType
+	case isBigInt(a) || isBigInt(b):
+		return bigIntType
+	case a == b && isSized(a) && v.keepsSize(op, a):
+		return a
 	}
 	return integerType
 }
 
+// keepsSize reports whether the operation on two integers of the sized type
+// t results in t. Operations which may overflow the type are done in int,
+// as a wrapped around result would be a surprise, unless they are checked.
+func (v *visitor) keepsSize(op string, t reflect.Type) bool {
+	switch {
+	case v.config.CheckedArithmetic, op == "%":
+		return true
+	case op == "/":
+		return isUnsigned(t)
+	}
+	return false
+}
+
+// isSized reports whether t is one of the builtin integer types, which
+// the runtime keeps in results of some operations on two values of the type.
+func isSized(t reflect.Type) bool {
+	return isInteger(t) && t.PkgPath() == "" && t.Name() == t.Kind().String()
+}
+
+func isUnsigned(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// sizeLiteral sets the type of an integer literal to the sized integer type
+// of the other operand, if the literal fits into it and the result of the
+// operation keeps the type. It returns the new type of the operand.
+func (v *visitor) sizeLiteral(op string, node ast.Node, t, other reflect.Type) reflect.Type {
+	if other == nil || other == integerType || !isSized(other) || !v.keepsSize(op, other) {
+		return t
+	}
+	if op == "-" && isUnsigned(other) {
+		return t // Like U - 1, which is negative for U = 0.
+	}
+	value, ok := integerLiteral(node)
+	if !ok || !fits(value, other) {
+		return t
+	}
+	setTypeForIntegers(node, other)
+	node.SetType(other)
+	return other
+}
+
+// integerLiteral returns the value of an integer literal, like 1 or -1.
+func integerLiteral(node ast.Node) (int, bool) {
+	switch n := node.(type) {
+	case *ast.IntegerNode:
+		return n.Value, true
+	case *ast.UnaryNode:
+		i, ok := n.Node.(*ast.IntegerNode)
+		if !ok || (n.Operator != "-" && n.Operator != "+") {
+			return 0, false
+		}
+		if n.Operator == "-" {
+			return -i.Value, true
+		}
+		return i.Value, true
+	}
+	return 0, false
+}
+
+// fits reports whether the integer type t can hold the value.
+func fits(value int, t reflect.Type) bool {
+	zero := reflect.Zero(t)
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return !zero.OverflowInt(int64(value))
+	}
+	return value >= 0 && !zero.OverflowUint(uint64(value))
+}
+
 func anyOf(t reflect.Type, fns ...func(reflect.Type) bool) bool {
 	for _, fn := range fns {
 		if fn(t) {
@@ -65,6 +204,31 @@ func isAny(t reflect.Type) bool {
 	return false
 }
 
+func isIP(t reflect.Type) bool {
+	return t == ipType
+}
+
+func isCIDR(t reflect.Type) bool {
+	return t == cidrType
+}
+
+// isOrdered reports whether values of type t can be sorted.
+func isOrdered(t reflect.Type) bool {
+	return anyOf(t, isNumber, isString, isTime, isVersion, isAny)
+}
+
+func isSet(t reflect.Type) bool {
+	return t == setType
+}
+
+func isVersion(t reflect.Type) bool {
+	return t == versionType
+}
+
+func isVersionConstraint(t reflect.Type) bool {
+	return t == versionConstraintType
+}
+
 func isInteger(t reflect.Type) bool {
 	if t != nil {
 		switch t.Kind() {
@@ -88,7 +252,20 @@ func isFloat(t reflect.Type) bool {
 }
 
 func isNumber(t reflect.Type) bool {
-	return isInteger(t) || isFloat(t)
+	return isInteger(t) || isFloat(t) || isDecimal(t) || isBigInt(t)
+}
+
+// isIntegral reports whether t is an integer or *big.Int.
+func isIntegral(t reflect.Type) bool {
+	return isInteger(t) || isBigInt(t)
+}
+
+func isBigInt(t reflect.Type) bool {
+	return t == bigIntType
+}
+
+func isDecimal(t reflect.Type) bool {
+	return t == decimalType
 }
 
 func isTime(t reflect.Type) bool {
@@ -143,6 +320,24 @@ func isArray(t reflect.Type) bool {
 	return false
 }
 
+func isFetcher(t reflect.Type) bool {
+	return t != nil && t.Implements(runtime.FetcherType)
+}
+
+func isIterable(t reflect.Type) bool {
+	return t != nil && t.Implements(runtime.IterableType)
+}
+
+// elements returns the type of a collection passed to loop builtins. Custom
+// collections implementing runtime.Iterable are arrays of unknown elements,
+// even if they are slices, as the VM iterates them with Index.
+func elements(t reflect.Type) reflect.Type {
+	if isIterable(t) {
+		return arrayType
+	}
+	return t
+}
+
 func isMap(t reflect.Type) bool {
 	if t != nil {
 		switch t.Kind() {
@@ -179,6 +374,21 @@ func isFunc(t reflect.Type) bool {
 	return false
 }
 
+// isLazy reports whether t is func() T or func() (T, error), the type of
+// lazy values.
+func isLazy(t reflect.Type) bool {
+	if t.Kind() != reflect.Func || t.NumIn() != 0 {
+		return false
+	}
+	switch t.NumOut() {
+	case 1:
+		return true
+	case 2:
+		return t.Out(1) == errorType
+	}
+	return false
+}
+
 func fetchField(t reflect.Type, name string) (reflect.StructField, bool) {
 	if t != nil {
 		// First check all structs fields.
@@ -204,6 +414,36 @@ func fetchField(t reflect.Type, name string) (reflect.StructField, bool) {
 	return reflect.StructField{}, false
 }
 
+// hasField reports whether a struct, or a pointer to struct, has the field
+// named by the property.
+func hasField(t reflect.Type, property ast.Node) bool {
+	name, ok := property.(*ast.StringNode)
+	if !ok {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	_, ok = fetchField(t, name.Value)
+	return ok
+}
+
+// isIndexable reports whether values of type t, or pointed by t, have
+// builtin [] access.
+func isIndexable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Array, reflect.Slice, reflect.String, reflect.Map:
+		return true
+	}
+	return false
+}
+
 func deref(t reflect.Type) (reflect.Type, bool) {
 	if t == nil {
 		return nil, false
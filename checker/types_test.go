@@ -0,0 +1,110 @@
+package checker_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ilius/expr/checker"
+	"github.com/ilius/expr/conf"
+	"github.com/ilius/expr/parser"
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+type numericEnv struct {
+	U8    uint8
+	U32   uint32
+	I8    int8
+	Int   int
+	Float float64
+	Dec   runtime.Decimal
+	Big   *big.Int
+}
+
+func TestCheck_numeric_types(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Operations which can overflow the sized type result in int.
+		{`U32 + U32`, "int"},
+		{`U32 + 1`, "int"},
+		{`U32 * 2`, "int"},
+		{`U8 - U8`, "int"},
+		{`U32 + Int`, "int"},
+		{`U32 + I8`, "int"},
+		// Others keep it, and a literal which fits takes the type.
+		{`U32 % U32`, "uint32"},
+		{`U32 % 2`, "uint32"},
+		{`2 % U32`, "uint32"},
+		{`I8 % -2`, "int8"},
+		{`U8 % 256`, "int"},
+		{`U32 % Int`, "int"},
+		{`U32 / 2`, "float64"},
+		{`U32 + 1.5`, "float64"},
+		{`Dec + U32`, "runtime.Decimal"},
+		{`Float * Dec`, "runtime.Decimal"},
+		{`Dec ** 2`, "runtime.Decimal"},
+		{`Big - 1`, "*big.Int"},
+		{`Big * U8`, "*big.Int"},
+		{`Big / 2`, "float64"},
+		{`Big + Float`, "float64"},
+		{`Big + Dec`, "runtime.Decimal"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		tree, err := parser.Parse(tt.input)
+		is.Msg(tt.input).NotErr(err)
+		got, err := checker.Check(tree, conf.New(numericEnv{}))
+		is.Msg(tt.input).NotErr(err)
+		is.Msg(tt.input).Equal(tt.want, got.String())
+	}
+}
+
+func TestCheck_numeric_types_IntegerDivision(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`U32 / U32`, "uint32"},
+		{`U32 / 2`, "uint32"},
+		{`I8 / I8`, "int"},
+		{`Int / 2`, "int"},
+		{`Int / Float`, "float64"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		tree, err := parser.Parse(tt.input)
+		is.Msg(tt.input).NotErr(err)
+		config := conf.New(numericEnv{})
+		config.IntegerDivision = true
+		got, err := checker.Check(tree, config)
+		is.Msg(tt.input).NotErr(err)
+		is.Msg(tt.input).Equal(tt.want, got.String())
+	}
+}
+
+func TestCheck_numeric_types_CheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`U32 + U32`, "uint32"},
+		{`U32 * 2 + U32`, "uint32"},
+		{`I8 + -1`, "int8"},
+		// U32 - 1 is negative for U32 = 0.
+		{`U32 - 1`, "int"},
+		{`U8 + 256`, "int"},
+		{`U32 + I8`, "int"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		tree, err := parser.Parse(tt.input)
+		is.Msg(tt.input).NotErr(err)
+		config := conf.New(numericEnv{})
+		config.CheckedArithmetic = true
+		got, err := checker.Check(tree, config)
+		is.Msg(tt.input).NotErr(err)
+		is.Msg(tt.input).Equal(tt.want, got.String())
+	}
+}
@@ -2,9 +2,12 @@ package compiler
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
+	"strings"
 
 	"github.com/ilius/expr/ast"
+	"github.com/ilius/expr/builtin"
 	"github.com/ilius/expr/conf"
 	"github.com/ilius/expr/file"
 	"github.com/ilius/expr/parser"
@@ -16,6 +19,11 @@ const (
 	placeholder = 12345
 )
 
+var (
+	decimalType = reflect.TypeOf(runtime.Decimal{})
+	bigIntType  = reflect.TypeOf(&big.Int{})
+)
+
 func Compile(tree *parser.Tree, config *conf.Config) (program *Program, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -24,14 +32,25 @@ func Compile(tree *parser.Tree, config *conf.Config) (program *Program, err erro
 	}()
 
 	c := &compiler{
-		locations:      make([]file.Location, 0),
-		constantsIndex: make(map[interface{}]int),
-		functionsIndex: make(map[string]int),
+		locations:        make([]file.Location, 0),
+		constantsIndex:   make(map[interface{}]int),
+		functionsIndex:   make(map[string]int),
+		definitionsIndex: make(map[string]int),
+		assigned:         make(map[string]bool),
 	}
 
 	if config != nil {
 		c.mapEnv = config.MapEnv
 		c.cast = config.Expect
+		c.definition = config.Definition
+		c.allDefinitions = config.Definitions
+		c.mutateEnv = config.MutateEnv
+		if config.CheckedArithmetic {
+			c.arithmetic |= ArithmeticChecked
+		}
+		if config.IntegerDivision {
+			c.arithmetic |= ArithmeticIntegerDivision
+		}
 	}
 
 	c.compile(tree.Node)
@@ -46,13 +65,14 @@ func Compile(tree *parser.Tree, config *conf.Config) (program *Program, err erro
 	}
 
 	program = &Program{
-		Node:      tree.Node,
-		Source:    tree.Source,
-		Locations: c.locations,
-		Constants: c.constants,
-		Bytecode:  c.bytecode,
-		Arguments: c.arguments,
-		Functions: c.functions,
+		Node:        tree.Node,
+		Source:      tree.Source,
+		Locations:   c.locations,
+		Constants:   c.constants,
+		Bytecode:    c.bytecode,
+		Arguments:   c.arguments,
+		Functions:   c.functions,
+		Definitions: c.definitions,
 	}
 	return
 }
@@ -69,6 +89,21 @@ type compiler struct {
 	nodes          []ast.Node
 	chains         [][]int
 	arguments      []int
+
+	// definition is set when compiling the body of a function added
+	// with expr.Define.
+	definition       *Definition
+	allDefinitions   map[string]*Definition
+	definitions      []*Definition
+	definitionsIndex map[string]int
+
+	// assigned are names assigned by actions compiled so far, which
+	// are loaded from the changes instead of the environment.
+	assigned  map[string]bool
+	mutateEnv bool
+
+	// arithmetic are flags of arithmetic opcodes, like vm.ArithmeticChecked.
+	arithmetic int
 }
 
 func (c *compiler) emitLocation(loc file.Location, op Opcode, arg int) int {
@@ -139,6 +174,21 @@ func (c *compiler) addFunction(node *ast.CallNode) int {
 	return p
 }
 
+func (c *compiler) addDefinition(node *ast.CallNode) int {
+	name := node.Callee.(*ast.IdentifierNode).Value
+	if p, ok := c.definitionsIndex[name]; ok {
+		return p
+	}
+	d, ok := c.allDefinitions[name]
+	if !ok {
+		panic(fmt.Sprintf("unknown definition %v", name))
+	}
+	p := len(c.definitions)
+	c.definitions = append(c.definitions, d)
+	c.definitionsIndex[name] = p
+	return p
+}
+
 func (c *compiler) patchJump(placeholder int) {
 	offset := len(c.bytecode) - placeholder
 	c.arguments[placeholder-1] = offset
@@ -163,6 +213,8 @@ func (c *compiler) compile(node ast.Node) {
 		c.IntegerNode(n)
 	case *ast.FloatNode:
 		c.FloatNode(n)
+	case *ast.DecimalNode:
+		c.DecimalNode(n)
 	case *ast.BoolNode:
 		c.BoolNode(n)
 	case *ast.StringNode:
@@ -195,6 +247,10 @@ func (c *compiler) compile(node ast.Node) {
 		c.MapNode(n)
 	case *ast.PairNode:
 		c.PairNode(n)
+	case *ast.ActionsNode:
+		c.ActionsNode(n)
+	case *ast.AssignNode:
+		c.AssignNode(n)
 	default:
 		panic(fmt.Sprintf("undefined node type (%T)", node))
 	}
@@ -205,6 +261,16 @@ func (c *compiler) NilNode(_ *ast.NilNode) {
 }
 
 func (c *compiler) IdentifierNode(node *ast.IdentifierNode) {
+	if c.definition != nil {
+		if i := c.definition.Param(node.Value); i >= 0 {
+			c.emit(OpLoadParam, i)
+			return
+		}
+	}
+	if c.assigned[node.Value] {
+		c.emit(OpLoadChange, c.addConstant(node.Value))
+		return
+	}
 	if c.mapEnv {
 		c.emit(OpLoadFast, c.addConstant(node.Value))
 	} else if len(node.FieldIndex) > 0 {
@@ -220,6 +286,9 @@ func (c *compiler) IdentifierNode(node *ast.IdentifierNode) {
 	} else {
 		c.emit(OpLoadConst, c.addConstant(node.Value))
 	}
+	if node.Lazy {
+		c.emitLazy(node)
+	}
 	if node.Deref {
 		c.emit(OpDeref)
 	} else if node.Type() == nil {
@@ -229,6 +298,14 @@ func (c *compiler) IdentifierNode(node *ast.IdentifierNode) {
 
 func (c *compiler) IntegerNode(node *ast.IntegerNode) {
 	t := node.Type()
+	switch t {
+	case decimalType:
+		c.emitPush(runtime.ToDecimal(node.Value))
+		return
+	case bigIntType:
+		c.emitPush(big.NewInt(int64(node.Value)))
+		return
+	}
 	if t == nil {
 		c.emitPush(node.Value)
 		return
@@ -264,6 +341,14 @@ func (c *compiler) IntegerNode(node *ast.IntegerNode) {
 }
 
 func (c *compiler) FloatNode(node *ast.FloatNode) {
+	if node.Type() == decimalType {
+		c.emitPush(runtime.ToDecimal(node.Value))
+		return
+	}
+	c.emitPush(node.Value)
+}
+
+func (c *compiler) DecimalNode(node *ast.DecimalNode) {
 	c.emitPush(node.Value)
 }
 
@@ -295,7 +380,7 @@ func (c *compiler) UnaryNode(node *ast.UnaryNode) {
 		// Do nothing
 
 	case "-":
-		c.emit(OpNegate)
+		c.emit(OpNegate, c.arithmetic&ArithmeticChecked)
 
 	default:
 		panic(fmt.Sprintf("unknown operator (%v)", node.Operator))
@@ -303,6 +388,13 @@ func (c *compiler) UnaryNode(node *ast.UnaryNode) {
 }
 
 func (c *compiler) BinaryNode(node *ast.BinaryNode) {
+	if node.Method != nil {
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpCallOperator, c.addConstant(node.Method))
+		return
+	}
+
 	l := kind(node.Left)
 	r := kind(node.Right)
 
@@ -325,6 +417,11 @@ func (c *compiler) BinaryNode(node *ast.BinaryNode) {
 		c.emit(OpEqual)
 		c.emit(OpNot)
 
+	case "is":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpIs)
+
 	case "or", "||":
 		c.compile(node.Left)
 		end := c.emit(OpJumpIfTrue, placeholder)
@@ -362,22 +459,22 @@ func (c *compiler) BinaryNode(node *ast.BinaryNode) {
 	case "+":
 		c.compile(node.Left)
 		c.compile(node.Right)
-		c.emit(OpAdd)
+		c.emit(OpAdd, c.arithmetic&ArithmeticChecked)
 
 	case "-":
 		c.compile(node.Left)
 		c.compile(node.Right)
-		c.emit(OpSubtract)
+		c.emit(OpSubtract, c.arithmetic&ArithmeticChecked)
 
 	case "*":
 		c.compile(node.Left)
 		c.compile(node.Right)
-		c.emit(OpMultiply)
+		c.emit(OpMultiply, c.arithmetic&ArithmeticChecked)
 
 	case "/":
 		c.compile(node.Left)
 		c.compile(node.Right)
-		c.emit(OpDivide)
+		c.emit(OpDivide, c.arithmetic)
 
 	case "%":
 		c.compile(node.Left)
@@ -404,6 +501,26 @@ func (c *compiler) BinaryNode(node *ast.BinaryNode) {
 			c.emit(OpMatches)
 		}
 
+	case "|":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpUnion)
+
+	case "&":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpIntersect)
+
+	case "subsetOf":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpSubsetOf)
+
+	case "supersetOf":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpSupersetOf)
+
 	case "contains":
 		c.compile(node.Left)
 		c.compile(node.Right)
@@ -458,7 +575,7 @@ func (c *compiler) MemberNode(node *ast.MemberNode) {
 		op = OpFetchField
 		for !node.Optional {
 			ident, ok := base.(*ast.IdentifierNode)
-			if ok && len(ident.FieldIndex) > 0 {
+			if ok && len(ident.FieldIndex) > 0 && !ident.Lazy {
 				if ident.Deref {
 					panic("IdentifierNode should not be dereferenced")
 				}
@@ -470,7 +587,7 @@ func (c *compiler) MemberNode(node *ast.MemberNode) {
 				goto deref
 			}
 			member, ok := base.(*ast.MemberNode)
-			if ok && len(member.FieldIndex) > 0 {
+			if ok && len(member.FieldIndex) > 0 && !member.Lazy {
 				if member.Deref {
 					panic("MemberNode should not be dereferenced")
 				}
@@ -500,6 +617,9 @@ func (c *compiler) MemberNode(node *ast.MemberNode) {
 	}
 
 deref:
+	if original.Lazy {
+		c.emitLazy(original)
+	}
 	if original.Deref {
 		c.emit(OpDeref)
 	} else if original.Type() == nil {
@@ -523,11 +643,21 @@ func (c *compiler) SliceNode(node *ast.SliceNode) {
 }
 
 func (c *compiler) CallNode(node *ast.CallNode) {
+	if c.takeFromFilter(node) {
+		return
+	}
 	for _, arg := range node.Arguments {
 		c.compile(arg)
 	}
+	if node.Definition {
+		c.emit(OpCallDefinition, c.addDefinition(node))
+		return
+	}
 	if node.Func != nil {
 		if node.Func.Opcode > 0 {
+			if node.Func.Opcode == builtin.Get && len(node.Arguments) == 2 {
+				c.emit(OpNil) // default value
+			}
 			c.emit(OpBuiltin, node.Func.Opcode)
 			return
 		}
@@ -557,6 +687,46 @@ func (c *compiler) CallNode(node *ast.CallNode) {
 	}
 }
 
+// takeFromFilter compiles take(filter(array, predicate), n) with a constant n
+// into a loop which stops after n elements are found.
+func (c *compiler) takeFromFilter(node *ast.CallNode) bool {
+	if node.Func == nil || node.Func.Opcode != builtin.Take || len(node.Arguments) != 2 {
+		return false
+	}
+	filter, ok := node.Arguments[0].(*ast.BuiltinNode)
+	if !ok || filter.Name != "filter" {
+		return false
+	}
+	n, ok := node.Arguments[1].(*ast.IntegerNode)
+	if !ok {
+		return false
+	}
+
+	c.compile(filter.Arguments[0])
+	c.emit(OpBegin)
+	var loopBreak int
+	c.emitLoop(func() {
+		c.emit(OpGetCount)
+		c.emitPush(n.Value)
+		c.emit(OpMoreOrEqual)
+		loopBreak = c.emit(OpJumpIfTrue, placeholder)
+		c.emit(OpPop)
+		c.compile(filter.Arguments[1])
+		c.emitCond(func() {
+			c.emit(OpIncrementCount)
+			c.emit(OpPointer)
+		})
+	})
+	end := c.emit(OpJump, placeholder)
+	c.patchJump(loopBreak)
+	c.emit(OpPop)
+	c.patchJump(end)
+	c.emit(OpGetCount)
+	c.emit(OpEnd)
+	c.emit(OpArray)
+	return true
+}
+
 func (c *compiler) BuiltinNode(node *ast.BuiltinNode) {
 	switch node.Name {
 	case "all":
@@ -649,11 +819,89 @@ func (c *compiler) BuiltinNode(node *ast.BuiltinNode) {
 		c.emit(OpGetCount)
 		c.emit(OpEnd)
 
+	case "first":
+		// The optimizer adds a third argument, which maps the found element.
+		c.compile(node.Arguments[0])
+		c.emit(OpBegin)
+		var loopBreak int
+		c.emitLoop(func() {
+			if len(node.Arguments) > 1 {
+				c.compile(node.Arguments[1])
+			} else {
+				c.emit(OpTrue)
+			}
+			loopBreak = c.emit(OpJumpIfTrue, placeholder)
+			c.emit(OpPop)
+		})
+		c.emit(OpNil)
+		end := c.emit(OpJump, placeholder)
+		c.patchJump(loopBreak)
+		c.emit(OpPop)
+		if len(node.Arguments) > 2 {
+			c.compile(node.Arguments[2])
+		} else {
+			c.emit(OpPointer)
+		}
+		c.patchJump(end)
+		c.emit(OpEnd)
+
+	case "takeWhile":
+		c.compile(node.Arguments[0])
+		c.emit(OpBegin)
+		var loopBreak int
+		c.emitLoop(func() {
+			c.compile(node.Arguments[1])
+			loopBreak = c.emit(OpJumpIfFalse, placeholder)
+			c.emit(OpPop)
+			c.emit(OpIncrementCount)
+			c.emit(OpPointer)
+		})
+		end := c.emit(OpJump, placeholder)
+		c.patchJump(loopBreak)
+		c.emit(OpPop)
+		c.patchJump(end)
+		c.emit(OpGetCount)
+		c.emit(OpEnd)
+		c.emit(OpArray)
+
+	case "sort", "sortDesc":
+		c.compile(node.Arguments[0])
+		c.emit(OpSort, sortOrder(node.Name))
+
+	case "sortBy", "sortByDesc":
+		c.compile(node.Arguments[0])
+		c.emit(OpBegin)
+		c.emitLoop(func() {
+			for _, arg := range node.Arguments[1:] {
+				c.compile(arg)
+			}
+		})
+		c.emitPush(len(node.Arguments) - 1)
+		c.emit(OpSortBy, sortOrder(node.Name))
+		c.emit(OpEnd)
+
+	case "try":
+		try := c.emit(OpTry, placeholder)
+		c.compile(node.Arguments[0])
+		end := c.emit(OpTryEnd, placeholder)
+		c.patchJump(try)
+		c.compile(node.Arguments[1])
+		c.emit(OpCatchEnd)
+		c.patchJump(end)
+
 	default:
 		panic(fmt.Sprintf("unknown builtin %v", node.Name))
 	}
 }
 
+// sortOrder returns the argument of OpSort and OpSortBy: 1 for descending order.
+func sortOrder(name string) int {
+	if strings.HasSuffix(name, "Desc") {
+		return 1
+	}
+	return 0
+}
+
 func (c *compiler) emitCond(body func()) {
 	noop := c.emit(OpJumpIfFalse, placeholder)
 	c.emit(OpPop)
@@ -682,6 +930,10 @@ func (c *compiler) ClosureNode(node *ast.ClosureNode) {
 }
 
 func (c *compiler) PointerNode(node *ast.PointerNode) {
+	if node.Name == "error" {
+		c.emit(OpError)
+		return
+	}
 	c.emit(OpPointer)
 }
 
@@ -730,3 +982,52 @@ func kind(node ast.Node) reflect.Kind {
 	}
 	return t.Kind()
 }
+
+func (c *compiler) ActionsNode(node *ast.ActionsNode) {
+	for _, assign := range node.Nodes {
+		c.compile(assign)
+	}
+	if c.mutateEnv {
+		c.emit(OpMutate)
+	}
+	c.emit(OpChanges)
+}
+
+func (c *compiler) AssignNode(node *ast.AssignNode) {
+	c.compile(node.Value)
+	c.emit(OpAssign, c.addConstant(&runtime.Target{
+		Name: node.Name,
+		Type: node.Type(),
+	}))
+	c.assigned[node.Name] = true
+}
+
+// emitLazy calls the lazy value on top of the stack. Values of variables
+// of env, and of their fields, like User.Profile, are the same in a run, so
+// they are called once, and the result is reused.
+func (c *compiler) emitLazy(node ast.Node) {
+	if key, ok := c.lazyKey(node); ok {
+		c.emit(OpLazy, c.addConstant(key))
+	} else {
+		c.emit(OpInvoke)
+	}
+}
+
+func (c *compiler) lazyKey(node ast.Node) (string, bool) {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		if c.assigned[n.Value] || (c.definition != nil && c.definition.Param(n.Value) >= 0) {
+			return "", false
+		}
+		return n.Value, true
+	case *ast.ChainNode:
+		return c.lazyKey(n.Node)
+	case *ast.MemberNode:
+		if n.Name == "" || n.Method {
+			return "", false
+		}
+		base, ok := c.lazyKey(n.Node)
+		return base + "." + n.Name, ok
+	}
+	return "", false
+}
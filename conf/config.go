@@ -6,6 +6,7 @@ import (
 
 	"github.com/ilius/expr/ast"
 	"github.com/ilius/expr/builtin"
+	"github.com/ilius/expr/vm"
 	"github.com/ilius/expr/vm/runtime"
 )
 
@@ -21,15 +22,51 @@ type Config struct {
 	ConstFns    map[string]reflect.Value
 	Visitors    []ast.Visitor
 	Functions   map[string]*builtin.Function
+	Definitions map[string]*vm.Definition
+	// Definition is set while compiling the body of a definition, so its
+	// parameters can be used.
+	Definition *vm.Definition
+	// Libraries are names of libraries, which definitions are added to
+	// Definitions as "library.name".
+	Libraries map[string]bool
+	// LibraryTypes are types of the environments libraries are compiled
+	// with, which must match Types.
+	LibraryTypes map[string]TypesTable
+	// Imports are libraries imported by every expression, in addition to
+	// imports of the expression itself.
+	Imports []string
+	// Actions is true if the input is a list of assignments, which result
+	// is a map of changes. With MutateEnv, the changes are also set in env.
+	Actions   bool
+	MutateEnv bool
+	// IntegerDivision makes the / operator of integers return an integer.
+	IntegerDivision bool
+	// CheckedArithmetic makes integer overflow a runtime.OverflowError
+	// instead of wrapping around.
+	CheckedArithmetic bool
+	// DecimalLiterals makes float literals decimals, as if written as 1.5d.
+	DecimalLiterals bool
+	// LazyValues makes env variables of type func() T values of type T,
+	// called on first access.
+	LazyValues bool
+	// StrictTypes forbids operations on values of interface{} type, which
+	// type is known only at run time.
+	StrictTypes bool
+	// Err is the first error of options, which can't return it, like an
+	// invalid snapshot. It is returned by expr.Compile.
+	Err error
 }
 
 // CreateNew creates new config with default values.
 func CreateNew() *Config {
 	c := &Config{
-		Operators: make(map[string][]string),
-		ConstFns:  make(map[string]reflect.Value),
-		Functions: make(map[string]*builtin.Function),
-		Optimize:  true,
+		Operators:    make(map[string][]string),
+		ConstFns:     make(map[string]reflect.Value),
+		Functions:    make(map[string]*builtin.Function),
+		Definitions:  make(map[string]*vm.Definition),
+		Libraries:    make(map[string]bool),
+		LibraryTypes: make(map[string]TypesTable),
+		Optimize:     true,
 	}
 	for _, f := range builtin.Builtins {
 		c.Functions[f.Name] = f
@@ -37,6 +74,14 @@ func CreateNew() *Config {
 	return c
 }
 
+// SetError records err as the error of the config, unless there is already
+// an error.
+func (c *Config) SetError(err error) {
+	if c.Err == nil {
+		c.Err = err
+	}
+}
+
 // New creates new config with environment.
 func New(env interface{}) *Config {
 	c := CreateNew()
@@ -63,7 +108,8 @@ func (c *Config) WithEnv(env interface{}) {
 }
 
 func (c *Config) Operator(operator string, fns ...string) {
-	c.Operators[operator] = append(c.Operators[operator], fns...)
+	key := operatorKey(operator)
+	c.Operators[key] = append(c.Operators[key], fns...)
 }
 
 func (c *Config) ConstExpr(name string) {
@@ -84,11 +130,11 @@ func (c *Config) Check() {
 			if !ok || fnType.Type.Kind() != reflect.Func {
 				panic(fmt.Errorf("function %s for %s operator does not exist in the environment", fn, operator))
 			}
-			requiredNumIn := 2
+			numIn := fnType.Type.NumIn()
 			if fnType.Method {
-				requiredNumIn = 3 // As first argument of method is receiver.
+				numIn-- // As first argument of method is receiver.
 			}
-			if fnType.Type.NumIn() != requiredNumIn || fnType.Type.NumOut() != 1 {
+			if !validArity(operator, numIn) || fnType.Type.NumOut() != 1 {
 				panic(fmt.Errorf("function %s for %s operator does not have a correct signature", fn, operator))
 			}
 		}
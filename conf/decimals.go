@@ -0,0 +1,16 @@
+package conf
+
+import (
+	"github.com/ilius/expr/ast"
+	"github.com/ilius/expr/vm/runtime"
+)
+
+// DecimalPatcher replaces float literals with decimal literals, so 0.1 is
+// exactly 0.1d. It is used if Config.DecimalLiterals is set.
+type DecimalPatcher struct{}
+
+func (DecimalPatcher) Visit(node *ast.Node) {
+	if n, ok := (*node).(*ast.FloatNode); ok {
+		ast.Patch(node, &ast.DecimalNode{Value: runtime.ToDecimal(n.Value)})
+	}
+}
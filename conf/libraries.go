@@ -0,0 +1,120 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ilius/expr/ast"
+	"github.com/ilius/expr/file"
+	"github.com/ilius/expr/vm"
+)
+
+// LibraryPatcher resolves names of definitions of libraries: `lib.name` and
+// names imported with `import "lib"` become identifiers "lib.name". Named
+// expressions become calls, as their bodies are evaluated on every use.
+// Parameters and variables of the environment shadow imported names.
+type LibraryPatcher struct {
+	Definitions map[string]*vm.Definition
+	Libraries   map[string]bool
+	Types       TypesTable
+	Params      *vm.Definition
+	// Imports maps imported names to "lib.name", or to "" if the name
+	// is imported from more than one library.
+	Imports map[string]string
+	Err     *file.Error
+}
+
+func NewLibraryPatcher(c *Config, imports []string) (*LibraryPatcher, error) {
+	p := &LibraryPatcher{
+		Definitions: c.Definitions,
+		Libraries:   c.Libraries,
+		Types:       c.Types,
+		Params:      c.Definition,
+		Imports:     make(map[string]string),
+	}
+	for _, lib := range imports {
+		if !c.Libraries[lib] {
+			return nil, fmt.Errorf("unknown library %v", lib)
+		}
+		prefix := lib + "."
+		for qualified := range c.Definitions {
+			if !strings.HasPrefix(qualified, prefix) {
+				continue
+			}
+			name := strings.TrimPrefix(qualified, prefix)
+			if other, ok := p.Imports[name]; ok && other != qualified {
+				p.Imports[name] = ""
+			} else {
+				p.Imports[name] = qualified
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *LibraryPatcher) Visit(node *ast.Node) {
+	var name string
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		if p.Params != nil && p.Params.Param(n.Value) >= 0 {
+			return
+		}
+		if d, ok := p.Definitions[n.Value]; ok {
+			if !d.Value {
+				return
+			}
+			name = n.Value
+		} else if qualified, ok := p.Imports[n.Value]; ok && !p.shadowed(n.Value) {
+			if qualified == "" {
+				p.error(n, "ambiguous identifier %v", n.Value)
+				return
+			}
+			name = qualified
+		} else {
+			return
+		}
+
+	case *ast.MemberNode:
+		lib, ok := n.Node.(*ast.IdentifierNode)
+		if !ok || !p.Libraries[lib.Value] || p.shadowed(lib.Value) {
+			return
+		}
+		property, ok := n.Property.(*ast.StringNode)
+		if !ok {
+			return
+		}
+		name = lib.Value + "." + property.Value
+		if _, ok := p.Definitions[name]; !ok {
+			p.error(n, "unknown name %v in library %v", property.Value, lib.Value)
+			return
+		}
+
+	default:
+		return
+	}
+
+	identifier := &ast.IdentifierNode{Value: name}
+	identifier.SetLocation((*node).Location())
+	if p.Definitions[name].Value {
+		ast.Patch(node, &ast.CallNode{Callee: identifier})
+	} else {
+		ast.Patch(node, identifier)
+	}
+}
+
+func (p *LibraryPatcher) shadowed(name string) bool {
+	if p.Params != nil && p.Params.Param(name) >= 0 {
+		return true
+	}
+	_, ok := p.Types[name]
+	return ok
+}
+
+func (p *LibraryPatcher) error(node ast.Node, format string, args ...interface{}) {
+	if p.Err == nil {
+		p.Err = &file.Error{
+			Location: node.Location(),
+			Message:  fmt.Sprintf(format, args...),
+		}
+	}
+}
@@ -6,10 +6,39 @@ import (
 	"github.com/ilius/expr/ast"
 )
 
-// OperatorsTable maps binary operators to corresponding list of functions.
+// OperatorsTable maps operators to corresponding list of functions.
 // Functions should be provided in the environment to allow operator overloading.
+// Binary operators, including "in" and "contains", and "[]" for index access
+// are replaced with functions of two arguments. Unary "-", "+" and "not" are
+// replaced with functions of one argument.
 type OperatorsTable map[string][]string
 
+// Functions returns functions overloading the operator. The "!" operator is
+// the same as "not".
+func (t OperatorsTable) Functions(operator string) ([]string, bool) {
+	fns, ok := t[operatorKey(operator)]
+	return fns, ok
+}
+
+func operatorKey(operator string) string {
+	if operator == "!" {
+		return "not"
+	}
+	return operator
+}
+
+// validArity reports whether a function with n arguments can overload
+// the operator.
+func validArity(operator string, n int) bool {
+	switch operatorKey(operator) {
+	case "not":
+		return n == 1
+	case "-", "+":
+		return n == 1 || n == 2
+	}
+	return n == 2
+}
+
 func FindSuitableOperatorOverload(fns []string, types TypesTable, l, r reflect.Type) (reflect.Type, string, bool) {
 	for _, fn := range fns {
 		fnType := types[fn]
@@ -17,6 +46,9 @@ func FindSuitableOperatorOverload(fns []string, types TypesTable, l, r reflect.T
 		if fnType.Method {
 			firstInIndex = 1 // As first argument to method is receiver.
 		}
+		if fnType.Type.NumIn() != firstInIndex+2 {
+			continue // Overloads unary operator.
+		}
 		firstArgType := fnType.Type.In(firstInIndex)
 		secondArgType := fnType.Type.In(firstInIndex + 1)
 
@@ -29,31 +61,69 @@ func FindSuitableOperatorOverload(fns []string, types TypesTable, l, r reflect.T
 	return nil, "", false
 }
 
+// FindSuitableUnaryOverload is like FindSuitableOperatorOverload for unary
+// operators, which are overloaded by functions of one argument.
+func FindSuitableUnaryOverload(fns []string, types TypesTable, t reflect.Type) (reflect.Type, string, bool) {
+	for _, fn := range fns {
+		fnType := types[fn]
+		firstInIndex := 0
+		if fnType.Method {
+			firstInIndex = 1 // As first argument to method is receiver.
+		}
+		if fnType.Type.NumIn() != firstInIndex+1 {
+			continue
+		}
+		argType := fnType.Type.In(firstInIndex)
+		if t == argType || (argType.Kind() == reflect.Interface && (t == nil || t.Implements(argType))) {
+			return fnType.Type.Out(0), fn, true
+		}
+	}
+	return nil, "", false
+}
+
 type OperatorPatcher struct {
 	Operators OperatorsTable
 	Types     TypesTable
 }
 
 func (p *OperatorPatcher) Visit(node *ast.Node) {
-	binaryNode, ok := (*node).(*ast.BinaryNode)
-	if !ok {
-		return
-	}
-
-	fns, ok := p.Operators[binaryNode.Operator]
-	if !ok {
-		return
-	}
+	switch n := (*node).(type) {
+	case *ast.BinaryNode:
+		fns, ok := p.Operators.Functions(n.Operator)
+		if !ok {
+			return
+		}
+		_, fn, ok := FindSuitableOperatorOverload(fns, p.Types, n.Left.Type(), n.Right.Type())
+		if ok {
+			p.patch(node, fn, n.Left, n.Right)
+		}
 
-	leftType := binaryNode.Left.Type()
-	rightType := binaryNode.Right.Type()
+	case *ast.UnaryNode:
+		fns, ok := p.Operators.Functions(n.Operator)
+		if !ok {
+			return
+		}
+		_, fn, ok := FindSuitableUnaryOverload(fns, p.Types, n.Node.Type())
+		if ok {
+			p.patch(node, fn, n.Node)
+		}
 
-	_, fn, ok := FindSuitableOperatorOverload(fns, p.Types, leftType, rightType)
-	if ok {
-		newNode := &ast.CallNode{
-			Callee:    &ast.IdentifierNode{Value: fn},
-			Arguments: []ast.Node{binaryNode.Left, binaryNode.Right},
+	case *ast.MemberNode:
+		fns, ok := p.Operators.Functions("[]")
+		if !ok || n.Method {
+			return
 		}
-		ast.Patch(node, newNode)
+		_, fn, ok := FindSuitableOperatorOverload(fns, p.Types, n.Node.Type(), n.Property.Type())
+		if ok {
+			p.patch(node, fn, n.Node, n.Property)
+		}
+	}
+}
+
+func (p *OperatorPatcher) patch(node *ast.Node, fn string, arguments ...ast.Node) {
+	newNode := &ast.CallNode{
+		Callee:    &ast.IdentifierNode{Value: fn},
+		Arguments: arguments,
 	}
+	ast.Patch(node, newNode)
 }
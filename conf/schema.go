@@ -0,0 +1,223 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/ilius/expr/vm/runtime"
+)
+
+// Schema describes the environment, or a value in it, without a Go value,
+// so expressions can be checked where the Go types are not available. It
+// is a subset of JSON Schema: types "boolean", "integer", "number",
+// "string", "array" and "object", and keywords "properties",
+// "additionalProperties" and "items". A value without a type is of any type.
+//
+// Formats "date-time", "duration", "decimal" and "bigint" describe values of
+// time.Time, time.Duration, runtime.Decimal and *big.Int, and formats of Go
+// numeric types, like "int8", "uint64" or "float" (float32), sized numbers.
+//
+// Values of the environment at run time must be of the described Go types:
+// an "integer" is an int, not a float64 as decoded by encoding/json.
+//
+// Functions are described by the "function" type with "params", "variadic"
+// (the last parameter is an array of the variadic arguments) and "returns",
+// which are not part of JSON Schema.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Params               []*Schema          `json:"params,omitempty"`
+	Variadic             bool               `json:"variadic,omitempty"`
+	Returns              *Schema            `json:"returns,omitempty"`
+}
+
+// ParseSchema parses a JSON Schema of the environment, which must be an
+// object with properties.
+func ParseSchema(data []byte) (*Schema, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	if _, err := s.TypesTable(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UnmarshalJSON accepts a list of types, like ["string", "null"], as JSON
+// Schema does, and a boolean "additionalProperties".
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+	var raw struct {
+		plain
+		Type                 json.RawMessage `json:"type"`
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = Schema(raw.plain)
+
+	if len(raw.Type) > 0 {
+		var types []string
+		if err := json.Unmarshal(raw.Type, &s.Type); err != nil {
+			if err := json.Unmarshal(raw.Type, &types); err != nil {
+				return fmt.Errorf("type must be a string or an array of strings")
+			}
+		}
+		for _, t := range types {
+			if t == "null" {
+				continue
+			}
+			if s.Type != "" {
+				s.Type = "" // Any of several types.
+				break
+			}
+			s.Type = t
+		}
+	}
+
+	if len(raw.AdditionalProperties) > 0 && raw.AdditionalProperties[0] == '{' {
+		s.AdditionalProperties = &Schema{}
+		return json.Unmarshal(raw.AdditionalProperties, s.AdditionalProperties)
+	}
+	return nil
+}
+
+// TypesTable creates types table of the variables of the environment
+// described by the schema.
+func (s *Schema) TypesTable() (TypesTable, error) {
+	if s.Type != "object" || s.Properties == nil {
+		return nil, fmt.Errorf("invalid schema: environment must be an object with properties")
+	}
+	types := make(TypesTable, len(s.Properties))
+	for name, p := range s.Properties {
+		t, err := p.reflectType(name)
+		if err != nil {
+			return nil, err
+		}
+		types[name] = Tag{Type: t}
+	}
+	return types, nil
+}
+
+var (
+	schemaAny     = reflect.TypeOf(new(interface{})).Elem()
+	schemaFormats = map[string]reflect.Type{
+		"date-time": reflect.TypeOf(time.Time{}),
+		"duration":  reflect.TypeOf(time.Duration(0)),
+		"decimal":   reflect.TypeOf(runtime.Decimal{}),
+		"bigint":    reflect.TypeOf(&big.Int{}),
+		"int8":      reflect.TypeOf(int8(0)),
+		"int16":     reflect.TypeOf(int16(0)),
+		"int32":     reflect.TypeOf(int32(0)),
+		"int64":     reflect.TypeOf(int64(0)),
+		"uint":      reflect.TypeOf(uint(0)),
+		"uint8":     reflect.TypeOf(uint8(0)),
+		"uint16":    reflect.TypeOf(uint16(0)),
+		"uint32":    reflect.TypeOf(uint32(0)),
+		"uint64":    reflect.TypeOf(uint64(0)),
+		"float":     reflect.TypeOf(float32(0)),
+	}
+)
+
+// reflectType returns the Go type of values described by the schema.
+// Objects with properties are structs, which fields are named by the
+// `expr` tag, so they are checked as fields of Go structs are.
+func (s *Schema) reflectType(path string) (reflect.Type, error) {
+	if s == nil {
+		return schemaAny, nil
+	}
+	if t, ok := schemaFormats[s.Format]; ok {
+		return t, nil
+	}
+
+	switch s.Type {
+	case "":
+		return schemaAny, nil
+	case "boolean":
+		return reflect.TypeOf(true), nil
+	case "integer":
+		return reflect.TypeOf(0), nil
+	case "number":
+		return reflect.TypeOf(float64(0)), nil
+	case "string":
+		return reflect.TypeOf(""), nil
+
+	case "array":
+		elem, err := s.Items.reflectType(path + "[]")
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elem), nil
+
+	case "object":
+		if len(s.Properties) == 0 {
+			elem, err := s.AdditionalProperties.reflectType(path + "[]")
+			if err != nil {
+				return nil, err
+			}
+			return reflect.MapOf(reflect.TypeOf(""), elem), nil
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fields := make([]reflect.StructField, len(names))
+		for i, name := range names {
+			t, err := s.Properties[name].reflectType(path + "." + name)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = reflect.StructField{
+				Name: fmt.Sprintf("F%d", i),
+				Type: t,
+				Tag:  reflect.StructTag(fmt.Sprintf(`expr:%q json:%q`, name, name)),
+			}
+		}
+		return reflect.StructOf(fields), nil
+
+	case "function":
+		in := make([]reflect.Type, len(s.Params))
+		for i, p := range s.Params {
+			t, err := p.reflectType(fmt.Sprintf("%v(%d)", path, i))
+			if err != nil {
+				return nil, err
+			}
+			in[i] = t
+		}
+		if s.Variadic && (len(in) == 0 || in[len(in)-1].Kind() != reflect.Slice) {
+			return nil, fmt.Errorf("invalid schema of %v: last parameter of variadic function must be an array", path)
+		}
+		out, err := s.Returns.reflectType(path + "()")
+		if err != nil {
+			return nil, err
+		}
+		return reflect.FuncOf(in, []reflect.Type{out}, s.Variadic), nil
+	}
+	return nil, fmt.Errorf("invalid schema of %v: unknown type %q", path, s.Type)
+}
+
+// WithSchema sets the types of the environment from the schema. The
+// environment at run time is a map[string]interface{}, with maps for
+// objects.
+func (c *Config) WithSchema(s *Schema) error {
+	types, err := s.TypesTable()
+	if err != nil {
+		return err
+	}
+	c.Env = nil
+	c.Types = types
+	c.MapEnv = true
+	c.DefaultType = nil
+	c.Strict = true
+	return nil
+}
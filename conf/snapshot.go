@@ -0,0 +1,176 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/ilius/expr/vm/runtime"
+)
+
+// SnapshotVersion is the version of snapshots written by ExportTypes.
+// Snapshots of older versions remain loadable by LoadSnapshot.
+const SnapshotVersion = 1
+
+// Snapshot is the serializable form of the types of an environment, for
+// checking expressions where the environment is not available.
+type Snapshot struct {
+	Version int     `json:"version"`
+	Env     *Schema `json:"env"`
+}
+
+// ExportTypes returns a JSON snapshot of the types of env: its fields,
+// including fields of nested structs, and its methods. Fields are named
+// as in expressions, so snapshots don't depend on the order of fields.
+// Types which can't be described, like channels, are of any type.
+func ExportTypes(env interface{}) ([]byte, error) {
+	if env == nil {
+		return nil, fmt.Errorf("cannot export types of nil env")
+	}
+	t := reflect.TypeOf(env)
+	e := &exporter{seen: map[reflect.Type]bool{dereference(t): true}}
+	s := e.table(t, CreateTypesTable(env))
+	return json.Marshal(Snapshot{Version: SnapshotVersion, Env: s})
+}
+
+// LoadSnapshot parses a snapshot written by ExportTypes and returns the
+// schema of the environment.
+func LoadSnapshot(data []byte) (*Schema, error) {
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %v", err)
+	}
+	// Snapshots of older versions should be converted here.
+	switch snapshot.Version {
+	case 0:
+		return nil, fmt.Errorf("invalid snapshot: missing version")
+	case 1:
+	default:
+		return nil, fmt.Errorf("unsupported snapshot version %v (supported versions are 1 to %v)", snapshot.Version, SnapshotVersion)
+	}
+	if snapshot.Env == nil {
+		return nil, fmt.Errorf("invalid snapshot: missing env")
+	}
+	if _, err := snapshot.Env.TypesTable(); err != nil {
+		return nil, err
+	}
+	return snapshot.Env, nil
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	decimalType  = reflect.TypeOf(runtime.Decimal{})
+	bigIntType   = reflect.TypeOf(&big.Int{})
+)
+
+type exporter struct {
+	// seen holds structs being exported, so recursive types end with any.
+	seen map[reflect.Type]bool
+}
+
+// table returns the schema of an object with variables of the types table,
+// which is created from a value of type t.
+func (e *exporter) table(t reflect.Type, types TypesTable) *Schema {
+	d := dereference(t)
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema, len(types))}
+	for name, tag := range types {
+		switch {
+		case tag.Ambiguous:
+			continue
+		case tag.Method:
+			s.Properties[name] = e.function(tag.Type, 1)
+		case len(tag.FieldIndex) > 0 && d.FieldByIndex(tag.FieldIndex).PkgPath != "":
+			continue // Unexported fields can't be read.
+		default:
+			s.Properties[name] = e.schema(tag.Type)
+		}
+	}
+	return s
+}
+
+func (e *exporter) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "duration"}
+	case decimalType:
+		return &Schema{Type: "number", Format: "decimal"}
+	case bigIntType:
+		return &Schema{Type: "integer", Format: "bigint"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if t.Elem().Kind() == reflect.Struct {
+			return e.object(t)
+		}
+		return e.schema(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int:
+		return &Schema{Type: "integer"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: t.Kind().String()}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: e.schema(t.Elem())}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return &Schema{}
+		}
+		return &Schema{Type: "object", AdditionalProperties: e.schema(t.Elem())}
+	case reflect.Func:
+		return e.function(t, 0)
+	case reflect.Struct:
+		return e.object(t)
+	}
+	return &Schema{}
+}
+
+// object returns the schema of a struct, or a pointer to struct, with
+// its fields and methods.
+func (e *exporter) object(t reflect.Type) *Schema {
+	d := dereference(t)
+	if e.seen[d] {
+		return &Schema{}
+	}
+	e.seen[d] = true
+	defer delete(e.seen, d)
+
+	types := FieldsFromStruct(d)
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		types[m.Name] = Tag{Type: m.Type, Method: true}
+	}
+	s := e.table(t, types)
+	if len(s.Properties) == 0 {
+		return &Schema{Type: "object"}
+	}
+	return s
+}
+
+// function returns the schema of a function, skipping the receiver of
+// methods, which is the first parameter.
+func (e *exporter) function(t reflect.Type, skip int) *Schema {
+	s := &Schema{Type: "function", Variadic: t.IsVariadic()}
+	for i := skip; i < t.NumIn(); i++ {
+		s.Params = append(s.Params, e.schema(t.In(i)))
+	}
+	if t.NumOut() > 0 {
+		s.Returns = e.schema(t.Out(0))
+	}
+	return s
+}
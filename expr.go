@@ -3,6 +3,8 @@ package expr
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/ilius/expr/ast"
 	"github.com/ilius/expr/builtin"
@@ -29,6 +31,34 @@ func Env(env interface{}) Option {
 	}
 }
 
+// Schema specifies types of env with a schema instead of a Go value, for
+// checking expressions where the Go types are not available. Env is a
+// map[string]interface{} at run time, with maps for objects of the schema.
+// An invalid schema is returned as an error by Compile.
+func Schema(s *conf.Schema) Option {
+	return func(c *conf.Config) {
+		if err := c.WithSchema(s); err != nil {
+			c.SetError(err)
+		}
+	}
+}
+
+// EnvFromSnapshot specifies types of env with a snapshot created by
+// conf.ExportTypes, for checking expressions where the Go types of env
+// are not available. Like with Schema, env is a map at run time. An invalid
+// snapshot is returned as an error by Compile.
+func EnvFromSnapshot(data []byte) Option {
+	return func(c *conf.Config) {
+		s, err := conf.LoadSnapshot(data)
+		if err == nil {
+			err = c.WithSchema(s)
+		}
+		if err != nil {
+			c.SetError(err)
+		}
+	}
+}
+
 // AllowUndefinedVariables allows to use undefined variables inside expressions.
 // This can be used with expr.Env option to partially define a few variables.
 func AllowUndefinedVariables() Option {
@@ -37,7 +67,9 @@ func AllowUndefinedVariables() Option {
 	}
 }
 
-// Operator allows to replace a binary operator with a function.
+// Operator allows to replace an operator with a function. Binary operators,
+// `in`, `contains` and `[]` (index access) are replaced with functions of two
+// arguments, unary `-`, `+` and `not` with functions of one argument.
 func Operator(operator string, fn ...string) Option {
 	return func(c *conf.Config) {
 		c.Operator(operator, fn...)
@@ -87,6 +119,76 @@ func AsFloat64() Option {
 	}
 }
 
+// AsActions tells the compiler to expect a list of assignments to variables
+// of the environment, separated by semicolons:
+//
+//	score = score + 10; tier = score > 100 ? "gold" : "silver"
+//
+// The result is a map[string]interface{} of the assigned values. Later
+// assignments see values assigned before.
+func AsActions() Option {
+	return func(c *conf.Config) {
+		c.Actions = true
+	}
+}
+
+// MutateEnv tells actions to also set the assigned values in the environment,
+// which must be a map or a pointer to struct. The environment is changed only
+// if all assignments succeed.
+func MutateEnv() Option {
+	return func(c *conf.Config) {
+		c.Actions = true
+		c.MutateEnv = true
+	}
+}
+
+// IntegerDivision makes the / operator of two integers return an integer,
+// truncated toward zero, instead of a float64.
+func IntegerDivision() Option {
+	return func(c *conf.Config) {
+		c.IntegerDivision = true
+	}
+}
+
+// CheckedArithmetic makes integer +, -, * and / fail with a
+// *runtime.OverflowError if the result does not fit into int, instead of
+// wrapping around. The error can be found with errors.As.
+func CheckedArithmetic() Option {
+	return func(c *conf.Config) {
+		c.CheckedArithmetic = true
+	}
+}
+
+// DecimalLiterals makes float literals decimals, like 12.50d, for configs
+// working with money, where floats cause rounding errors.
+func DecimalLiterals() Option {
+	return func(c *conf.Config) {
+		c.DecimalLiterals = true
+	}
+}
+
+// LazyValues makes variables of env of type func() T, or func() (T, error),
+// and such fields of structs, values of type T. The function is called when
+// the value is first used, and its result is reused until the end of the run,
+// so expensive values are only computed for expressions which need them.
+// Explicit calls, like Now(), call the function every time.
+func LazyValues() Option {
+	return func(c *conf.Config) {
+		c.LazyValues = true
+	}
+}
+
+// StrictTypes makes operations on values of interface{} type, like
+// `Data.count + 1`, compile errors, as their types are known only at run
+// time. Such values must be converted, like int(Data.count), or tested
+// with the is operator: `Data.count is int and Data.count + 1 > 2`.
+// Comparisons with == and != are allowed.
+func StrictTypes() Option {
+	return func(c *conf.Config) {
+		c.StrictTypes = true
+	}
+}
+
 // Optimize turns optimizations on or off.
 func Optimize(b bool) Option {
 	return func(c *conf.Config) {
@@ -103,33 +205,113 @@ func Patch(visitor ast.Visitor) Option {
 
 // Function adds function to list of functions what will be available in expressions.
 func Function(name string, fn func(params ...interface{}) (interface{}, error), types ...interface{}) Option {
-	return func(c *conf.Config) {
-		ts := make([]reflect.Type, len(types))
-		for i, t := range types {
-			t := reflect.TypeOf(t)
-			if t.Kind() == reflect.Ptr {
-				t = t.Elem()
-			}
-			if t.Kind() != reflect.Func {
+	// The function is created once, so definitions compiled with it can be
+	// reused, see compileDefinitions.
+	ts := make([]reflect.Type, len(types))
+	for i, t := range types {
+		t := reflect.TypeOf(t)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Func {
+			return func(c *conf.Config) {
 				panic(fmt.Sprintf("expr: type of %s is not a function", name))
 			}
-			ts[i] = t
 		}
-		c.Functions[name] = &builtin.Function{
-			Name:  name,
-			Func:  fn,
-			Types: ts,
+		ts[i] = t
+	}
+	f := &builtin.Function{
+		Name:  name,
+		Func:  fn,
+		Types: ts,
+	}
+	return func(c *conf.Config) {
+		c.Functions[name] = f
+	}
+}
+
+// Define adds a function written in the expression language. The signature
+// lists parameters with optional types and an optional result type:
+//
+//	expr.Define("discount(price float, pct float) float", "price * (1 - pct / 100)")
+//
+// A signature without parentheses, like "isVip bool", defines a named
+// expression, which is used without parentheses and evaluated on every use.
+//
+// The body is compiled with the other options, once for every set of options
+// the function is used with, and can use the environment, other defined
+// functions and itself. Recursion is limited by vm.MaxCallDepth.
+func Define(signature, body string) Option {
+	d, err := newDefinition(signature, body)
+	return func(c *conf.Config) {
+		if err != nil {
+			c.SetError(err)
+			return
 		}
+		c.Definitions[d.Name] = d
 	}
 }
 
+func newDefinition(signature, body string) (*vm.Definition, error) {
+	sig, err := parser.ParseSignature(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %v", signature, err)
+	}
+	params := make([]string, len(sig.Params))
+	in := make([]reflect.Type, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = param.Name
+		if in[i], err = typeByName(sig.Name, param.Type); err != nil {
+			return nil, err
+		}
+	}
+	out, err := typeByName(sig.Name, sig.Out)
+	if err != nil {
+		return nil, err
+	}
+	return &vm.Definition{
+		Name:   sig.Name,
+		Params: params,
+		Type:   reflect.FuncOf(in, []reflect.Type{out}, false),
+		Body:   body,
+		Value:  sig.Value,
+	}, nil
+}
+
+func typeByName(fn, name string) (reflect.Type, error) {
+	if name == "" {
+		name = "any"
+	}
+	t, ok := checker.TypeByName(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown type %v in signature of %v", name, fn)
+	}
+	return t, nil
+}
+
 // Compile parses and compiles given input expression to bytecode program.
 func Compile(input string, ops ...Option) (*vm.Program, error) {
+	config, err := newConfig(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	program, _, err := compile(input, config)
+	return program, err
+}
+
+func newConfig(ops []Option) (*conf.Config, error) {
 	config := conf.CreateNew()
 
 	for _, op := range ops {
 		op(config)
 	}
+	if config.Err != nil {
+		return nil, config.Err
+	}
+	if err := checkLibraries(config); err != nil {
+		return nil, err
+	}
 	config.Check()
 
 	if len(config.Operators) > 0 {
@@ -139,45 +321,215 @@ func Compile(input string, ops ...Option) (*vm.Program, error) {
 		})
 	}
 
-	tree, err := parser.Parse(input)
-	if err != nil {
+	if err := compileDefinitions(config); err != nil {
 		return nil, err
 	}
+	return config, nil
+}
 
-	if len(config.Visitors) > 0 {
-		for _, v := range config.Visitors {
-			// We need to perform types check, because some visitors may rely on
-			// types information available in the tree.
-			_, _ = checker.Check(tree, config)
-			ast.Walk(&tree.Node, v)
+// compileDefinitions compiles bodies of functions added with Define, in
+// the order of their names. A function without a result type returns the
+// type of its body, or any when called from bodies compiled before.
+// Definitions of libraries are already compiled.
+//
+// Functions added with Define are not modified: their compiled copies
+// replace them in the config, and are reused by configs with the same key.
+func compileDefinitions(config *conf.Config) error {
+	names := make([]string, 0, len(config.Definitions))
+	for name, d := range config.Definitions {
+		if d.Program == nil {
+			names = append(names, name)
 		}
-		_, err = checker.Check(tree, config)
-		if err != nil {
-			return nil, err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	compileAll := func() (map[string]*vm.Definition, error) {
+		body := *config
+		body.Definitions = make(map[string]*vm.Definition, len(config.Definitions))
+		for name, d := range config.Definitions {
+			body.Definitions[name] = d
 		}
+		compiled := make(map[string]*vm.Definition, len(names))
+		for _, name := range names {
+			compiled[name] = config.Definitions[name].Copy()
+			body.Definitions[name] = compiled[name]
+		}
+		for _, name := range names {
+			if err := compileDefinition(&body, compiled[name]); err != nil {
+				return nil, err
+			}
+		}
+		return compiled, nil
+	}
+
+	var compiled map[string]*vm.Definition
+	var err error
+	if key, ok := definitionsKey(config); ok {
+		compiled, err = config.Definitions[names[0]].Compiled(key, compileAll)
 	} else {
-		_, err = checker.Check(tree, config)
-		if err != nil {
-			return nil, err
+		compiled, err = compileAll()
+	}
+	if err != nil {
+		return err
+	}
+	for name, d := range compiled {
+		config.Definitions[name] = d
+	}
+	return nil
+}
+
+// definitionsKey returns the key of compiled definitions of the config,
+// made of everything which compiling bodies depends on, or false if they
+// can't be reused, like with constant expressions, which are evaluated
+// with values of env, or with visitors, which may have state.
+func definitionsKey(config *conf.Config) (string, bool) {
+	if len(config.ConstFns) > 0 {
+		return "", false
+	}
+	for _, v := range config.Visitors {
+		if _, ok := v.(*conf.OperatorPatcher); !ok {
+			return "", false
+		}
+	}
+
+	var key strings.Builder
+	fmt.Fprintf(&key, "%v %v %p %v %v %v %v %v %v %v;",
+		config.MapEnv, config.Strict, config.DefaultType, config.Optimize,
+		config.IntegerDivision, config.CheckedArithmetic, config.DecimalLiterals,
+		config.LazyValues, config.StrictTypes, config.Imports)
+	for _, name := range sortedKeys(config.Types) {
+		tag := config.Types[name]
+		fmt.Fprintf(&key, "t %q %p %v %v %v %v;", name, tag.Type, tag.Ambiguous, tag.FieldIndex, tag.Method, tag.MethodIndex)
+	}
+	for _, name := range sortedKeys(config.Operators) {
+		fmt.Fprintf(&key, "o %q %q;", name, config.Operators[name])
+	}
+	for _, name := range sortedKeys(config.Functions) {
+		fmt.Fprintf(&key, "f %q %p;", name, config.Functions[name])
+	}
+	for _, name := range sortedKeys(config.Definitions) {
+		fmt.Fprintf(&key, "d %q %p;", name, config.Definitions[name])
+	}
+	for _, name := range sortedKeys(config.Libraries) {
+		fmt.Fprintf(&key, "l %q;", name)
+	}
+	return key.String(), true
+}
+
+// sortedKeys returns keys of the map, which keys are strings, in order.
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.String()
+	}
+	sort.Strings(names)
+	return names
+}
+
+func compileDefinition(config *conf.Config, d *vm.Definition) error {
+	body := *config
+	body.Definition = d
+	body.Expect = reflect.Invalid
+	body.Actions = false
+	body.MutateEnv = false
+
+	program, t, err := compile(d.Body, &body)
+	if err != nil {
+		return fmt.Errorf("%v: %w", d.Name, err)
+	}
+	d.Program = program
+
+	out := d.Type.Out(0)
+	switch {
+	case t == nil || t.Kind() == reflect.Interface:
+	case out.Kind() == reflect.Interface:
+		in := make([]reflect.Type, d.Type.NumIn())
+		for i := range in {
+			in[i] = d.Type.In(i)
 		}
+		d.Type = reflect.FuncOf(in, []reflect.Type{t}, false)
+	case !t.AssignableTo(out):
+		return fmt.Errorf("%v: body returns %v, not %v", d.Name, t, out)
+	}
+	return nil
+}
+
+func compile(input string, config *conf.Config) (*vm.Program, reflect.Type, error) {
+	tree, t, err := check(input, config)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if config.Optimize {
 		err = optimizer.Optimize(&tree.Node, config)
 		if err != nil {
 			if fileError, ok := err.(*file.Error); ok {
-				return nil, fileError.Bind(tree.Source)
+				return nil, nil, fileError.Bind(tree.Source)
 			}
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	program, err := compiler.Compile(tree, config)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return program, nil
+	return program, t, nil
+}
+
+// check parses and type checks the input. Errors of the checker are
+// returned with the tree, which has types of all nodes.
+func check(input string, config *conf.Config) (*parser.Tree, reflect.Type, error) {
+	parse := parser.Parse
+	if config.Actions {
+		parse = parser.ParseActions
+	}
+	var opts []parser.Option
+	if config.Types != nil {
+		opts = append(opts, parser.Shadow(func(name string) bool {
+			_, ok := config.Types[name]
+			return ok
+		}))
+	}
+	tree, err := parse(input, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(config.Definitions) > 0 || len(tree.Imports) > 0 {
+		imports := append(append([]string{}, config.Imports...), tree.Imports...)
+		patcher, err := conf.NewLibraryPatcher(config, imports)
+		if err != nil {
+			return nil, nil, err
+		}
+		ast.Walk(&tree.Node, patcher)
+		if patcher.Err != nil {
+			return nil, nil, patcher.Err.Bind(tree.Source)
+		}
+	}
+
+	if config.DecimalLiterals {
+		ast.Walk(&tree.Node, conf.DecimalPatcher{})
+	}
+
+	if len(config.Visitors) > 0 {
+		for _, v := range config.Visitors {
+			// We need to perform types check, because some visitors may rely on
+			// types information available in the tree.
+			_, _ = checker.Check(tree, config)
+			ast.Walk(&tree.Node, v)
+		}
+	}
+	t, err := checker.Check(tree, config)
+	if err != nil {
+		return tree, nil, err
+	}
+	return tree, t, nil
 }
 
 // Run evaluates given bytecode program.
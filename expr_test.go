@@ -2,15 +2,23 @@ package expr_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"net"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/ilius/expr"
 	"github.com/ilius/expr/ast"
+	"github.com/ilius/expr/conf"
 	"github.com/ilius/expr/file"
+	"github.com/ilius/expr/vm"
+	"github.com/ilius/expr/vm/runtime"
 	"github.com/ilius/is/v2"
 )
 
@@ -527,6 +535,566 @@ func TestOperator_interface(t *testing.T) {
 	is.Equal(true, output)
 }
 
+type bitset uint64
+
+type bitsetEnv struct {
+	Flags bitset
+	Other bitset
+}
+
+func (bitsetEnv) Invert(b bitset) bitset          { return ^b }
+func (bitsetEnv) Empty(b bitset) bool             { return b == 0 }
+func (bitsetEnv) Has(b bitset, i int) bool        { return b&(1<<uint(i)) != 0 }
+func (bitsetEnv) In(i int, b bitset) bool         { return b&(1<<uint(i)) != 0 }
+func (bitsetEnv) Contains(b, o bitset) bool       { return b&o == o }
+func (bitsetEnv) Without(b, o bitset) bitset      { return b &^ o }
+func (bitsetEnv) Named(b bitset, name string) int { return len(name) }
+
+func TestOperator_unary_and_membership(t *testing.T) {
+	is := is.New(t)
+	env := bitsetEnv{Flags: 0b1010, Other: 0b0010}
+	options := []expr.Option{
+		expr.Env(bitsetEnv{}),
+		expr.Operator("-", "Invert", "Without"),
+		expr.Operator("!", "Empty"),
+		expr.Operator("in", "In"),
+		expr.Operator("contains", "Contains"),
+		expr.Operator("[]", "Has", "Named"),
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`-Flags`, ^bitset(0b1010)},
+		{`Flags - Other`, bitset(0b1000)},
+		{`not Flags`, false},
+		{`!(Flags - Flags)`, true},
+		{`1 in Flags`, true},
+		{`2 in Flags`, false},
+		{`Flags contains Other`, true},
+		{`Other contains Flags`, false},
+		{`Flags[3]`, true},
+		{`Flags[0]`, false},
+		{`Flags["abc"]`, 3},
+		{`Flags.abcd`, 4},
+		{`(-Flags)[0] && 1 + 1 == 2 && -1 < 0`, true},
+	}
+	for _, tt := range tests {
+		program, err := expr.Compile(tt.code, options...)
+		is.Msg(tt.code).NotErr(err)
+		output, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(output, tt.want)
+	}
+
+	invalid := []struct {
+		operator string
+		fn       string
+	}{
+		{"not", "Without"},
+		{"[]", "Invert"},
+		{"in", "Empty"},
+	}
+	for _, tt := range invalid {
+		func() {
+			defer func() {
+				is.Equal(fmt.Sprint(recover()), fmt.Sprintf("function %v for %v operator does not have a correct signature", tt.fn, tt.operator))
+			}()
+			_, _ = expr.Compile(`Flags`, expr.Env(bitsetEnv{}), expr.Operator(tt.operator, tt.fn))
+		}()
+	}
+}
+
+// record is a lazily loaded object with dynamic fields.
+type record struct {
+	loads  *int
+	fields map[string]interface{}
+}
+
+func (r record) Fetch(key interface{}) (interface{}, bool) {
+	*r.loads++
+	v, ok := r.fields[key.(string)]
+	return v, ok
+}
+
+// table is a custom collection of records.
+type table []map[string]interface{}
+
+func (t table) Len() int                { return len(t) }
+func (t table) Index(i int) interface{} { return t[i] }
+
+type tags []string
+
+func (t tags) Len() int                { return len(t) }
+func (t tags) Index(i int) interface{} { return t[i] }
+
+type counter struct{}
+
+func (counter) Len() int { return 42 }
+
+// document is a record with declared fields and methods.
+type document struct {
+	record
+	Name string
+}
+
+func (d *document) Title() string { return "Dr. " + d.Name }
+
+// queue is a custom collection, which is not a slice.
+type queue struct{ items []int }
+
+func (q *queue) Len() int                { return len(q.items) }
+func (q *queue) Index(i int) interface{} { return q.items[i] }
+
+type collectionEnv struct {
+	User     record
+	Orders   *table
+	Tags     tags
+	Counter  counter
+	Document *document
+	Queue    *queue
+}
+
+func TestExpr_custom_collections(t *testing.T) {
+	is := is.New(t)
+	loads := 0
+	env := collectionEnv{
+		User: record{loads: &loads, fields: map[string]interface{}{
+			"Name": "Anna",
+			"Age":  30,
+		}},
+		Orders: &table{
+			{"id": 1, "total": 30},
+			{"id": 2, "total": 120},
+			{"id": 3, "total": 75},
+		},
+		Tags: tags{"new", "vip"},
+		Document: &document{
+			record: record{loads: &loads, fields: map[string]interface{}{"Name": "fetched", "Year": 2020}},
+			Name:   "Who",
+		},
+		Queue: &queue{items: []int{10, 20, 30}},
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`User.Name`, "Anna"},
+		{`User["Age"] + 1`, 31},
+		{`User.Missing`, nil},
+		{`"Age" in User`, true},
+		{`"Email" in User`, false},
+		{`len(Orders)`, 3},
+		{`len(Counter)`, 42},
+		{`count(Orders, .total > 50)`, 2},
+		{`all(Orders, .id > 0)`, true},
+		{`map(Orders, .id)`, []interface{}{1, 2, 3}},
+		{`map(filter(Orders, .total > 50), .id)`, []interface{}{2, 3}},
+		{`map(sortBy(Orders, .total), .id)`, []interface{}{1, 3, 2}},
+		{`first(Orders, .total > 100).id`, 2},
+		{`"vip" in Tags`, true},
+		{`"old" in Tags`, false},
+		{`sort(Tags)`, []interface{}{"new", "vip"}},
+		{`Document.Name`, "Who"},
+		{`Document["Name"] + "!"`, "Who!"},
+		{`Document.Title()`, "Dr. Who"},
+		{`Document.Year`, 2020},
+		{`Queue[0]`, 10},
+		{`Queue[-1]`, 30},
+		{`Queue[1] + 1`, 21},
+		{`len(Queue)`, 3},
+		{`map(Queue, # * 2)`, []interface{}{20, 40, 60}},
+	}
+	for _, tt := range tests {
+		program, err := expr.Compile(tt.code, expr.Env(collectionEnv{}))
+		is.Msg(tt.code).NotErr(err)
+		output, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(output, tt.want)
+	}
+	is.Equal(loads, 6)
+
+	program, err := expr.Compile(`Queue[3]`, expr.Env(collectionEnv{}))
+	is.NotErr(err)
+	_, err = expr.Run(program, env)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "index out of range: 3 (length 3)"))
+
+	// Declared fields of values of unknown type take precedence as well.
+	output, err := expr.Eval(`Doc.Name`, map[string]interface{}{"Doc": env.Document})
+	is.NotErr(err)
+	is.Equal(output, "Who")
+}
+
+type profile struct {
+	Country string
+	Score   int
+}
+
+type lazyUser struct {
+	Profile func() *profile
+}
+
+type lazyEnv struct {
+	Profile func() *profile
+	Limit   func() (int, error)
+	Name    string
+	Now     func() time.Time
+	User    *lazyUser
+}
+
+func TestExpr_lazy_values(t *testing.T) {
+	is := is.New(t)
+	calls := 0
+	env := lazyEnv{
+		Profile: func() *profile {
+			calls++
+			return &profile{Country: "NL", Score: 80}
+		},
+		Limit: func() (int, error) { return 0, errors.New("limit is unavailable") },
+		Name:  "Anna",
+	}
+
+	program, err := expr.Compile(`Profile.Country == "NL" && Profile.Score > 50`, expr.Env(lazyEnv{}), expr.LazyValues())
+	is.NotErr(err)
+	output, err := expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(output, true)
+	is.Equal(calls, 1)
+
+	_, err = expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(calls, 2)
+
+	calls = 0
+	program, err = expr.Compile(`Name == "Bob" and Profile.Score > 50`, expr.Env(lazyEnv{}), expr.LazyValues())
+	is.NotErr(err)
+	output, err = expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(output, false)
+	is.Equal(calls, 0)
+
+	program, err = expr.Compile(`Limit > 10`, expr.Env(lazyEnv{}), expr.LazyValues())
+	is.NotErr(err)
+	_, err = expr.Run(program, env)
+	is.ErrMsg(err, "limit is unavailable (1:1)\n | Limit > 10\n | ^")
+
+	mapEnv := map[string]interface{}{
+		"Tags": func() []string { return []string{"a", "b"} },
+	}
+	program, err = expr.Compile(`len(Tags) == 2 && "b" in Tags`, expr.Env(mapEnv), expr.LazyValues())
+	is.NotErr(err)
+	output, err = expr.Run(program, mapEnv)
+	is.NotErr(err)
+	is.Equal(output, true)
+
+	_, err = expr.Compile(`Profile.Score`, expr.Env(lazyEnv{}))
+	is.Err(err)
+
+	// Lazy values can be called explicitly, and fields of structs are lazy too.
+	env.Now = func() time.Time { return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }
+	env.User = &lazyUser{Profile: env.Profile}
+	tests := []struct {
+		code  string
+		want  interface{}
+		calls int
+	}{
+		{`Profile().Country`, "NL", 1},
+		{`Profile().Score + Profile().Score`, 160, 2},
+		{`Now().Year() == Now.Year()`, true, 0},
+		{`User.Profile.Score + User.Profile.Score`, 160, 1},
+		{`User.Profile().Country`, "NL", 1},
+	}
+	for _, tt := range tests {
+		calls = 0
+		program, err := expr.Compile(tt.code, expr.Env(lazyEnv{}), expr.LazyValues())
+		is.Msg(tt.code).NotErr(err)
+		output, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(output, tt.want)
+		is.Msg(tt.code).Equal(calls, tt.calls)
+	}
+
+	// Definitions share results of lazy values with the caller.
+	calls = 0
+	program, err = expr.Compile(`good() && Profile.Score > 1`, expr.Env(lazyEnv{}), expr.LazyValues(),
+		expr.Define("good() bool", "Profile.Country == \"NL\""))
+	is.NotErr(err)
+	output, err = expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(output, true)
+	is.Equal(calls, 1)
+}
+
+func TestExpr_schema(t *testing.T) {
+	is := is.New(t)
+	schema, err := conf.ParseSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"user": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"age": {"type": ["integer", "null"]},
+					"address": {
+						"type": "object",
+						"properties": {"city": {"type": "string"}}
+					},
+					"created": {"type": "string", "format": "date-time"}
+				}
+			},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"limits": {"type": "object", "additionalProperties": {"type": "number"}},
+			"extra": {},
+			"greet": {"type": "function", "params": [{"type": "string"}], "returns": {"type": "string"}}
+		}
+	}`))
+	is.NotErr(err)
+
+	env := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":    "Anna",
+			"age":     30,
+			"address": map[string]interface{}{"city": "Utrecht"},
+		},
+		"tags":   []string{"vip"},
+		"limits": map[string]float64{"daily": 100},
+		"extra":  true,
+		"greet":  func(s string) string { return "Hello, " + s },
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`user.name`, "Anna"},
+		{`user.age + 1`, 31},
+		{`user.address.city == "Utrecht"`, true},
+		{`"vip" in tags`, true},
+		{`limits["daily"] > 50`, true},
+		{`extra`, true},
+		{`greet(user.name)`, "Hello, Anna"},
+	}
+	for _, tt := range tests {
+		program, err := expr.Compile(tt.code, expr.Schema(schema))
+		is.Msg(tt.code).NotErr(err)
+		output, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(output, tt.want)
+	}
+
+	errs := []struct {
+		code string
+		err  string
+	}{
+		{`user.email`, "user has no field email (1:6)"},
+		{`user.address.zip`, "user.address has no field zip (1:14)"},
+		{`user.address.zip()`, "user.address has no method zip (1:14)"},
+		{`user.name + 1`, "invalid operation: + (mismatched types string and int)"},
+		{`greet(true)`, "cannot use bool as argument (type string) to call greet"},
+		{`unknown`, "unknown name unknown"},
+	}
+	for _, tt := range errs {
+		_, err := expr.Compile(tt.code, expr.Schema(schema))
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+
+	_, err = expr.Compile(`user.created.Year() > 2000`, expr.Schema(schema))
+	is.NotErr(err)
+
+	schema = &conf.Schema{
+		Type: "object",
+		Properties: map[string]*conf.Schema{
+			"price": {Type: "number", Format: "decimal"},
+			"items": {Type: "array", Items: &conf.Schema{Type: "integer"}},
+		},
+	}
+	_, err = expr.Compile(`price + "1"`, expr.Schema(schema))
+	is.Err(err)
+	_, err = expr.Compile(`price * 2 > 10 && all(items, # > 0)`, expr.Schema(schema))
+	is.NotErr(err)
+
+	_, err = conf.ParseSchema([]byte(`{"type": "object", "properties": {"a": {"type": "tuple"}}}`))
+	is.ErrMsg(err, `invalid schema of a: unknown type "tuple"`)
+
+	_, err = expr.Compile(`a`, expr.Schema(&conf.Schema{Type: "object"}))
+	is.ErrMsg(err, "invalid schema: environment must be an object with properties")
+}
+
+func TestExpr_env_snapshot(t *testing.T) {
+	is := is.New(t)
+	data, err := conf.ExportTypes(&mockEnv{})
+	is.NotErr(err)
+
+	valid := []string{
+		`Ticket.Price > 100 && Ticket.PriceDiv(2) == 50`,
+		`Passengers.Adults + Passengers.Children > 2`,
+		`all(Segments, {.Origin != .Destination})`,
+		`Segments[0].Date.Before(BirthDay)`,
+		`lowercase + String`,
+		`Add(1, 2) + Inc(3) + Sum(Array)`,
+		`Variadic("x", 1, 2)[0] == 1`,
+		`len(Tweets[0].Text) > 0 && OneDayDuration > Duration("1h")`,
+		`Any.anything`,
+	}
+	for _, code := range valid {
+		_, err := expr.Compile(code, expr.EnvFromSnapshot(data))
+		is.Msg(code).NotErr(err)
+	}
+
+	invalid := []string{
+		`Ticket.Cost`,
+		`Lowercase`,
+		`Ticket.PriceDiv("2")`,
+		`Segments[0].Origin + 1`,
+		`Passengers.Adults.Name`,
+	}
+	for _, code := range invalid {
+		_, err := expr.Compile(code, expr.EnvFromSnapshot(data))
+		is.Msg(code).Err(err)
+	}
+
+	_, err = conf.LoadSnapshot([]byte(`{"version": 99, "env": {"type": "object", "properties": {}}}`))
+	is.ErrMsg(err, "unsupported snapshot version 99 (supported versions are 1 to 1)")
+
+	_, err = conf.LoadSnapshot([]byte(`{"env": {"type": "object", "properties": {}}}`))
+	is.ErrMsg(err, "invalid snapshot: missing version")
+
+	// Invalid snapshots are errors of Compile and Vet.
+	_, err = expr.Compile(`a`, expr.EnvFromSnapshot([]byte(`{"version": 1}`)))
+	is.ErrMsg(err, "invalid snapshot: missing env")
+
+	_, err = expr.Vet([]string{`a`}, expr.EnvFromSnapshot([]byte(`{`)), expr.Env(map[string]interface{}{}))
+	is.ErrMsg(err, "invalid snapshot: unexpected end of JSON input")
+
+	schema, err := conf.LoadSnapshot([]byte(`{"version": 1, "env": {"type": "object", "properties": {"a": {"type": "integer"}}}}`))
+	is.NotErr(err)
+	is.Equal(schema.Properties["a"].Type, "integer")
+}
+
+type vetUserV1 struct {
+	Name  string
+	Email string
+	Age   int
+}
+
+type vetUserV2 struct {
+	Name string
+	Age  float64
+}
+
+func (vetUserV2) IsAdult() bool { return true }
+
+type vetEnvV1 struct {
+	User   vetUserV1
+	Orders []vetUserV1
+	Score  int
+}
+
+type vetEnvV2 struct {
+	User   vetUserV2
+	Orders []vetUserV2
+}
+
+func TestVet(t *testing.T) {
+	is := is.New(t)
+	corpus := []string{
+		`User.Name == "Anna"`,
+		`User.Email endsWith "@example.com"`,
+		`User.Age + 1`,
+		`Score > 10`,
+		`all(Orders, .Email != "")`,
+		`User.Age > 18`,
+		`User.Unknown`,
+	}
+
+	issues, err := expr.Vet(corpus, expr.Env(vetEnvV1{}), expr.Env(vetEnvV2{}))
+	is.NotErr(err)
+	is.Equal(len(issues), 4)
+
+	is.Equal(issues[0].Index, 1)
+	is.Err(issues[0].Err)
+	is.True(strings.Contains(issues[0].Err.Error(), "has no field Email"))
+	is.Equal(issues[0].Removed, []string{"User.Email"})
+
+	is.Equal(issues[1].Index, 2)
+	is.NotErr(issues[1].Err)
+	is.Equal(issues[1].OldType.String(), "int")
+	is.Equal(issues[1].NewType.String(), "float64")
+	is.Equal(len(issues[1].Removed), 0)
+
+	is.Equal(issues[2].Index, 3)
+	is.ErrMsg(issues[2].Err, "unknown name Score (1:1)\n | Score > 10\n | ^")
+	is.Equal(issues[2].Removed, []string{"Score"})
+
+	is.Equal(issues[3].Index, 4)
+	is.Err(issues[3].Err)
+	is.Equal(issues[3].Removed, []string{"Orders[].Email"})
+
+	schema := &conf.Schema{Type: "object", Properties: map[string]*conf.Schema{
+		"User": {Type: "object", Properties: map[string]*conf.Schema{
+			"Name": {Type: "string"},
+			"Age":  {Type: "integer"},
+		}},
+	}}
+	issues, err = expr.Vet(corpus[:3], expr.Env(vetEnvV1{}), expr.Schema(schema))
+	is.NotErr(err)
+	is.Equal(len(issues), 1)
+	is.Equal(issues[0].Removed, []string{"User.Email"})
+}
+
+func TestExpr_strict_types(t *testing.T) {
+	is := is.New(t)
+	env := map[string]interface{}{
+		"Data": map[string]interface{}{
+			"count": 3,
+			"name":  "box",
+			"items": []interface{}{1, 2},
+			"next":  func() int { return 4 },
+		},
+		"Inc":   func(x int) int { return x + 1 },
+		"Limit": 10,
+	}
+
+	valid := []struct {
+		code string
+		want interface{}
+	}{
+		{`int(Data.count) + 1`, 4},
+		{`Data.count is int and Data.count + 1 > 2`, true},
+		{`Data.name is string ? Data.name + "!" : ""`, "box!"},
+		{`Inc(int(Data.count)) < Limit`, true},
+		{`Data.items is array and all(Data.items, int(#) > 0)`, true},
+		{`Data is map and Data.items is array ? Data.items[1] : 0`, 2},
+		{`Data.next is func ? Data.next() : 0`, 4},
+	}
+	for _, tt := range valid {
+		program, err := expr.Compile(tt.code, expr.Env(env), expr.StrictTypes())
+		is.Msg(tt.code).NotErr(err)
+		output, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(output, tt.want)
+	}
+
+	invalid := []struct {
+		code string
+		err  string
+	}{
+		{`Data.count + 1`, "ambiguous use of interface {} value in operator + (convert it, like int(x), or test its type with is) (1:6)\n | Data.count + 1\n | .....^"},
+		{`Inc(Data.count)`, "ambiguous use of interface {} value in argument of Inc (convert it, like int(x), or test its type with is) (1:10)\n | Inc(Data.count)\n | .........^"},
+	}
+	for _, tt := range invalid {
+		_, err := expr.Compile(tt.code, expr.Env(env), expr.StrictTypes())
+		is.Msg(tt.code).ErrMsg(err, tt.err)
+
+		_, err = expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+	}
+}
+
 func TestExpr_readme_example(t *testing.T) {
 	is := is.New(t)
 	env := map[string]interface{}{
@@ -1722,6 +2290,1620 @@ func TestRun_custom_func_returns_an_error_as_second_arg(t *testing.T) {
 	is.Equal(true, out)
 }
 
+func TestExpr_json(t *testing.T) {
+	env := map[string]interface{}{
+		"Raw": `{"user": {"name": "Bob", "tags": ["a", "b"], "age": 42, "score": 1.5}}`,
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`fromJSON(Raw).user.age + 1`, 43},
+		{`fromJSON(Raw)["user"]["tags"][0]`, "a"},
+		{`jsonPath(Raw, "$.user.tags[1]")`, "b"},
+		{`jsonPath(fromJSON(Raw), "$.user.score")`, 1.5},
+		{`fromJSON(toJSON({a: "b"})).a`, "b"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_json_invalid_path(t *testing.T) {
+	is := is.New(t)
+	_, err := expr.Compile(`jsonPath("{}", "a.b")`)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `invalid JSON path "a.b": must start with $`))
+
+	_, err = expr.Compile(`jsonPath("{}", "$.a]")`)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `invalid JSON path "$.a]": unexpected ']' in key "a]"`))
+
+	_, err = expr.Eval(`jsonPath("{}", path)`, map[string]interface{}{"path": "$[x"})
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `unclosed bracket`))
+}
+
+func TestExpr_json_memory_budget(t *testing.T) {
+	is := is.New(t)
+	env := map[string]interface{}{
+		"Raw": "[" + strings.Repeat("1,", 1e6) + "1]",
+	}
+
+	_, err := expr.Eval(`len(fromJSON(Raw))`, env)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "memory budget exceeded"))
+}
+
+func TestExpr_regexp_builtins(t *testing.T) {
+	env := map[string]interface{}{
+		"Text":    "2023-01-15, 2024-12-31",
+		"Pattern": `(?P<year>\d{4})-(?P<month>\d{2})`,
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`findAll(Text, "\\d{4}")`, []string{"2023", "2024"}},
+		{`capture(Text, Pattern).year`, "2023"},
+		{`replaceRegex(Text, "(\\d{4})-", "$1/")`, "2023/01-15, 2024/12-31"},
+		{`len(split(Text, ",\\s*"))`, 2},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_regexp_builtins_shadowed_by_env(t *testing.T) {
+	is := is.New(t)
+	env := map[string]interface{}{
+		"split": func(s string) []string { return strings.Fields(s) },
+	}
+
+	program, err := expr.Compile(`split("a b")`, expr.Env(env))
+	is.NotErr(err)
+
+	out, err := expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal([]string{"a", "b"}, out)
+}
+
+func TestExpr_builtins_shadowed_by_env(t *testing.T) {
+	env := map[string]interface{}{
+		"sort":  func(s string) string { return "sorted " + s },
+		"first": func(a []interface{}) interface{} { return a[0] },
+		"try":   func(n int) int { return n + 1 },
+		"type":  "admin",
+		"hash":  map[string]interface{}{"a": 1},
+		"len":   func(s string) int { return 42 },
+		"map":   func(a, b int) int { return a + b },
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`sort("a")`, "sorted a"},
+		{`first([3, 2])`, 3},
+		{`try(1)`, 2},
+		{`type == "admin"`, true},
+		{`hash.a`, 1},
+		// Core builtins take precedence.
+		{`len("a")`, 1},
+		{`map([1, 2], # * 2)`, []interface{}{2, 4}},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+		out, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, out)
+	}
+
+	// Without the env the builtins are used.
+	is := is.New(t)
+	out, err := expr.Eval(`sort([2, 1])`, nil)
+	is.NotErr(err)
+	is.Equal([]interface{}{1, 2}, out)
+}
+
+func TestExpr_regexp_builtins_invalid_pattern(t *testing.T) {
+	is := is.New(t)
+	_, err := expr.Compile(`findAll("abc", "[a-z")`)
+	is.Err(err)
+	is.Equal("error parsing regexp: missing closing ]: `[a-z` (1:16)\n | findAll(\"abc\", \"[a-z\")\n | ...............^", err.Error())
+}
+
+func TestExpr_type_and_is(t *testing.T) {
+	type Env struct {
+		Value interface{}
+		Meta  map[string]interface{}
+	}
+
+	tests := []struct {
+		code  string
+		value interface{}
+		want  interface{}
+	}{
+		{`type(Value)`, int32(1), "int32"},
+		{`type(Value)`, time.Second, "duration"},
+		{`Value is not int`, "1", true},
+		{`Value is int ? Value + 1 : 0`, 1, 2},
+		{`Value is int ? Value + 1 : 0`, "1", 0},
+		{`Value is string && Value startsWith "a"`, 1, false},
+		{`Value is array && len(Value) > 1`, []int{1, 2}, true},
+		{`Value is map && Value.a == 1`, map[string]interface{}{"a": 1}, true},
+		{`Meta.x is float ? Meta.x * 2 : -1`, nil, 3.0},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		env := Env{Value: tt.value, Meta: map[string]interface{}{"x": 1.5}}
+		program, err := expr.Compile(tt.code, expr.Env(Env{}))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+type myInt int
+
+func TestExpr_is_exact_types(t *testing.T) {
+	type Env struct {
+		X interface{}
+		S interface{}
+		F func(int) int
+		G func([]string) int
+	}
+	env := Env{
+		X: myInt(3),
+		S: []string{"a"},
+		F: func(i int) int { return i },
+		G: func(s []string) int { return len(s) },
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`X is int ? F(X) : 0`, 0},
+		{`X is int ? X + 1 : 0`, 0},
+		{`X is int ? X == 3 : false`, false},
+		{`S is array ? G(S) : 0`, 1},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(Env{}))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_is_narrowing_errors(t *testing.T) {
+	type Env struct {
+		Value interface{}
+	}
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`Value is string ? Value - 1 : 0`, "invalid operation: - (mismatched types string and int)"},
+		{`Value is "int"`, "expected type name (got String(\"int\"))"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		_, err := expr.Compile(tt.code, expr.Env(Env{}))
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+}
+
+func TestExpr_ip_cidr(t *testing.T) {
+	env := map[string]interface{}{
+		"Addr":      "192.168.1.10",
+		"Addr6":     "2001:db8::1",
+		"IP":        net.ParseIP("10.0.0.1"),
+		"Allowlist": []string{"172.16.0.0/12", "192.168.0.0/16"},
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`ip(Addr) in cidr("192.168.0.0/16")`, true},
+		{`Addr in cidr("192.168.1.0/24")`, true},
+		{`IP == ip("10.0.0.1")`, true},
+		{`ip(Addr) in [cidr("10.0.0.0/8"), cidr("192.168.2.0/24")]`, false},
+		{`ip(Addr6) in [cidr("10.0.0.0/8"), cidr("2001:db8::/32")]`, true},
+		{`ip(Addr) in map(Allowlist, cidr(#))`, true},
+		{`"foo" in [cidr("10.0.0.0/8"), "foo"]`, true},
+		{`Addr in [cidr("192.168.0.0/16"), "foo"]`, true},
+		{`"foo" in [cidr("10.0.0.0/8")]`, false},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_ip_cidr_invalid(t *testing.T) {
+	is := is.New(t)
+
+	_, err := expr.Compile(`ip("300.0.0.1")`)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `invalid IP address "300.0.0.1"`))
+
+	_, err = expr.Compile(`"10.0.0.1" in cidr("10.0.0.0/33")`)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `invalid CIDR address: 10.0.0.0/33`))
+
+	_, err = expr.Eval(`ip(Addr)`, map[string]interface{}{"Addr": "nope"})
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `invalid IP address "nope"`))
+}
+
+func TestExpr_semver(t *testing.T) {
+	env := map[string]interface{}{
+		"AppVersion": "2.10.0",
+		"MinVersion": "2.9.1",
+		"Pre":        "3.0.0-rc.1",
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`AppVersion >= "2.9.0"`, false},
+		{`semver(AppVersion) >= "2.9.0"`, true},
+		{`semver(AppVersion) > semver(MinVersion)`, true},
+		{`"2.10.0" == semver(AppVersion)`, true},
+		{`semver(AppVersion) != "2.10.0"`, false},
+		{`semver(Pre) < "3.0.0"`, true},
+		{`semver(AppVersion) in "^2.3"`, true},
+		{`semver(Pre) in "^2.3"`, false},
+		{`map(["1.0.0", "2.4.1", "3.0.0"], semver(#) in "^2")`, []interface{}{false, true, false}},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_semver_invalid(t *testing.T) {
+	env := map[string]interface{}{
+		"AppVersion": "2.10",
+	}
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`semver("2.10")`, `invalid version "2.10": expected MAJOR.MINOR.PATCH (1:8)`},
+		{`semver(AppVersion) >= "2.x.0"`, `invalid version "2.x.0": invalid number "x" (1:23)`},
+		{`semver(AppVersion) in "^2.y"`, `invalid version constraint "^2.y": invalid number "y" (1:23)`},
+		{`semver(AppVersion) < AppVersion`, `invalid operation: < (mismatched types *runtime.Version and string) (1:20)`},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		_, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+
+	is := is.New(t)
+	_, err := expr.Eval(`semver(AppVersion) > "2.0.0"`, env)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `invalid version "2.10"`))
+}
+
+func TestExpr_encoding_and_hashing(t *testing.T) {
+	env := map[string]interface{}{
+		"UserID": "user-2",
+		"ID":     42,
+		"Raw":    []byte("hi"),
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`md5("abc")`, "900150983cd24fb0d6963f7d28e17f72"},
+		{`sha256(Raw) == sha256("hi")`, true},
+		{`hash("abc") % 10`, 3},
+		{`hash(ID) == hash("42")`, true},
+		{`base64Decode(base64Encode(UserID))`, "user-2"},
+		{`hexEncode(Raw)`, "6869"},
+		{`urlEncode("a b&c=d")`, "a+b%26c%3Dd"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+// TestExpr_bucket_is_stable pins bucket() results, which must never change.
+func TestExpr_bucket_is_stable(t *testing.T) {
+	is := is.New(t)
+
+	out, err := expr.Eval(`map(["user-1", "user-2", "user-3"], bucket(#, 100))`, nil)
+	is.NotErr(err)
+	is.Equal([]interface{}{0, 57, 38}, out)
+
+	out, err = expr.Eval(`bucket(42, 100)`, nil)
+	is.NotErr(err)
+	is.Equal(11, out)
+
+	_, err = expr.Eval(`bucket("user-1", 0)`, nil)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "invalid number of buckets 0"))
+}
+
+func TestExpr_encoding_invalid(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`sha256(1)`, "invalid argument for sha256 (type int)"},
+		{`bucket("a", "b")`, "invalid argument for bucket (type string)"},
+		{`base64Decode("%%%")`, "cannot decode base64"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		_, err := expr.Eval(tt.code, nil)
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+}
+
+func TestExpr_get_and_has(t *testing.T) {
+	type Address struct {
+		City string `expr:"city"`
+		Zip  *string
+	}
+	type User struct {
+		Name      string
+		Addresses []Address `expr:"addresses"`
+		Manager   *User
+		Meta      map[string]interface{}
+		Scores    map[int]float64
+		secret    string
+	}
+	type Env struct {
+		User   User
+		Nobody *User
+		Dyn    map[string]interface{}
+		Any    interface{}
+	}
+
+	env := Env{
+		User: User{
+			Name:      "ann",
+			Addresses: []Address{{City: "Oslo"}, {City: "Rome"}},
+			Meta:      map[string]interface{}{"tags": []interface{}{"a", "b"}, "nil": nil, "a.b": 1},
+			Scores:    map[int]float64{2: 0.5},
+			secret:    "x",
+		},
+		Dyn: map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": []interface{}{1, 2, map[string]interface{}{"c": "deep"}},
+			},
+		},
+		Any: &User{Name: "bob"},
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`get(Dyn, "a.b[-1].c")`, "deep"},
+		{`get(Dyn, "a.x.y.z", 0)`, 0},
+		{`get(User, "addresses[1].city", "")`, "Rome"},
+		{`get(User, "Meta.tags[1]")`, "b"},
+		{`get(User, "secret", "hidden")`, "hidden"},
+		{`get(Nobody, "Name", "none")`, "none"},
+		{`get(Any, "Name")`, "bob"},
+		{`get(Dyn, Path, "none")`, "deep"},
+		{`has(User, "addresses[2].city")`, false},
+		{`has(User, "Meta.nil")`, true},
+		{`has(Any, "Manager.Name")`, false},
+	}
+
+	vars := map[string]interface{}{
+		"User":   env.User,
+		"Nobody": env.Nobody,
+		"Dyn":    env.Dyn,
+		"Any":    env.Any,
+		"Path":   "a.b[2].c",
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(vars))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, vars)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_get_precompiles_struct_paths(t *testing.T) {
+	is := is.New(t)
+	type Inner struct {
+		Value int `expr:"value"`
+	}
+	type Env struct {
+		Items []Inner `expr:"items"`
+	}
+
+	program, err := expr.Compile(`get(Env, "items[0].value", -1)`, expr.Env(map[string]interface{}{"Env": Env{}}))
+	is.NotErr(err)
+
+	var path *runtime.Path
+	for _, c := range program.Constants {
+		if p, ok := c.(*runtime.Path); ok {
+			path = p
+		}
+	}
+	is.True(path != nil)
+	is.Equal([]int{0}, path.Steps[0].Field)
+	is.Equal([]int{0}, path.Steps[2].Field)
+
+	out, err := expr.Run(program, map[string]interface{}{"Env": Env{Items: []Inner{{Value: 7}}}})
+	is.NotErr(err)
+	is.Equal(7, out)
+
+	_, err = expr.Compile(`has(Env, "items[x]")`, expr.Env(map[string]interface{}{"Env": Env{}}))
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `invalid path "items[x]": bad index "x"`))
+}
+
+func TestExpr_sets(t *testing.T) {
+	env := map[string]interface{}{
+		"UserTags":     []string{"admin", "beta", "eu"},
+		"RequiredTags": []string{"beta", "us"},
+		"IDs":          []int64{1, 2, 3},
+		"Any":          interface{}(2),
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`"us" in set(UserTags)`, false},
+		{`set(UserTags) & set(RequiredTags) == set(["beta"])`, true},
+		{`len(set(UserTags) | set(RequiredTags))`, 4},
+		{`set(UserTags) - set(RequiredTags) == set(["admin", "eu"])`, true},
+		{`set(RequiredTags) not subsetOf set(UserTags)`, true},
+		{`set(UserTags) supersetOf set(["eu", "admin"])`, true},
+		{`set(UserTags) | set(RequiredTags) - set(["us"]) == set(UserTags)`, true},
+		{`2.0 in set(IDs)`, true},
+		{`Any in ["a", 2]`, true},
+		{`Any in ["a", "b"]`, false},
+		{`[1, 2] in set(IDs)`, false},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_sets_invalid(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`set("a")`, "invalid argument for set (type string)"},
+		{`set(["a"]) | ["b"]`, "invalid operation: | (mismatched types runtime.Set and []interface {})"},
+		{`1 subsetOf 2`, "invalid operation: subsetOf (mismatched types int and int)"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		_, err := expr.Compile(tt.code)
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+
+	is := is.New(t)
+	_, err := expr.Eval(`set([[1]])`, nil)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "cannot use []interface {} as set element"))
+}
+
+func TestExpr_sort(t *testing.T) {
+	type Task struct {
+		Name     string
+		Priority int
+		Due      time.Time
+	}
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	env := map[string]interface{}{
+		"Numbers": []int{3, 1, 2},
+		"Words":   []string{"b", "c", "a"},
+		"Mixed":   []interface{}{2, 1.5, nil, 1},
+		"Tasks": []Task{
+			{"b", 2, day.Add(time.Hour)},
+			{"a", 1, day},
+			{"c", 2, day},
+			{"a", 2, day},
+		},
+	}
+	names := func(tasks ...string) []interface{} {
+		out := make([]interface{}, len(tasks))
+		for i, t := range tasks {
+			out[i] = t
+		}
+		return out
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`sortDesc(Numbers)`, []interface{}{3, 2, 1}},
+		{`sort(Mixed)`, []interface{}{nil, 1, 1.5, 2}},
+		{`map(sortByDesc(Tasks, .Priority), .Name)`, names("b", "c", "a", "a")},
+		{`map(sortBy(Tasks, {.Priority}, {.Name}), .Name)`, names("a", "a", "b", "c")},
+		{`map(sortBy(Tasks, {-.Priority}, {.Name}), .Name)`, names("a", "b", "c", "a")},
+		{`map(sortBy(Tasks, .Due, .Name), .Name)`, names("a", "a", "c", "b")},
+		{`map(sortBy(Tasks, len(.Name) * 0), .Name)`, names("b", "a", "c", "a")},
+		{`sortBy(Numbers, -#)[0]`, 3},
+		{`map(filter(sortBy(Tasks, .Name), .Priority == 2), .Name)`, names("a", "b", "c")},
+		{`sortBy(map(Words, {sortBy(Numbers, # * 1)}), #[0])[0]`, []interface{}{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_sort_errors(t *testing.T) {
+	env := map[string]interface{}{
+		"Flags": []bool{true, false},
+		"Maps":  []map[string]int{{"a": 1}},
+		"Any":   []interface{}{1, "a"},
+	}
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`sort(Flags)`, "cannot sort array of bool (1:6)"},
+		{`sortBy(Maps, #)`, "cannot sort by map[string]int (1:14)"},
+		{`sortBy(Maps, .a, #)`, "cannot sort by map[string]int (1:18)"},
+		{`sort(1)`, "builtin sort takes only array (got int) (1:6)"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		_, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+
+	is := is.New(t)
+	_, err := expr.Eval(`sort(Any)`, env)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "invalid operation: string < int"))
+}
+
+func TestExpr_sort_memory_budget(t *testing.T) {
+	is := is.New(t)
+	env := map[string]interface{}{
+		"Numbers": make([]int, 1e6),
+	}
+
+	_, err := expr.Eval(`sortBy(Numbers, #, #)`, env)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "memory budget exceeded"))
+}
+
+func TestExpr_first_take_takeWhile(t *testing.T) {
+	type Item struct {
+		Name  string
+		Price int
+	}
+	env := map[string]interface{}{
+		"Numbers": []int{1, 2, 3, 4, 5},
+		"Items":   []Item{{"a", 5}, {"b", 15}, {"c", 25}},
+		"Empty":   []int{},
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`first(Numbers, # > 2)`, 3},
+		{`first(Numbers, # > 5)`, nil},
+		{`first(Items, .Price > 10).Name`, "b"},
+		{`first(Empty, true)`, nil},
+		{`first(Numbers)`, 1},
+		{`first(Items).Name`, "a"},
+		{`first(Empty)`, nil},
+		{`first(filter(Numbers, # > 2))`, 3},
+		{`first(filter(Numbers, # > 5))`, nil},
+		{`first(map(Items, .Name))`, "a"},
+		{`first(map(Empty, # * 2))`, nil},
+		{`first(map(Items, .Price), # > 10)`, 15},
+		{`first(map(Numbers, # * 2), # > 20)`, nil},
+		{`first(map(filter(Items, .Price > 10), .Name), # == "c")`, "c"},
+		{`takeWhile(Numbers, # < 3)`, []interface{}{1, 2}},
+		{`takeWhile(Numbers, # < 10)`, []interface{}{1, 2, 3, 4, 5}},
+		{`takeWhile(Numbers, # > 10)`, []interface{}{}},
+		{`take(Numbers, 2)`, []interface{}{1, 2}},
+		{`take(Numbers, 10)`, []interface{}{1, 2, 3, 4, 5}},
+		{`take(Numbers, -1)`, []interface{}{}},
+		{`take(filter(Numbers, # % 2 == 1), 2)`, []interface{}{1, 3}},
+		{`take(filter(Numbers, # % 2 == 1), 0)`, []interface{}{}},
+		{`take(filter(Numbers, # > 3), 5)`, []interface{}{4, 5}},
+		{`len(filter(Numbers, # > 1))`, 4},
+		{`any(filter(Numbers, # > 1), # == 1)`, false},
+		{`all(filter(Numbers, # > 2), # > 2)`, true},
+		{`all(filter(Numbers, # > 2), # > 3)`, false},
+		{`none(filter(Numbers, # > 2), # == 1)`, true},
+		{`one(filter(Numbers, # > 2), # == 3)`, true},
+		{`count(filter(Numbers, # > 2), # < 5)`, 2},
+		{`first(filter(Items, .Price > 10), .Name == "c").Name`, "c"},
+		{`filter(filter(Numbers, # > 1), # < 4)`, []interface{}{2, 3}},
+		{`count(map(Items, .Price), # > 10)`, 2},
+		{`any(map(filter(Items, .Price > 10), .Name), # == "c")`, true},
+		{`count(map(Numbers, # * 2), # > 2 && # < 10)`, 3},
+		{`count(map(Numbers, # * 2), any(Numbers, {# == 2}))`, 5},
+		{`count(map(Items, .Price), all(Numbers, {# < 6}) && # > 10)`, 2},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_fused_builtins_stop_early(t *testing.T) {
+	calls := 0
+	env := map[string]interface{}{
+		"Numbers": []int{1, 2, 3, 4, 5, 6},
+		"Visit": func(n int) bool {
+			calls++
+			return n%2 == 0
+		},
+	}
+
+	tests := []struct {
+		code  string
+		want  interface{}
+		calls int
+	}{
+		{`any(filter(Numbers, Visit(#)), # == 2)`, true, 2},
+		{`first(filter(Numbers, Visit(#)), # > 2)`, 4, 4},
+		{`take(filter(Numbers, Visit(#)), 2)`, []interface{}{2, 4}, 4},
+		{`first(Numbers, Visit(#))`, 2, 2},
+		{`first(filter(Numbers, Visit(#)))`, 2, 2},
+		{`first(map(Numbers, Visit(#)))`, false, 1},
+		// The found element is mapped once more for the result.
+		{`first(map(Numbers, Visit(#)), #)`, true, 3},
+		{`takeWhile(Numbers, !Visit(#))`, []interface{}{1}, 2},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		calls = 0
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+		is.Msg(tt.code).Equal(tt.calls, calls)
+	}
+}
+
+func TestExpr_try(t *testing.T) {
+	type User struct {
+		Name string
+	}
+	env := map[string]interface{}{
+		"Numbers": []int{1, 2, 0, 4},
+		"Zero":    0,
+		"Users":   []*User{{"a"}, nil, {"c"}},
+		"Parse": func(s string) (int, error) {
+			if s == "" {
+				return 0, fmt.Errorf("empty input")
+			}
+			return len(s), nil
+		},
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`try(1 + 2, 0)`, 3},
+		{`try(10 % Zero > 1, false)`, false},
+		{`try(10 % Zero, -1)`, -1},
+		{`try(Parse("ab"), -1)`, 2},
+		{`try(Parse(""), -1)`, -1},
+		{`try(Parse(""), {#error})`, "empty input"},
+		{`try(Parse(""), {#error contains "empty" ? 0 : 1})`, 0},
+		{`try(Parse(""), {})`, map[string]interface{}{}},
+		{`try(Parse(""), {a: 1}).a`, 1},
+		{`map(Users, try(.Name, "?"))`, []interface{}{"a", "?", "c"}},
+		{`map(Numbers, try(12 % #, {#}))`, []interface{}{0, 0, 0, 0}},
+		{`map(Numbers, try(12 % #, {-1}))`, []interface{}{0, 0, -1, 0}},
+		{`try(map(Numbers, 12 % #), [])`, []interface{}{}},
+		{`try(all(Numbers, 12 % # == 0), false) || true`, true},
+		{`try(try(Parse(""), Parse("")), {#error + "!"})`, "empty input!"},
+		{`try(Parse(""), {try(Parse(#error + ""), {#error})})`, 11},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+
+		// The program must be reusable after an error was caught.
+		got, err = expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_try_errors(t *testing.T) {
+	env := map[string]interface{}{
+		"Zero": 0,
+	}
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`#error`, "cannot use #error outside try handler"},
+		{`try(#error, 0)`, "cannot use #error outside try handler"},
+		{`try(1, #error)`, "cannot use #error outside try handler"},
+		{`try(1, {#})`, "cannot use pointer accessor outside closure"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		_, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+
+	is := is.New(t)
+	program, err := expr.Compile(`try(1 % Zero > 0, {1 % Zero})`, expr.Env(env))
+	is.NotErr(err)
+	_, err = expr.Run(program, env)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "integer divide by zero"))
+}
+
+func TestExpr_define(t *testing.T) {
+	type Item struct {
+		Price float64
+	}
+	env := map[string]interface{}{
+		"Items":    []Item{{100}, {50}},
+		"Discount": 10.0,
+	}
+	defs := []expr.Option{
+		expr.Env(env),
+		expr.Define("discount(price float, pct float) float", "price * (1 - pct / 100)"),
+		expr.Define("sale(price)", "discount(price, Discount)"),
+		expr.Define("fact(n int) int", "n <= 1 ? 1 : n * fact(n - 1)"),
+		expr.Define("fib(n int)", "n < 2 ? n : fib(n - 1) + fib(n - 2)"),
+		expr.Define("total()", "sum(map(Items, sale(.Price)))"),
+		expr.Define("sum(xs array)", "reduce(xs, 0)"),
+		expr.Define("reduce(xs array, acc)", "len(xs) == 0 ? acc : reduce(xs[1:], acc + xs[0])"),
+		expr.Define("inverse(x)", "try(1 % x, {#error})"),
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`discount(200, 25)`, 150.0},
+		{`sale(100)`, 90.0},
+		{`map(Items, discount(.Price, 50))`, []interface{}{50.0, 25.0}},
+		{`fact(5)`, 120},
+		{`fib(10)`, 55},
+		{`total()`, 135.0},
+		{`inverse(0)`, "runtime error: integer divide by zero"},
+		{`try(fact(5) % (fact(0) - 1), -1)`, -1},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, defs...)
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+}
+
+func TestExpr_define_compiled_once(t *testing.T) {
+	is := is.New(t)
+	defs := []expr.Option{
+		expr.Define("discount(price float)", "price * (1 - Discount / 100)"),
+		expr.Define("sale(price)", "discount(price)"),
+	}
+	compile := func(env interface{}) *vm.Program {
+		program, err := expr.Compile(`sale(100)`, append([]expr.Option{expr.Env(env)}, defs...)...)
+		is.NotErr(err)
+		return program
+	}
+
+	first := compile(map[string]interface{}{"Discount": 10.0})
+	second := compile(map[string]interface{}{"Discount": 20.0})
+	is.Equal(len(first.Definitions), 1)
+	is.True(first.Definitions[0] == second.Definitions[0])
+
+	// Bodies are compiled again with other types of env.
+	other := compile(map[string]interface{}{"Discount": 10})
+	is.True(first.Definitions[0] != other.Definitions[0])
+	got, err := expr.Run(other, map[string]interface{}{"Discount": 10})
+	is.NotErr(err)
+	is.Equal(got, 90.0)
+}
+
+func TestExpr_define_errors(t *testing.T) {
+	env := map[string]interface{}{}
+
+	tests := []struct {
+		code string
+		def  expr.Option
+		err  string
+	}{
+		{`twice(1, 2)`, expr.Define("twice(x)", "x * 2"), "too many arguments to call twice"},
+		{`twice("a")`, expr.Define("twice(x int)", "x * 2"), "cannot use string as argument (type int) to call twice"},
+		{`twice(1)`, expr.Define("twice(x string)", "x * 2"), "twice: invalid operation: * (mismatched types string and int)"},
+		{`twice(1)`, expr.Define("twice(x int) string", "x * 2"), "twice: body returns int, not string"},
+		{`twice(1)`, expr.Define("twice(x)", "y * 2"), "twice: unknown name y"},
+		{`loop(1)`, expr.Define("loop(x)", "loop(x + 1)"), "maximum call depth 100 exceeded in loop"},
+		{`twice`, expr.Define("twice(x)", "x * 2"), "twice is a function and must be called (1:1)"},
+		{`map([1], twice)`, expr.Define("twice(x)", "x * 2"), "twice is a function and must be called (1:10)"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env), tt.def)
+		if err == nil {
+			_, err = expr.Run(program, env)
+		}
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+
+	signatures := []struct {
+		sig string
+		err string
+	}{
+		{"twice x y", `invalid signature "twice x y": unexpected token Identifier("y") (1:9)`},
+		{"twice(x int", `invalid signature "twice(x int": unexpected token EOF (1:11)`},
+		{"twice(x y z)", `invalid signature "twice(x y z)": unexpected token Identifier("z") (1:11)`},
+		{"twice(x, x)", `invalid signature "twice(x, x)": duplicate parameter x (1:11)`},
+		{"twice(x number)", "unknown type number in signature of twice"},
+		{"twice(x) number", "unknown type number in signature of twice"},
+	}
+	for _, tt := range signatures {
+		is := is.New(t)
+		_, err := expr.Compile(`1`, expr.Define(tt.sig, "1"))
+		is.Msg(tt.sig).Err(err)
+		is.Msg(tt.sig).Equal(tt.err, strings.Split(err.Error(), "\n")[0])
+	}
+}
+
+func TestExpr_function_concurrent_compile(t *testing.T) {
+	is := is.New(t)
+	opts := []expr.Option{
+		expr.Function("g", func(params ...interface{}) (interface{}, error) {
+			return params[0], nil
+		}, new(func(int) int)),
+		expr.Define("twice(x int) int", "g(x) * 2"),
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := expr.Compile(`twice(g(1))`, opts...)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		is.NotErr(err)
+	}
+}
+
+func TestExpr_library(t *testing.T) {
+	type User struct {
+		Spend   float64
+		Country string
+	}
+	type Env struct {
+		User  User
+		Price float64
+	}
+	env := Env{User: User{Spend: 2000, Country: "NL"}, Price: 200}
+
+	is := is.New(t)
+	pricing := expr.NewLibrary("pricing", expr.Env(Env{}))
+	is.NotErr(pricing.Set("isVip", "User.Spend > 1000"))
+	is.NotErr(pricing.Set("isEU", `User.Country in ["NL", "DE"]`))
+	is.NotErr(pricing.Set("discount(price float, pct float) float", "price * (1 - pct / 100)"))
+	is.NotErr(pricing.Set("vipPrice", "isVip ? discount(Price, 10) : Price"))
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`pricing.isVip`, true},
+		{`pricing.isVip && pricing.isEU`, true},
+		{`pricing.discount(Price, 50)`, 100.0},
+		{`pricing.vipPrice`, 180.0},
+		{"import \"pricing\"\nisVip && vipPrice < Price", true},
+		{"import \"pricing\"\ndiscount(100, 25)", 75.0},
+	}
+
+	for _, tt := range tests {
+		program, err := expr.Compile(tt.code, expr.Env(Env{}), expr.Libraries(pricing))
+		is.Msg(tt.code).NotErr(err)
+
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+
+	// The type of a definition is known to the checker.
+	_, err := expr.Compile(`pricing.isVip + 1`, expr.Env(Env{}), expr.Libraries(pricing))
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "invalid operation: + (mismatched types bool and int)"))
+
+	// Changing a definition recompiles its dependents. Programs compiled
+	// before keep using the old definitions.
+	before, err := expr.Compile(`pricing.vipPrice`, expr.Env(Env{}), expr.Libraries(pricing))
+	is.NotErr(err)
+	is.NotErr(pricing.Set("isVip", "User.Spend > 5000"))
+	after, err := expr.Compile(`pricing.vipPrice`, expr.Env(Env{}), expr.Libraries(pricing))
+	is.NotErr(err)
+
+	got, err := expr.Run(before, env)
+	is.NotErr(err)
+	is.Equal(180.0, got)
+	got, err = expr.Run(after, env)
+	is.NotErr(err)
+	is.Equal(200.0, got)
+}
+
+func TestExpr_library_errors(t *testing.T) {
+	type Env struct {
+		Price float64
+	}
+
+	is := is.New(t)
+	lib := expr.NewLibrary("lib", expr.Env(Env{}))
+	is.NotErr(lib.Set("a", "Price > 10"))
+	is.NotErr(lib.Set("b", "a || Price < 0"))
+	is.NotErr(lib.Set("c", "b && a"))
+
+	err := lib.Set("a", "c")
+	is.Err(err)
+	is.Equal("dependency cycle: a -> c -> b -> a", err.Error())
+
+	// Definitions may call themselves.
+	is.NotErr(lib.Set("fact(n int) int", "n <= 1 ? 1 : n * fact(n - 1)"))
+
+	// A change which breaks a dependent is rejected.
+	err = lib.Set("a", "Price")
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "lib.b: invalid operation: || (mismatched types float64 and bool)"))
+
+	err = lib.Set("e", "unknown > 1")
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "lib.e: unknown name unknown"))
+
+	program, err := expr.Compile(`lib.c`, expr.Env(Env{}), expr.Libraries(lib))
+	is.NotErr(err)
+	got, err := expr.Run(program, Env{Price: 20})
+	is.NotErr(err)
+	is.Equal(true, got)
+
+	program, err = expr.Compile(`lib.fact(5)`, expr.Env(Env{}), expr.Libraries(lib))
+	is.NotErr(err)
+	got, err = expr.Run(program, Env{})
+	is.NotErr(err)
+	is.Equal(120, got)
+
+	// Definitions read fields of the environment of the library.
+	type Other struct {
+		Name  string
+		Price float64
+	}
+	_, err = expr.Compile(`lib.a`, expr.Env(Other{}), expr.Libraries(lib))
+	is.ErrMsg(err, "library lib is compiled with another env (Price differs)")
+	_, err = expr.Compile(`lib.a`, expr.Libraries(lib), expr.Env(map[string]interface{}{"Price": 1.0}))
+	is.ErrMsg(err, "library lib is compiled with another env (Price differs)")
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`lib.x`, "unknown name x in library lib"},
+		{`import "other" a`, "unknown library other"},
+		{`lib.a()`, "bool is not callable"},
+	}
+	for _, tt := range tests {
+		_, err := expr.Compile(tt.code, expr.Env(Env{}), expr.Libraries(lib))
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+}
+
+func TestExpr_actions(t *testing.T) {
+	type Env struct {
+		Score  float64
+		Tier   string
+		Visits int
+		Tags   []string `expr:"tags"`
+	}
+
+	is := is.New(t)
+	code := `Score = Score + 10; Visits = Visits + 1; Tier = Score > 100 ? "gold" : "silver"; tags = ["new"];`
+
+	program, err := expr.Compile(code, expr.Env(&Env{}), expr.AsActions())
+	is.NotErr(err)
+
+	env := &Env{Score: 95, Visits: 2}
+	got, err := expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(map[string]interface{}{
+		"Score":  105.0,
+		"Visits": 3,
+		"Tier":   "gold",
+		"tags":   []string{"new"},
+	}, got)
+	is.Equal(&Env{Score: 95, Visits: 2}, env) // env is not changed
+
+	program, err = expr.Compile(code, expr.Env(&Env{}), expr.MutateEnv())
+	is.NotErr(err)
+
+	_, err = expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(&Env{Score: 105, Visits: 3, Tier: "gold", Tags: []string{"new"}}, env)
+
+	mapEnv := map[string]interface{}{"count": 1, "label": ""}
+	program, err = expr.Compile(`count = count * 2; label = count > 1 ? "many" : "one"`, expr.Env(mapEnv), expr.MutateEnv())
+	is.NotErr(err)
+
+	got, err = expr.Run(program, mapEnv)
+	is.NotErr(err)
+	is.Equal(map[string]interface{}{"count": 2, "label": "many"}, got)
+	is.Equal(map[string]interface{}{"count": 2, "label": "many"}, mapEnv)
+
+	program, err = expr.Compile(``, expr.AsActions())
+	is.NotErr(err)
+	got, err = expr.Run(program, nil)
+	is.NotErr(err)
+	is.Equal(map[string]interface{}{}, got)
+}
+
+func TestExpr_actions_errors(t *testing.T) {
+	type Env struct {
+		Score  float64
+		Visits int
+		Name   string
+		Small  int8
+		Any    interface{}
+	}
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`Unknown = 1`, "cannot assign to unknown name Unknown"},
+		{`Small = 1000`, "cannot use 1000 as int8 in assignment to Small"},
+		{`Small = -129`, "cannot use -129 as int8 in assignment to Small"},
+		{`Score = "a"`, "cannot use string as float64 in assignment to Score"},
+		{`Visits = Score`, "cannot use float64 as int in assignment to Visits"},
+		{`Score == 1`, "unexpected token Operator(\"==\")"},
+		{`Score = 1 Visits = 2`, "unexpected token Identifier(\"Visits\")"},
+		{`Score.X = 1`, "unexpected token Operator(\".\")"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		_, err := expr.Compile(tt.code, expr.Env(&Env{}), expr.AsActions())
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+
+	// The env is not changed if an assignment fails.
+	is := is.New(t)
+	program, err := expr.Compile(`Visits = 5; Score = Visits % (Visits - 5)`, expr.Env(&Env{}), expr.MutateEnv())
+	is.NotErr(err)
+	env := &Env{Visits: 1}
+	_, err = expr.Run(program, env)
+	is.Err(err)
+	is.Equal(1, env.Visits)
+
+	// Numbers must fit into the type of the variable.
+	overflows := []struct {
+		code string
+		err  string
+	}{
+		{`Small = Small * 300`, "cannot use 300 as int8 in assignment to Small"},
+		{`Small = 100; Small = Small + Small`, "cannot use 200 as int8 in assignment to Small"},
+		{`Visits = Any`, "cannot use 1.5 as int in assignment to Visits"},
+	}
+	for _, tt := range overflows {
+		program, err = expr.Compile(tt.code, expr.Env(&Env{}), expr.MutateEnv())
+		is.Msg(tt.code).NotErr(err)
+		_, err = expr.Run(program, &Env{Small: 1, Any: 1.5})
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+	}
+	program, err = expr.Compile(`Small = Small * 100; Visits = Any`, expr.Env(&Env{}), expr.MutateEnv())
+	is.NotErr(err)
+	env = &Env{Small: 1, Any: 2.0}
+	_, err = expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(int8(100), env.Small)
+	is.Equal(2, env.Visits)
+
+	// A struct env must be passed by pointer to be changed.
+	program, err = expr.Compile(`Visits = 5`, expr.Env(Env{}), expr.MutateEnv())
+	is.NotErr(err)
+	_, err = expr.Run(program, Env{})
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "env must be a map or a pointer to struct"))
+}
+
+func TestExpr_integer_division(t *testing.T) {
+	env := map[string]interface{}{
+		"a": 7,
+		"b": -2,
+		"c": uint8(3),
+		"f": 2.0,
+	}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`a / c`, 2},
+		{`-7 / 2`, -3},
+		{`a / f`, 3.5},
+		{`a / b * b + a % b`, 7},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env), expr.IntegerDivision())
+		is.Msg(tt.code).NotErr(err)
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+
+	is := is.New(t)
+	got, err := expr.Eval(`7 / 2`, nil)
+	is.NotErr(err)
+	is.Equal(3.5, got)
+
+	_, err = expr.Compile(`1 / 0`, expr.IntegerDivision())
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "integer divide by zero"))
+}
+
+func TestExpr_checked_arithmetic(t *testing.T) {
+	env := map[string]interface{}{
+		"max":   math.MaxInt64,
+		"min":   math.MinInt64,
+		"big":   uint64(math.MaxUint64),
+		"small": int8(math.MinInt8),
+		"one":   1,
+	}
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`max + one`, "integer overflow: 9223372036854775807 + 1"},
+		{`-small`, "integer overflow: --128"},
+		{`big + one`, "integer overflow: 18446744073709551615 + 1"},
+		{`min / -1`, "integer overflow: -9223372036854775808 / -1"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env), expr.CheckedArithmetic(), expr.IntegerDivision())
+		is.Msg(tt.code).NotErr(err)
+		_, err = expr.Run(program, env)
+		is.Msg(tt.code).Err(err)
+		is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+
+		var overflow *runtime.OverflowError
+		is.Msg(tt.code).True(errors.As(err, &overflow))
+	}
+
+	is := is.New(t)
+	got, err := expr.Eval(`max + one`, env)
+	is.NotErr(err)
+	is.Equal(math.MinInt64, got)
+
+	program, err := expr.Compile(`max - one + min / 2 * -1`, expr.Env(env), expr.CheckedArithmetic())
+	is.NotErr(err)
+	got, err = expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(9223372036854775806+4611686018427387904.0, got)
+
+	program, err = expr.Compile(`try(max + one, -1)`, expr.Env(env), expr.CheckedArithmetic())
+	is.NotErr(err)
+	got, err = expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(-1, got)
+
+	// Constant folding reports overflow instead of hiding it.
+	_, err = expr.Compile(`9223372036854775807 + 1`, expr.CheckedArithmetic())
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "integer overflow: 9223372036854775807 + 1"))
+
+	got, err = expr.Eval(`9223372036854775807 + 1`, nil)
+	is.NotErr(err)
+	is.Equal(math.MinInt64, got)
+}
+
+func TestExpr_decimal(t *testing.T) {
+	type Env struct {
+		Price    runtime.Decimal
+		Quantity int
+		Rate     float64
+		Total    runtime.Decimal
+	}
+	env := Env{Price: runtime.NewDecimal(1999, 2), Quantity: 3, Rate: 0.1}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`0.1d + 0.2d == 0.3d`, true},
+		{`0.1 + 0.2 == 0.3`, false},
+		{`string(Price * Quantity)`, "59.97"},
+		{`string(-Price)`, "-19.99"},
+		{`string(10.00d / 4)`, "2.50"},
+		{`string(Price * Rate)`, "1.999"},
+		{`Price < 20`, true},
+		{`Price == 19.990d`, true},
+		{`1d in [1, 2]`, true},
+		{`len(set([1.0d, 1d, 1, 0.1d, 0.10d]))`, 2},
+		{`int(Price)`, 19},
+		{`float(Price)`, 19.99},
+		{`string(abs(-Price))`, "19.99"},
+		{`string(decimal(Quantity))`, "3"},
+		{`Price is decimal`, true},
+		{`toJSON(1.50d)`, "1.50"},
+		{`string(Price ** 2)`, "399.6001"},
+		{`string(2d ** -2)`, "0.25"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env), expr.Function("string", func(params ...interface{}) (interface{}, error) {
+			return fmt.Sprint(params[0]), nil
+		}))
+		is.Msg(tt.code).NotErr(err)
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+
+	is := is.New(t)
+	program, err := expr.Compile(`Total = Price * Quantity - 0.5`, expr.Env(&Env{}), expr.MutateEnv())
+	is.NotErr(err)
+	e := &Env{Price: runtime.NewDecimal(1999, 2), Quantity: 3}
+	_, err = expr.Run(program, e)
+	is.NotErr(err)
+	is.Equal("59.47", e.Total.String())
+
+	program, err = expr.Compile(`Total = 10`, expr.Env(&Env{}), expr.MutateEnv())
+	is.NotErr(err)
+	_, err = expr.Run(program, e)
+	is.NotErr(err)
+	is.Equal("10", e.Total.String())
+
+	_, err = expr.Compile(`Quantity = Price`, expr.Env(&Env{}), expr.AsActions())
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "cannot use runtime.Decimal as int in assignment to Quantity"))
+
+	_, err = expr.Eval(`1d / 0`, nil)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "decimal divide by zero"))
+
+	for _, code := range []string{`2d ** 0.5`, `2 ** 2d`, `Price ** Price`} {
+		_, err = expr.Compile(code, expr.Env(env))
+		is.Msg(code).Err(err)
+		is.Msg(code).True(strings.Contains(err.Error(), "invalid operation: ** (decimal powers need an integer exponent)"))
+	}
+	_, err = expr.Eval(`x ** 0.5`, map[string]interface{}{"x": runtime.NewDecimal(2, 0)})
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "invalid operation: decimal ** float64 (decimal powers need an integer exponent)"))
+
+	_, err = expr.Compile(`decimal("1.2.3")`)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `invalid decimal "1.2.3"`))
+
+	_, err = expr.Compile(`1e99999999d`)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `decimal "1e99999999" is out of range`))
+
+	_, err = expr.Eval(`decimal(S)`, map[string]interface{}{"S": "1e99999999"})
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), `decimal "1e99999999" is out of range`))
+
+	_, err = expr.Eval(`Price ** 10000000`, env)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "decimal power out of range: 19.99 ** 10000000"))
+
+	_, err = expr.Eval(`map(1..3000, Price ** 2000)`, env)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "memory budget exceeded"))
+
+	_, err = expr.Compile(`0x1Fd + 1d2`)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "bad number syntax"))
+
+	// With DecimalLiterals float literals are decimals.
+	got, err := expr.Eval(`0.1 + 0.2 == 0.3`, nil)
+	is.NotErr(err)
+	is.Equal(false, got)
+	program, err = expr.Compile(`0.1 + 0.2 == 0.3 && Price * 1.1 == 21.989`, expr.Env(env), expr.DecimalLiterals())
+	is.NotErr(err)
+	got, err = expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(true, got)
+}
+
+func TestExpr_numeric_tower(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	type Env struct {
+		Adults   uint32
+		Children uint32
+		Age      int8
+		A, B     uint8
+		U, C     uint32
+		I16      int16
+		Count    int
+		Price    float64
+		Big      *big.Int
+		Total    *big.Int
+		U64      uint64
+	}
+	env := Env{Adults: 2, Children: 1, Age: 100, A: 200, B: 100, C: 2, I16: 32000, Count: 3, Price: 1.5, Big: huge, U64: 1 << 63}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`Adults * 2 + Children`, 5},
+		{`Adults % 2`, uint32(0)},
+		{`Age + 28`, 128},
+		{`A + B > 250`, true},
+		{`U - 1 < 0`, true},
+		{`Adults / Children`, 2.0},
+		{`Big * Adults`, new(big.Int).Mul(huge, big.NewInt(2))},
+		{`-Big`, new(big.Int).Neg(huge)},
+		{`Big == 100000000000000000000`, true},
+		{`Big == 100000000000000000001`, false},
+		{`int(Big / Big)`, 1},
+		{`Big is bigint`, true},
+		{`string(Big + 1d)`, "100000000000000000001"},
+		{`U64 > 0`, true},
+		{`U64 == -9223372036854775808`, false},
+		{`string(U64 + 1)`, "9223372036854775809"},
+		{`string(U64 * 2)`, "18446744073709551616"},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env), expr.Function("string", func(params ...interface{}) (interface{}, error) {
+			return fmt.Sprint(params[0]), nil
+		}))
+		is.Msg(tt.code).NotErr(err)
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+
+	is := is.New(t)
+	program, err := expr.Compile(`Adults / Children`, expr.Env(env), expr.IntegerDivision())
+	is.NotErr(err)
+	got, err := expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal(uint32(2), got)
+
+	program, err = expr.Compile(`Big / 3`, expr.Env(env), expr.IntegerDivision())
+	is.NotErr(err)
+	got, err = expr.Run(program, env)
+	is.NotErr(err)
+	is.Equal("33333333333333333333", fmt.Sprint(got))
+
+	// Checked operations keep sized types, and fail out of their range.
+	checked := []struct {
+		code string
+		want interface{}
+		err  string
+	}{
+		{code: `Adults + 1`, want: uint32(3)},
+		{code: `Adults * 2 + Children`, want: uint32(5)},
+		{code: `U - 1`, want: -1},
+		{code: `U - C`, err: "integer overflow: 0 - 2"},
+		{code: `Age + Age`, err: "integer overflow: 100 + 100"},
+	}
+	for _, tt := range checked {
+		program, err := expr.Compile(tt.code, expr.Env(env), expr.CheckedArithmetic())
+		is.Msg(tt.code).NotErr(err)
+		got, err := expr.Run(program, env)
+		if tt.err != "" {
+			is.Msg(tt.code).Err(err)
+			is.Msg(tt.code).True(strings.Contains(err.Error(), tt.err))
+			continue
+		}
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+
+	for _, code := range []string{`int(Big * Big)`, `int(decimal(Big * Big))`} {
+		_, err = expr.Eval(code, env)
+		is.Msg(code).Err(err)
+		is.Msg(code).True(strings.Contains(err.Error(), "cannot convert 10000000000000000000000000000000000000000 to int (out of range)"))
+	}
+
+	program, err = expr.Compile(`Total = Count * 2`, expr.Env(&Env{}), expr.MutateEnv())
+	is.NotErr(err)
+	e := &Env{Count: 3}
+	_, err = expr.Run(program, e)
+	is.NotErr(err)
+	is.Equal(big.NewInt(6), e.Total)
+}
+
+type money int64
+
+func (m money) Add(o money) money   { return m + o }
+func (m money) Sub(o money) money   { return m - o }
+func (m money) Mul(n float64) money { return money(float64(m) * n) }
+func (m money) Div(n int) money     { return m / money(n) }
+func (m money) Compare(o money) int { return int(m - o) }
+func (m money) String() string      { return fmt.Sprintf("$%d.%02d", m/100, m%100) }
+
+type label struct{ name string }
+
+func (l *label) Equal(o *label) bool { return strings.EqualFold(l.name, o.name) }
+
+func TestExpr_operator_methods(t *testing.T) {
+	type Env struct {
+		Price    money
+		Discount money
+		Tags     []money
+		A, B     *label
+		P, Q     *label
+	}
+	env := Env{Price: 1999, Discount: 500, A: &label{"x"}, B: &label{"X"}, Q: &label{"q"}}
+
+	tests := []struct {
+		code string
+		want interface{}
+	}{
+		{`Price + Discount`, money(2499)},
+		{`Price - Discount`, money(1499)},
+		{`Price * 2`, money(3998)},
+		{`Price * 0.5`, money(999)},
+		{`Price / 2`, money(999)},
+		{`(Price - Discount) * 2 + Discount`, money(3498)},
+		{`Price > Discount`, true},
+		{`Price <= Discount`, false},
+		{`Price == Price - Discount + Discount`, true},
+		{`Price != Discount`, true},
+		{`A == B`, true},
+		{`A != B`, false},
+		{`P == Q`, false},
+		{`Q == P`, false},
+		{`P != Q`, true},
+		{`P == P`, true},
+		{`P == nil`, true},
+		{`Q != nil`, true},
+		{`Price + Discount > Price`, true},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t)
+		program, err := expr.Compile(tt.code, expr.Env(env))
+		is.Msg(tt.code).NotErr(err)
+		got, err := expr.Run(program, env)
+		is.Msg(tt.code).NotErr(err)
+		is.Msg(tt.code).Equal(tt.want, got)
+	}
+
+	is := is.New(t)
+	program, err := expr.Compile(`Price + Discount`, expr.Env(env))
+	is.NotErr(err)
+	is.True(strings.Contains(program.Disassemble(), "OpCallOperator"))
+
+	_, err = expr.Compile(`A + B`, expr.Env(env))
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "invalid operation: + (mismatched types *expr_test.label and *expr_test.label)"))
+}
+
 // func TestFunction(t *testing.T) {
 // 	add := expr.Function(
 // 		"add",
@@ -10,12 +10,18 @@ type Error struct {
 	Location
 	Message string
 	Snippet string
+	// Err is the cause of a runtime error, if it was an error value.
+	Err error `json:"-"`
 }
 
 func (e *Error) Error() string {
 	return e.format()
 }
 
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 func (e *Error) Bind(source *Source) *Error {
 	if snippet, found := source.Snippet(e.Location.Line); found {
 		snippet := strings.Replace(snippet, "\t", " ", -1)
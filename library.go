@@ -0,0 +1,254 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ilius/expr/conf"
+	"github.com/ilius/expr/vm"
+)
+
+// Library is a set of named expressions and functions, compiled once against
+// the environment of its options and shared by expressions compiled with the
+// Libraries option. Expressions use the definitions as `pricing.isVip`, where
+// pricing is the name of the library, or import all of them by name:
+//
+//	import "pricing"
+//	isVip && discount(Price, 10) > 100
+//
+// Changing a definition with Set recompiles only definitions which depend on
+// it. Programs compiled before keep using the definitions they were compiled
+// with, so they must be compiled again to use the changes.
+//
+// Expressions using the library must be compiled with the same types of
+// environment as the library, as bodies of definitions run with it.
+type Library struct {
+	name    string
+	options []Option
+	mu      sync.RWMutex
+	entries map[string]*libraryEntry
+	types   conf.TypesTable // types of the environment of the options
+}
+
+type libraryEntry struct {
+	signature  string
+	definition *vm.Definition
+	deps       []string // names of definitions called by the body
+}
+
+// NewLibrary creates an empty library. Options, like Env, are used for
+// compiling all definitions of the library.
+func NewLibrary(name string, ops ...Option) *Library {
+	return &Library{
+		name:    name,
+		options: ops,
+		entries: make(map[string]*libraryEntry),
+	}
+}
+
+func (l *Library) Name() string {
+	return l.name
+}
+
+// Set adds or replaces a definition with a signature like in Define. The body
+// can use other definitions of the library by their names. If the body does
+// not compile, if any definition which depends on it does not compile anymore,
+// or if definitions depend on each other in a cycle, the library is not changed.
+// A definition may call itself, as functions added with Define can.
+func (l *Library) Set(signature, body string) error {
+	d, err := newDefinition(signature, body)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make(map[string]*libraryEntry, len(l.entries)+1)
+	for name, e := range l.entries {
+		entries[name] = e
+	}
+	entries[d.Name] = &libraryEntry{signature: signature, definition: d}
+	if err := l.compile(entries, d.Name); err != nil {
+		return err
+	}
+	if path := cycle(entries, d.Name); path != nil {
+		return fmt.Errorf("dependency cycle: %v", strings.Join(path, " -> "))
+	}
+
+	for _, name := range l.dependents(d.Name) {
+		e := entries[name]
+		redefined, err := newDefinition(e.signature, e.definition.Body)
+		if err != nil {
+			return err
+		}
+		entries[name] = &libraryEntry{signature: e.signature, definition: redefined}
+		if err := l.compile(entries, name); err != nil {
+			return err
+		}
+	}
+
+	l.entries = entries
+	return nil
+}
+
+func (l *Library) compile(entries map[string]*libraryEntry, name string) error {
+	config, err := newConfig(l.options)
+	if err != nil {
+		return err
+	}
+	l.types = config.Types
+	l.addTo(config, entries)
+	config.Imports = []string{l.name}
+
+	e := entries[name]
+	if err := compileDefinition(config, e.definition); err != nil {
+		return fmt.Errorf("%v.%w", l.name, err)
+	}
+	for _, d := range e.definition.Program.Definitions {
+		if dep, ok := entries[d.Name]; ok && dep.definition == d && d.Name != name {
+			e.deps = append(e.deps, d.Name)
+		}
+	}
+	return nil
+}
+
+func (l *Library) addTo(config *conf.Config, entries map[string]*libraryEntry) {
+	for name, e := range entries {
+		config.Definitions[l.name+"."+name] = e.definition
+	}
+	config.Libraries[l.name] = true
+}
+
+// dependents returns names of definitions which depend on the definition
+// directly or indirectly, with every definition after its dependencies.
+func (l *Library) dependents(name string) []string {
+	affected := map[string]bool{name: true}
+	for changed := true; changed; {
+		changed = false
+		for other, e := range l.entries {
+			if affected[other] {
+				continue
+			}
+			for _, dep := range e.deps {
+				if affected[dep] {
+					affected[other] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(affected))
+	for other := range affected {
+		names = append(names, other)
+	}
+	sort.Strings(names)
+
+	var order []string
+	visited := map[string]bool{name: true}
+	var visit func(string)
+	visit = func(n string) {
+		if visited[n] {
+			return
+		}
+		visited[n] = true
+		for _, dep := range l.entries[n].deps {
+			if affected[dep] {
+				visit(dep)
+			}
+		}
+		order = append(order, n)
+	}
+	for _, n := range names {
+		visit(n)
+	}
+	return order
+}
+
+// cycle returns a path of definitions from the definition back to itself,
+// if there is one.
+func cycle(entries map[string]*libraryEntry, name string) []string {
+	path := []string{name}
+	visited := make(map[string]bool)
+	var visit func(string) bool
+	visit = func(n string) bool {
+		for _, dep := range entries[n].deps {
+			if dep == name {
+				path = append(path, dep)
+				return true
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			path = append(path, dep)
+			if visit(dep) {
+				return true
+			}
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	if visit(name) {
+		return path
+	}
+	return nil
+}
+
+// Libraries makes definitions of the libraries available to the expression.
+func Libraries(libs ...*Library) Option {
+	return func(c *conf.Config) {
+		for _, l := range libs {
+			l.mu.RLock()
+			l.addTo(c, l.entries)
+			if l.types != nil {
+				c.LibraryTypes[l.name] = l.types
+			}
+			l.mu.RUnlock()
+		}
+	}
+}
+
+// checkLibraries reports an error if a library is compiled with other types
+// of environment than the config, as bodies of its definitions would read
+// fields of the wrong type at run time.
+func checkLibraries(config *conf.Config) error {
+	names := make([]string, 0, len(config.LibraryTypes))
+	for name := range config.LibraryTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		types := config.LibraryTypes[name]
+		variables := make([]string, 0, len(types))
+		for variable := range types {
+			variables = append(variables, variable)
+		}
+		sort.Strings(variables)
+		for _, variable := range variables {
+			got, ok := config.Types[variable]
+			if !ok || !sameTag(got, types[variable]) {
+				return fmt.Errorf("library %v is compiled with another env (%v differs)", name, variable)
+			}
+		}
+	}
+	return nil
+}
+
+func sameTag(a, b conf.Tag) bool {
+	if a.Type != b.Type || a.Ambiguous != b.Ambiguous || a.Method != b.Method ||
+		a.MethodIndex != b.MethodIndex || len(a.FieldIndex) != len(b.FieldIndex) {
+		return false
+	}
+	for i := range a.FieldIndex {
+		if a.FieldIndex[i] != b.FieldIndex[i] {
+			return false
+		}
+	}
+	return true
+}
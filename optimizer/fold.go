@@ -1,16 +1,20 @@
 package optimizer
 
 import (
+	"fmt"
 	"math"
 	"reflect"
 
 	. "github.com/ilius/expr/ast"
 	"github.com/ilius/expr/file"
+	"github.com/ilius/expr/vm/runtime"
 )
 
 type fold struct {
-	applied bool
-	err     *file.Error
+	applied         bool
+	err             *file.Error
+	checked         bool
+	integerDivision bool
 }
 
 func (fold *fold) Visit(node *Node) {
@@ -30,7 +34,9 @@ func (fold *fold) Visit(node *Node) {
 		switch n.Operator {
 		case "-":
 			if i, ok := n.Node.(*IntegerNode); ok {
-				patchWithType(&IntegerNode{Value: -i.Value}, n.Node.Type())
+				if v, ok := fold.integer(*node, "negate", i.Value, 0); ok {
+					patchWithType(&IntegerNode{Value: v}, n.Node.Type())
+				}
 			}
 			if i, ok := n.Node.(*FloatNode); ok {
 				patchWithType(&FloatNode{Value: -i.Value}, n.Node.Type())
@@ -55,7 +61,9 @@ func (fold *fold) Visit(node *Node) {
 				a := toInteger(n.Left)
 				b := toInteger(n.Right)
 				if a != nil && b != nil {
-					patchWithType(&IntegerNode{Value: a.Value + b.Value}, a.Type())
+					if v, ok := fold.integer(*node, "+", a.Value, b.Value); ok {
+						patchWithType(&IntegerNode{Value: v}, a.Type())
+					}
 				}
 			}
 			{
@@ -91,7 +99,9 @@ func (fold *fold) Visit(node *Node) {
 				a := toInteger(n.Left)
 				b := toInteger(n.Right)
 				if a != nil && b != nil {
-					patchWithType(&IntegerNode{Value: a.Value - b.Value}, a.Type())
+					if v, ok := fold.integer(*node, "-", a.Value, b.Value); ok {
+						patchWithType(&IntegerNode{Value: v}, a.Type())
+					}
 				}
 			}
 			{
@@ -120,7 +130,9 @@ func (fold *fold) Visit(node *Node) {
 				a := toInteger(n.Left)
 				b := toInteger(n.Right)
 				if a != nil && b != nil {
-					patchWithType(&IntegerNode{Value: a.Value * b.Value}, a.Type())
+					if v, ok := fold.integer(*node, "*", a.Value, b.Value); ok {
+						patchWithType(&IntegerNode{Value: v}, a.Type())
+					}
 				}
 			}
 			{
@@ -148,7 +160,20 @@ func (fold *fold) Visit(node *Node) {
 			{
 				a := toInteger(n.Left)
 				b := toInteger(n.Right)
-				if a != nil && b != nil {
+				// Integers used as floats are divided as floats by the VM.
+				integers := n.Type() == nil || n.Type().Kind() == reflect.Int
+				if a != nil && b != nil && fold.integerDivision && integers {
+					if b.Value == 0 {
+						fold.err = &file.Error{
+							Location: (*node).Location(),
+							Message:  "integer divide by zero",
+						}
+						return
+					}
+					if v, ok := fold.integer(*node, "/", a.Value, b.Value); ok {
+						patchWithType(&IntegerNode{Value: v}, a.Type())
+					}
+				} else if a != nil && b != nil {
 					patchWithType(&FloatNode{Value: float64(a.Value) / float64(b.Value)}, a.Type())
 				}
 			}
@@ -318,6 +343,44 @@ func toString(n Node) *StringNode {
 	return nil
 }
 
+// integerOps are the unchecked and checked runtime functions of integer
+// operations, which results may overflow.
+var integerOps = map[string][2]func(a, b interface{}) interface{}{
+	"+":      {runtime.Add, runtime.AddChecked},
+	"-":      {runtime.Subtract, runtime.SubtractChecked},
+	"*":      {runtime.Multiply, runtime.MultiplyChecked},
+	"/":      {runtime.DivideInt, runtime.DivideIntChecked},
+	"negate": {negate, negateChecked},
+}
+
+func negate(a, _ interface{}) interface{} {
+	return runtime.Negate(a)
+}
+
+func negateChecked(a, _ interface{}) interface{} {
+	return runtime.NegateChecked(a)
+}
+
+// integer folds an integer operation with the same function as the VM, so
+// an overflow in checked arithmetic is a compile error, and is never hidden
+// by folding.
+func (fold *fold) integer(node Node, op string, a, b int) (result int, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			fold.err = &file.Error{
+				Location: node.Location(),
+				Message:  fmt.Sprintf("%v", r),
+			}
+			ok = false
+		}
+	}()
+	fn := integerOps[op][0]
+	if fold.checked {
+		fn = integerOps[op][1]
+	}
+	return fn(a, b).(int), true
+}
+
 func toInteger(n Node) *IntegerNode {
 	switch a := n.(type) {
 	case *IntegerNode:
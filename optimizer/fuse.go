@@ -0,0 +1,194 @@
+package optimizer
+
+import (
+	"reflect"
+
+	. "github.com/ilius/expr/ast"
+	"github.com/ilius/expr/builtin"
+)
+
+var boolType = reflect.TypeOf(true)
+
+// fuse merges chains of collection builtins into a single loop, so no
+// intermediate arrays are allocated and loops stop as early as possible:
+//
+//	len(filter(a, p))       -> count(a, p)
+//	any(filter(a, p), q)    -> any(a, p && q)    (also none, one, count, first, filter)
+//	all(filter(a, p), q)    -> all(a, !p || q)
+//	any(map(a, f), q)       -> any(a, q(f(#)))   (also all, none, one, count)
+//	first(map(a, f), q)     -> f(first(a, q(f(#))))
+//	first(filter(a, p))     -> first(a, p)
+//	first(map(a, f))        -> f(first(a))
+type fuse struct {
+	applied bool
+}
+
+func (f *fuse) Visit(node *Node) {
+	switch n := (*node).(type) {
+	case *CallNode:
+		if n.Func == nil || n.Func.Opcode != builtin.Len || len(n.Arguments) != 1 {
+			return
+		}
+		if filter, ok := n.Arguments[0].(*BuiltinNode); ok && filter.Name == "filter" {
+			f.patch(node, &BuiltinNode{
+				Name:      "count",
+				Arguments: filter.Arguments,
+			})
+		}
+
+	case *BuiltinNode:
+		if len(n.Arguments) == 0 {
+			return
+		}
+		inner, ok := n.Arguments[0].(*BuiltinNode)
+		if !ok || len(inner.Arguments) != 2 {
+			return
+		}
+		p, ok := inner.Arguments[1].(*ClosureNode)
+		if !ok {
+			return
+		}
+
+		if len(n.Arguments) == 1 {
+			if n.Name != "first" {
+				return
+			}
+			switch inner.Name {
+			case "filter":
+				f.patch(node, &BuiltinNode{
+					Name:      n.Name,
+					Arguments: []Node{inner.Arguments[0], p},
+				})
+			case "map":
+				f.patch(node, &BuiltinNode{
+					Name:      n.Name,
+					Arguments: []Node{inner.Arguments[0], closure(boolean(true)), p},
+				})
+			}
+			return
+		}
+
+		q, ok := n.Arguments[1].(*ClosureNode)
+		if !ok {
+			return
+		}
+		// The mapping of first, added by fusing it over map.
+		mapping := n.Arguments[2:]
+
+		switch inner.Name {
+		case "filter":
+			var predicate Node
+			switch n.Name {
+			case "any", "none", "one", "count", "first", "filter":
+				predicate = and(p.Node, q.Node)
+			case "all":
+				predicate = or(not(p.Node), q.Node)
+			default:
+				return
+			}
+			f.patch(node, &BuiltinNode{
+				Name:      n.Name,
+				Arguments: append([]Node{inner.Arguments[0], closure(predicate)}, mapping...),
+			})
+
+		case "map":
+			switch n.Name {
+			case "any", "all", "none", "one", "count", "first":
+			default:
+				return
+			}
+			if len(mapping) > 0 {
+				return
+			}
+			// The mapping closure is evaluated once per pointer, so only
+			// predicates which use the element once are fused.
+			pointers := topLevelPointers(&q.Node)
+			if len(pointers) != 1 {
+				return
+			}
+			*pointers[0] = p.Node
+			arguments := []Node{inner.Arguments[0], q}
+			if n.Name == "first" {
+				// The found element is mapped once more for the result.
+				arguments = append(arguments, p)
+			}
+			f.patch(node, &BuiltinNode{
+				Name:      n.Name,
+				Arguments: arguments,
+			})
+		}
+	}
+}
+
+func (f *fuse) patch(node *Node, newNode Node) {
+	f.applied = true
+	Patch(node, newNode)
+}
+
+func and(a, b Node) Node {
+	node := &BinaryNode{Operator: "&&", Left: a, Right: b}
+	node.SetType(boolType)
+	return node
+}
+
+func or(a, b Node) Node {
+	node := &BinaryNode{Operator: "||", Left: a, Right: b}
+	node.SetType(boolType)
+	return node
+}
+
+func not(a Node) Node {
+	node := &UnaryNode{Operator: "not", Node: a}
+	node.SetType(boolType)
+	return node
+}
+
+func boolean(value bool) Node {
+	node := &BoolNode{Value: value}
+	node.SetType(boolType)
+	return node
+}
+
+func closure(body Node) Node {
+	node := &ClosureNode{Node: body}
+	node.SetType(body.Type())
+	return node
+}
+
+// topLevelPointers returns pointers (# and .field) of the closure body,
+// except pointers of nested closures, which refer to their own elements.
+func topLevelPointers(body *Node) []*Node {
+	all := &pointerCollector{}
+	Walk(body, all)
+	nested := &pointerCollector{}
+	for _, c := range all.closures {
+		Walk(c, nested)
+	}
+	skip := make(map[*Node]bool)
+	for _, p := range nested.pointers {
+		skip[p] = true
+	}
+	var out []*Node
+	for _, p := range all.pointers {
+		if !skip[p] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+type pointerCollector struct {
+	pointers []*Node
+	closures []*Node
+}
+
+func (c *pointerCollector) Visit(node *Node) {
+	switch n := (*node).(type) {
+	case *PointerNode:
+		if n.Name == "" {
+			c.pointers = append(c.pointers, node)
+		}
+	case *ClosureNode:
+		c.closures = append(c.closures, node)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 
 	. "github.com/ilius/expr/ast"
+	"github.com/ilius/expr/vm/runtime"
 )
 
 type inArray struct{}
@@ -12,53 +13,94 @@ func (*inArray) Visit(node *Node) {
 	switch n := (*node).(type) {
 	case *BinaryNode:
 		if n.Operator == "in" {
-			if array, ok := n.Right.(*ArrayNode); ok {
-				if len(array.Nodes) > 0 {
-					t := n.Left.Type()
-					if t == nil || t.Kind() != reflect.Int {
-						// This optimization can be only performed if left side is int type,
-						// as runtime.in func uses reflect.Map.MapIndex and keys of map must,
-						// be same as checked value type.
-						goto string
-					}
-
-					for _, a := range array.Nodes {
-						if _, ok := a.(*IntegerNode); !ok {
-							goto string
-						}
-					}
-					{
-						value := make(map[int]struct{})
-						for _, a := range array.Nodes {
-							value[a.(*IntegerNode).Value] = struct{}{}
-						}
-						Patch(node, &BinaryNode{
-							Operator: n.Operator,
-							Left:     n.Left,
-							Right:    &ConstantNode{Value: value},
-						})
-					}
-
-				string:
-					for _, a := range array.Nodes {
-						if _, ok := a.(*StringNode); !ok {
-							return
-						}
-					}
-					{
-						value := make(map[string]struct{})
-						for _, a := range array.Nodes {
-							value[a.(*StringNode).Value] = struct{}{}
-						}
-						Patch(node, &BinaryNode{
-							Operator: n.Operator,
-							Left:     n.Left,
-							Right:    &ConstantNode{Value: value},
-						})
-					}
+			values, ok := constantArray(n.Right)
+			if !ok || len(values) == 0 {
+				return
+			}
 
+			var value interface{}
+			switch t := n.Left.Type(); {
+			case t != nil && t.Kind() == reflect.Int && allOf(values, isInt):
+				// runtime.in func uses reflect.Map.MapIndex and keys of map must,
+				// be same as checked value type.
+				set := make(map[int]struct{})
+				for _, v := range values {
+					set[v.(int)] = struct{}{}
 				}
+				value = set
+			case t != nil && t.Kind() == reflect.String && allOf(values, isString):
+				set := make(map[string]struct{})
+				for _, v := range values {
+					set[v.(string)] = struct{}{}
+				}
+				value = set
+			default:
+				// Left side may be of any type, runtime.Set normalizes numbers
+				// and never fails on values of other types.
+				value = runtime.NewSet(values)
+			}
+			Patch(node, &BinaryNode{
+				Operator: n.Operator,
+				Left:     n.Left,
+				Right:    &ConstantNode{Value: value},
+			})
+		}
+	}
+}
+
+// constantArray returns values of an array of scalar literals, or of such
+// an array already folded into a constant.
+func constantArray(node Node) ([]interface{}, bool) {
+	switch n := node.(type) {
+	case *ArrayNode:
+		values := make([]interface{}, len(n.Nodes))
+		for i, a := range n.Nodes {
+			switch a := a.(type) {
+			case *IntegerNode:
+				values[i] = a.Value
+			case *StringNode:
+				values[i] = a.Value
+			case *FloatNode:
+				values[i] = a.Value
+			case *BoolNode:
+				values[i] = a.Value
+			default:
+				return nil, false
+			}
+		}
+		return values, true
+	case *ConstantNode:
+		values, ok := n.Value.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		for _, v := range values {
+			switch v.(type) {
+			case int, string, float64, bool:
+			default:
+				return nil, false
 			}
 		}
+		return values, true
+	}
+	return nil, false
+}
+
+func allOf(values []interface{}, fn func(interface{}) bool) bool {
+	for _, v := range values {
+		if !fn(v) {
+			return false
+		}
 	}
+	return true
+}
+
+func isInt(v interface{}) bool {
+	_, ok := v.(int)
+	return ok
+}
+
+func isString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
 }
@@ -0,0 +1,48 @@
+package optimizer
+
+import (
+	"net"
+
+	. "github.com/ilius/expr/ast"
+	"github.com/ilius/expr/builtin"
+	"github.com/ilius/expr/vm/runtime"
+)
+
+// inCIDR replaces constant lists of networks, like
+// `ip in [cidr("10.0.0.0/8"), cidr("192.168.0.0/16")]`,
+// with a prefix trie.
+type inCIDR struct{}
+
+func (*inCIDR) Visit(node *Node) {
+	switch n := (*node).(type) {
+	case *BinaryNode:
+		if n.Operator == "in" {
+			if array, ok := n.Right.(*ArrayNode); ok {
+				if len(array.Nodes) == 0 {
+					return
+				}
+				nets := make([]*net.IPNet, 0, len(array.Nodes))
+				for _, a := range array.Nodes {
+					call, ok := a.(*CallNode)
+					if !ok || call.Func == nil || call.Func.Opcode != builtin.CIDR || len(call.Arguments) != 1 {
+						return
+					}
+					c, ok := call.Arguments[0].(*ConstantNode)
+					if !ok {
+						return
+					}
+					ipNet, ok := c.Value.(*net.IPNet)
+					if !ok {
+						return
+					}
+					nets = append(nets, ipNet)
+				}
+				Patch(node, &BinaryNode{
+					Operator: n.Operator,
+					Left:     n.Left,
+					Right:    &ConstantNode{Value: runtime.NewIPTrie(nets)},
+				})
+			}
+		}
+	}
+}
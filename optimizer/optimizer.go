@@ -7,8 +7,13 @@ import (
 
 func Optimize(node *Node, config *conf.Config) error {
 	Walk(node, &inArray{})
+	Walk(node, &inCIDR{})
 	for limit := 1000; limit >= 0; limit-- {
 		fold := &fold{}
+		if config != nil {
+			fold.checked = config.CheckedArithmetic
+			fold.integerDivision = config.IntegerDivision
+		}
 		Walk(node, fold)
 		if fold.err != nil {
 			return fold.err
@@ -31,6 +36,15 @@ func Optimize(node *Node, config *conf.Config) error {
 			}
 		}
 	}
+	for limit := 100; limit >= 0; limit-- {
+		fuse := &fuse{}
+		Walk(node, fuse)
+		if !fuse.applied {
+			break
+		}
+	}
+	// Arrays may become constant only after folding.
+	Walk(node, &inArray{})
 	Walk(node, &inRange{})
 	Walk(node, &constRange{})
 	return nil
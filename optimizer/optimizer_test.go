@@ -1,6 +1,7 @@
 package optimizer_test
 
 import (
+	"net"
 	"strings"
 	"testing"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/ilius/expr/conf"
 	"github.com/ilius/expr/optimizer"
 	"github.com/ilius/expr/parser"
+	"github.com/ilius/expr/vm/runtime"
 	"github.com/ilius/is/v2"
 )
 
@@ -72,6 +74,171 @@ func TestOptimize_in_array(t *testing.T) {
 	is.Equal(ast.Dump(expected), ast.Dump(tree.Node))
 }
 
+func TestOptimize_in_array_of_strings(t *testing.T) {
+	is := is.New(t)
+	config := conf.New(map[string]interface{}{"s": "", "v": nil})
+
+	tree, err := parser.Parse(`s in ["a", "b"] and v in ["a", 1, 2.5]`)
+	is.NotErr(err)
+
+	_, err = checker.Check(tree, config)
+	is.NotErr(err)
+
+	err = optimizer.Optimize(&tree.Node, nil)
+	is.NotErr(err)
+
+	expected := &ast.BinaryNode{
+		Operator: "and",
+		Left: &ast.BinaryNode{
+			Operator: "in",
+			Left:     &ast.IdentifierNode{Value: "s"},
+			Right:    &ast.ConstantNode{Value: map[string]struct{}{"a": {}, "b": {}}},
+		},
+		Right: &ast.BinaryNode{
+			Operator: "in",
+			Left:     &ast.IdentifierNode{Value: "v"},
+			Right:    &ast.ConstantNode{Value: runtime.Set{"a": {}, 1: {}, 2.5: {}}},
+		},
+	}
+	is.Equal(ast.Dump(expected), ast.Dump(tree.Node))
+}
+
+func TestOptimize_in_cidr(t *testing.T) {
+	is := is.New(t)
+	config := conf.New(map[string]string{"addr": ""})
+
+	tree, err := parser.Parse(`ip(addr) in [cidr("10.0.0.0/8"), cidr("192.168.0.0/16")]`)
+	is.NotErr(err)
+
+	_, err = checker.Check(tree, config)
+	is.NotErr(err)
+
+	err = optimizer.Optimize(&tree.Node, nil)
+	is.NotErr(err)
+
+	node, ok := tree.Node.(*ast.BinaryNode)
+	is.True(ok)
+	constant, ok := node.Right.(*ast.ConstantNode)
+	is.True(ok)
+	trie, ok := constant.Value.(*runtime.IPTrie)
+	is.True(ok)
+	is.True(trie.Contains(net.ParseIP("10.1.2.3")))
+	is.True(trie.Contains(net.ParseIP("192.168.255.1")))
+	is.True(!trie.Contains(net.ParseIP("192.169.0.1")))
+}
+
+func TestOptimize_fuse(t *testing.T) {
+	config := conf.New(map[string]interface{}{"a": []int{}})
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{`len(filter(a, # > 1))`, `count(a, # > 1)`},
+		{`any(filter(a, # > 1), # < 5)`, `any(a, # > 1 && # < 5)`},
+		{`all(filter(a, # > 1), # < 5)`, `all(a, not (# > 1) || # < 5)`},
+		{`count(map(filter(a, # > 1), # * 2), # < 5)`, `count(a, # > 1 && # * 2 < 5)`},
+		{`any(map(a, # * 2), # > 1 && # < 5)`, `any(map(a, # * 2), # > 1 && # < 5)`},
+		{`any(map(a, # * 2), all(a, {# > 1}))`, `any(map(a, # * 2), all(a, # > 1))`},
+		{`first(filter(a, # > 1))`, `first(a, # > 1)`},
+		{`first(filter(a, # > 1), # < 5)`, `first(a, # > 1 && # < 5)`},
+		{`first(map(a, # * 2), # > 1 && # < 5)`, `first(map(a, # * 2), # > 1 && # < 5)`},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t).Msg(tt.code)
+		tree, err := parser.Parse(tt.code)
+		is.NotErr(err)
+
+		_, err = checker.Check(tree, config)
+		is.NotErr(err)
+
+		err = optimizer.Optimize(&tree.Node, nil)
+		is.NotErr(err)
+
+		expected, err := parser.Parse(tt.want)
+		is.NotErr(err)
+		is.Equal(ast.Dump(expected.Node), ast.Dump(tree.Node))
+	}
+}
+
+func TestOptimize_fuse_first_over_map(t *testing.T) {
+	config := conf.New(map[string]interface{}{"a": []int{}})
+
+	double := &ast.BinaryNode{
+		Operator: "*",
+		Left:     &ast.PointerNode{},
+		Right:    &ast.IntegerNode{Value: 2},
+	}
+	tests := []struct {
+		code string
+		want ast.Node
+	}{
+		{
+			`first(map(a, # * 2), # > 1)`,
+			&ast.BuiltinNode{
+				Name: "first",
+				Arguments: []ast.Node{
+					&ast.IdentifierNode{Value: "a"},
+					&ast.ClosureNode{Node: &ast.BinaryNode{
+						Operator: ">",
+						Left:     double,
+						Right:    &ast.IntegerNode{Value: 1},
+					}},
+					&ast.ClosureNode{Node: double},
+				},
+			},
+		},
+		{
+			`first(map(a, # * 2))`,
+			&ast.BuiltinNode{
+				Name: "first",
+				Arguments: []ast.Node{
+					&ast.IdentifierNode{Value: "a"},
+					&ast.ClosureNode{Node: &ast.BoolNode{Value: true}},
+					&ast.ClosureNode{Node: double},
+				},
+			},
+		},
+		{
+			`first(map(filter(a, # > 1), # * 2), # < 5)`,
+			&ast.BuiltinNode{
+				Name: "first",
+				Arguments: []ast.Node{
+					&ast.IdentifierNode{Value: "a"},
+					&ast.ClosureNode{Node: &ast.BinaryNode{
+						Operator: "&&",
+						Left: &ast.BinaryNode{
+							Operator: ">",
+							Left:     &ast.PointerNode{},
+							Right:    &ast.IntegerNode{Value: 1},
+						},
+						Right: &ast.BinaryNode{
+							Operator: "<",
+							Left:     double,
+							Right:    &ast.IntegerNode{Value: 5},
+						},
+					}},
+					&ast.ClosureNode{Node: double},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		is := is.New(t).Msg(tt.code)
+		tree, err := parser.Parse(tt.code)
+		is.NotErr(err)
+
+		_, err = checker.Check(tree, config)
+		is.NotErr(err)
+
+		err = optimizer.Optimize(&tree.Node, nil)
+		is.NotErr(err)
+		is.Equal(ast.Dump(tt.want), ast.Dump(tree.Node))
+	}
+}
+
 func TestOptimize_in_range(t *testing.T) {
 	is := is.New(t)
 	tree, err := parser.Parse(`age in 18..31`)
@@ -17,7 +17,7 @@ type lexTest struct {
 
 var lexTests = []lexTest{
 	{
-		".5 0.025 1 02 1e3 0xFF 1.2e-4 1_000_000 _42 -.5",
+		".5 0.025 1 02 1e3 0xFF 1.2e-4 1_000_000 _42 -.5 12.50d 3d 0xFd",
 		[]Token{
 			{Kind: Number, Value: ".5"},
 			{Kind: Number, Value: "0.025"},
@@ -30,6 +30,9 @@ var lexTests = []lexTest{
 			{Kind: Identifier, Value: "_42"},
 			{Kind: Operator, Value: "-"},
 			{Kind: Number, Value: ".5"},
+			{Kind: Number, Value: "12.50d"},
+			{Kind: Number, Value: "3d"},
+			{Kind: Number, Value: "0xFd"},
 			{Kind: EOF},
 		},
 	},
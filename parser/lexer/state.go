@@ -32,7 +32,7 @@ func root(l *lexer) stateFn {
 		l.emit(Bracket)
 	case strings.ContainsRune(")]}", r):
 		l.emit(Bracket)
-	case strings.ContainsRune("#,?:%+-^", r): // single rune operator
+	case strings.ContainsRune("#,?:%+-^;", r): // single rune operator
 		l.emit(Operator)
 	case strings.ContainsRune("&|!=*<>", r): // possible double rune operator
 		l.accept("&|=*")
@@ -58,7 +58,8 @@ func number(l *lexer) stateFn {
 }
 
 func (l *lexer) scanNumber() bool {
-	digits := "0123456789_"
+	const decimalDigits = "0123456789_"
+	digits := decimalDigits
 	// Is it hex?
 	if l.accept("0") {
 		// Note: Leading 0 does not mean octal in floats.
@@ -87,6 +88,10 @@ func (l *lexer) scanNumber() bool {
 		l.accept("+-")
 		l.acceptRun(digits)
 	}
+	// Decimal literal, like 12.50d.
+	if digits == decimalDigits {
+		l.accept("d")
+	}
 	// Next thing mustn't be alphanumeric.
 	if IsAlphaNumeric(l.peek()) {
 		l.next()
@@ -117,7 +122,7 @@ loop:
 			switch l.word() {
 			case "not":
 				return not
-			case "in", "or", "and", "matches", "contains", "startsWith", "endsWith":
+			case "in", "or", "and", "matches", "contains", "startsWith", "endsWith", "subsetOf", "supersetOf":
 				l.emit(Operator)
 			default:
 				l.emit(Identifier)
@@ -147,7 +152,7 @@ func not(l *lexer) stateFn {
 	}
 
 	switch l.word() {
-	case "in", "matches", "contains", "startsWith", "endsWith":
+	case "in", "matches", "contains", "startsWith", "endsWith", "subsetOf", "supersetOf":
 		l.emit(Operator)
 	default:
 		l.end, l.loc, l.prev = pos, loc, prev
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -9,6 +10,7 @@ import (
 	. "github.com/ilius/expr/ast"
 	"github.com/ilius/expr/file"
 	. "github.com/ilius/expr/parser/lexer"
+	"github.com/ilius/expr/vm/runtime"
 )
 
 type associativity int
@@ -46,6 +48,9 @@ var binaryOperators = map[string]operator{
 	">=":         {20, left},
 	"<=":         {20, left},
 	"in":         {20, left},
+	"subsetOf":   {20, left},
+	"supersetOf": {20, left},
+	"is":         {20, left},
 	"matches":    {20, left},
 	"contains":   {20, left},
 	"startsWith": {20, left},
@@ -53,9 +58,11 @@ var binaryOperators = map[string]operator{
 	"..":         {25, left},
 	"+":          {30, left},
 	"-":          {30, left},
+	"|":          {30, left},
 	"*":          {60, left},
 	"/":          {60, left},
 	"%":          {60, left},
+	"&":          {60, left},
 	"**":         {100, right},
 	"^":          {100, right},
 }
@@ -68,6 +75,41 @@ var builtins = map[string]builtin{
 	"filter": {2},
 	"map":    {2},
 	"count":  {2},
+
+	"first":     {2},
+	"takeWhile": {2},
+
+	"sort":       {1},
+	"sortDesc":   {1},
+	"sortBy":     {2},
+	"sortByDesc": {2},
+
+	"try": {2},
+}
+
+// coreBuiltins are parsed as builtins even if the environment defines
+// the name. Other builtins are parsed as calls of the env function, see
+// Shadow, so adding builtins doesn't change the meaning of expressions of
+// environments which already define such names.
+var coreBuiltins = map[string]bool{
+	"all":    true,
+	"none":   true,
+	"any":    true,
+	"one":    true,
+	"filter": true,
+	"map":    true,
+	"count":  true,
+}
+
+// predicateOptional builtins can be called without the predicate.
+var predicateOptional = map[string]bool{
+	"first": true,
+}
+
+// variadicBuiltins accept more closures after the last one.
+var variadicBuiltins = map[string]bool{
+	"sortBy":     true,
+	"sortByDesc": true,
 }
 
 type parser struct {
@@ -76,14 +118,39 @@ type parser struct {
 	pos     int
 	err     *file.Error
 	depth   int // closure call depth
+	defined func(name string) bool
+}
+
+// Option configures the parser.
+type Option func(p *parser)
+
+// Shadow makes calls of builtins, except the core ones, which names are
+// defined by the environment, calls of the env functions.
+func Shadow(defined func(name string) bool) Option {
+	return func(p *parser) {
+		p.defined = defined
+	}
 }
 
 type Tree struct {
 	Node   Node
 	Source *file.Source
+	// Imports are names of libraries imported with `import "name"`
+	// before the expression.
+	Imports []string
 }
 
-func Parse(input string) (*Tree, error) {
+func Parse(input string, opts ...Option) (*Tree, error) {
+	return parse(input, false, opts)
+}
+
+// ParseActions parses a list of assignments separated by semicolons,
+// like `score = score + 10; tier = "gold"`.
+func ParseActions(input string, opts ...Option) (*Tree, error) {
+	return parse(input, true, opts)
+}
+
+func parse(input string, actions bool, opts []Option) (*Tree, error) {
 	source := file.NewSource(input)
 
 	tokens, err := Lex(source)
@@ -95,8 +162,23 @@ func Parse(input string) (*Tree, error) {
 		tokens:  tokens,
 		current: tokens[0],
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
 
-	node := p.parseExpression(0)
+	var imports []string
+	for p.current.Is(Identifier, "import") && p.peek().Is(String) {
+		p.next()
+		imports = append(imports, p.current.Value)
+		p.next()
+	}
+
+	var node Node
+	if actions {
+		node = p.parseActions()
+	} else {
+		node = p.parseExpression(0)
+	}
 
 	if !p.current.Is(EOF) {
 		p.error("unexpected token %v", p.current)
@@ -107,11 +189,32 @@ func Parse(input string) (*Tree, error) {
 	}
 
 	return &Tree{
-		Node:   node,
-		Source: source,
+		Node:    node,
+		Source:  source,
+		Imports: imports,
 	}, nil
 }
 
+func (p *parser) parseActions() Node {
+	node := &ActionsNode{}
+	node.SetLocation(p.current.Location)
+	for !p.current.Is(EOF) && p.err == nil {
+		token := p.current
+		p.expect(Identifier)
+		p.expect(Operator, "=")
+		assign := &AssignNode{
+			Name:  token.Value,
+			Value: p.parseExpression(0),
+		}
+		assign.SetLocation(token.Location)
+		node.Nodes = append(node.Nodes, assign)
+		if !p.current.Is(EOF) {
+			p.expect(Operator, ";")
+		}
+	}
+	return node
+}
+
 func (p *parser) error(format string, args ...interface{}) {
 	if p.err == nil { // show first error
 		p.err = &file.Error{
@@ -130,6 +233,35 @@ func (p *parser) next() {
 	p.current = p.tokens[p.pos]
 }
 
+// peek returns the token after the current one.
+func (p *parser) peek() Token {
+	return p.peekAt(1)
+}
+
+func (p *parser) peekAt(n int) Token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+// isHandler reports whether the current token starts an error handler of
+// try, like {#error}, rather than a map literal. Map literals start with
+// a key followed by a colon, or are empty.
+func (p *parser) isHandler() bool {
+	if !p.current.Is(Bracket, "{") {
+		return false
+	}
+	key := p.peek()
+	if key.Is(Bracket, "}") {
+		return false
+	}
+	if key.Is(Number) || key.Is(String) || key.Is(Identifier) {
+		return !p.peekAt(2).Is(Operator, ":")
+	}
+	return true
+}
+
 func (p *parser) expect(kind Kind, values ...string) {
 	if p.current.Is(kind, values...) {
 		p.next()
@@ -144,7 +276,7 @@ func (p *parser) parseExpression(precedence int) Node {
 	nodeLeft := p.parsePrimary()
 
 	token := p.current
-	for token.Is(Operator) && p.err == nil {
+	for (token.Is(Operator) || isTypeTest(token)) && p.err == nil {
 		negate := false
 		var notToken Token
 
@@ -160,7 +292,14 @@ func (p *parser) parseExpression(precedence int) Node {
 				p.next()
 
 				var nodeRight Node
-				if op.associativity == left {
+				if token.Value == "is" {
+					if p.current.Is(Operator, "not") && !negate {
+						notToken = p.current
+						negate = true
+						p.next()
+					}
+					nodeRight = p.parseTypeName()
+				} else if op.associativity == left {
 					nodeRight = p.parseExpression(op.precedence + 1)
 				} else {
 					nodeRight = p.parseExpression(op.precedence)
@@ -195,6 +334,26 @@ func (p *parser) parseExpression(precedence int) Node {
 	return nodeLeft
 }
 
+// isTypeTest reports whether token is the `is` operator. It is lexed as an
+// identifier, so names like `is` remain usable in the environment, and
+// is treated as an operator only after an operand.
+func isTypeTest(token Token) bool {
+	return token.Is(Identifier, "is")
+}
+
+// parseTypeName parses the right side of the `is` operator.
+func (p *parser) parseTypeName() Node {
+	token := p.current
+	if !token.Is(Identifier) {
+		p.error("expected type name (got %v)", token)
+		return &NilNode{}
+	}
+	p.next()
+	node := &StringNode{Value: token.Value}
+	node.SetLocation(token.Location)
+	return node
+}
+
 func (p *parser) parsePrimary() Node {
 	token := p.current
 
@@ -218,6 +377,14 @@ func (p *parser) parsePrimary() Node {
 		return p.parsePostfixExpression(expr)
 	}
 
+	if token.Is(Operator, "#") && p.peek().Is(Identifier, "error") {
+		p.next()
+		p.next()
+		node := &PointerNode{Name: "error"}
+		node.SetLocation(token.Location)
+		return p.parsePostfixExpression(node)
+	}
+
 	if p.depth > 0 {
 		if token.Is(Operator, "#") || token.Is(Operator, ".") {
 			if token.Is(Operator, "#") {
@@ -296,6 +463,14 @@ func (p *parser) parsePrimaryExpression() Node {
 			node := &IntegerNode{Value: int(number)}
 			node.SetLocation(token.Location)
 			return node
+		} else if strings.HasSuffix(value, "d") {
+			number, err := runtime.ParseDecimal(strings.TrimSuffix(value, "d"))
+			if err != nil {
+				p.error("invalid decimal literal: %v", err)
+			}
+			node := &DecimalNode{Value: number}
+			node.SetLocation(token.Location)
+			return node
 		} else if strings.ContainsAny(value, ".eE") {
 			number, err := strconv.ParseFloat(value, 64)
 			if err != nil {
@@ -307,6 +482,12 @@ func (p *parser) parsePrimaryExpression() Node {
 		} else {
 			number, err := strconv.ParseInt(value, 10, 64)
 			if err != nil {
+				// Integers which do not fit into int are *big.Int.
+				if b, ok := new(big.Int).SetString(value, 10); ok {
+					node := &ConstantNode{Value: b}
+					node.SetLocation(token.Location)
+					return node
+				}
 				p.error("invalid integer literal: %v", err)
 			}
 			node := &IntegerNode{Value: int(number)}
@@ -333,12 +514,17 @@ func (p *parser) parsePrimaryExpression() Node {
 	return p.parsePostfixExpression(node)
 }
 
+// shadowed reports whether the builtin is a call of the env function.
+func (p *parser) shadowed(name string) bool {
+	return !coreBuiltins[name] && p.defined != nil && p.defined(name)
+}
+
 func (p *parser) parseIdentifierExpression(token Token) Node {
 	var node Node
 	if p.current.Is(Bracket, "(") {
 		var arguments []Node
 
-		if b, ok := builtins[token.Value]; ok {
+		if b, ok := builtins[token.Value]; ok && !p.shadowed(token.Value) {
 			p.expect(Bracket, "(")
 			// TODO: Add builtins signatures.
 			if b.arity == 1 {
@@ -347,8 +533,20 @@ func (p *parser) parseIdentifierExpression(token Token) Node {
 			} else if b.arity == 2 {
 				arguments = make([]Node, 2)
 				arguments[0] = p.parseExpression(0)
-				p.expect(Operator, ",")
-				arguments[1] = p.parseClosure()
+				if predicateOptional[token.Value] && p.current.Is(Bracket, ")") {
+					arguments = arguments[:1]
+				} else {
+					p.expect(Operator, ",")
+					if token.Value == "try" && !p.isHandler() {
+						arguments[1] = p.parseExpression(0)
+					} else {
+						arguments[1] = p.parseClosure()
+					}
+				}
+				for variadicBuiltins[token.Value] && p.current.Is(Operator, ",") && p.err == nil {
+					p.next()
+					arguments = append(arguments, p.parseClosure())
+				}
 			}
 			p.expect(Bracket, ")")
 
@@ -440,6 +440,25 @@ func TestParse(t *testing.T) {
 				Node:     &IdentifierNode{Value: "in_var"},
 			},
 		},
+		{
+			"a is int",
+			&BinaryNode{
+				Operator: "is",
+				Left:     &IdentifierNode{Value: "a"},
+				Right:    &StringNode{Value: "int"},
+			},
+		},
+		{
+			"a is not nil",
+			&UnaryNode{
+				Operator: "not",
+				Node: &BinaryNode{
+					Operator: "is",
+					Left:     &IdentifierNode{Value: "a"},
+					Right:    &StringNode{Value: "nil"},
+				},
+			},
+		},
 		{
 			"all(Tickets, #)",
 			&BuiltinNode{
@@ -450,6 +469,54 @@ func TestParse(t *testing.T) {
 						Node: &PointerNode{},
 					}}},
 		},
+		{
+			"sortBy(Tickets, {.Price}, .Name)",
+			&BuiltinNode{
+				Name: "sortBy",
+				Arguments: []Node{
+					&IdentifierNode{Value: "Tickets"},
+					&ClosureNode{
+						Node: &MemberNode{
+							Node:     &PointerNode{},
+							Property: &StringNode{Value: "Price"},
+						},
+					},
+					&ClosureNode{
+						Node: &MemberNode{
+							Node:     &PointerNode{},
+							Property: &StringNode{Value: "Name"},
+						},
+					},
+				},
+			},
+		},
+		{
+			"first(Tickets)",
+			&BuiltinNode{
+				Name:      "first",
+				Arguments: []Node{&IdentifierNode{Value: "Tickets"}},
+			},
+		},
+		{
+			"try(a, {#error})",
+			&BuiltinNode{
+				Name: "try",
+				Arguments: []Node{
+					&IdentifierNode{Value: "a"},
+					&ClosureNode{Node: &PointerNode{Name: "error"}},
+				},
+			},
+		},
+		{
+			"try(a, {b: 1})",
+			&BuiltinNode{
+				Name: "try",
+				Arguments: []Node{
+					&IdentifierNode{Value: "a"},
+					&MapNode{Pairs: []Node{&PairNode{Key: &StringNode{Value: "b"}, Value: &IntegerNode{Value: 1}}}},
+				},
+			},
+		},
 		{
 			"all(Tickets, {.Price > 0})",
 			&BuiltinNode{
@@ -604,6 +671,11 @@ a map key must be a quoted string, a number, a identifier, or an expression encl
 unexpected token Operator(",") (1:16)
  | {foo:1, bar:2, ,}
  | ...............^
+
+all(Tickets)
+unexpected token Bracket(")") (1:12)
+ | all(Tickets)
+ | ...........^
 `
 
 func TestParse_error(t *testing.T) {
@@ -717,3 +789,48 @@ func TestParse_optional_chaining(t *testing.T) {
 		is.Msg(test.input).Equal(ast.Dump(test.expected), ast.Dump(actual.Node))
 	}
 }
+
+func TestParseActions(t *testing.T) {
+	is := is.New(t)
+	actual, err := parser.ParseActions(`a = 1; b = a + 1;`)
+	is.NotErr(err)
+
+	expected := &ActionsNode{
+		Nodes: []Node{
+			&AssignNode{Name: "a", Value: &IntegerNode{Value: 1}},
+			&AssignNode{
+				Name: "b",
+				Value: &BinaryNode{
+					Operator: "+",
+					Left:     &IdentifierNode{Value: "a"},
+					Right:    &IntegerNode{Value: 1},
+				},
+			},
+		},
+	}
+	is.Equal(ast.Dump(expected), ast.Dump(actual.Node))
+}
+
+func TestParse_Shadow(t *testing.T) {
+	is := is.New(t)
+	defined := func(name string) bool { return name == "sort" || name == "map" }
+
+	actual, err := parser.Parse(`sort(a) + map(a, #)`, parser.Shadow(defined))
+	is.NotErr(err)
+
+	expected := &BinaryNode{
+		Operator: "+",
+		Left: &CallNode{
+			Callee:    &IdentifierNode{Value: "sort"},
+			Arguments: []Node{&IdentifierNode{Value: "a"}},
+		},
+		Right: &BuiltinNode{
+			Name: "map",
+			Arguments: []Node{
+				&IdentifierNode{Value: "a"},
+				&ClosureNode{Node: &PointerNode{}},
+			},
+		},
+	}
+	is.Equal(ast.Dump(expected), ast.Dump(actual.Node))
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"github.com/ilius/expr/file"
+	. "github.com/ilius/expr/parser/lexer"
+)
+
+// Signature is a signature of a function written in the expression language,
+// like `discount(price float, pct) float`, or of a named expression without
+// parameters and parentheses, like `isVip bool`. Types are optional.
+type Signature struct {
+	Name   string
+	Params []Param
+	Out    string
+	Value  bool // true for named expressions
+}
+
+type Param struct {
+	Name string
+	Type string
+}
+
+func ParseSignature(input string) (*Signature, error) {
+	source := file.NewSource(input)
+
+	tokens, err := Lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		tokens:  tokens,
+		current: tokens[0],
+	}
+
+	sig := &Signature{Name: p.parseName()}
+	if p.current.Is(Bracket, "(") {
+		p.next()
+		seen := make(map[string]bool)
+		for !p.current.Is(Bracket, ")") && p.err == nil {
+			if len(sig.Params) > 0 {
+				p.expect(Operator, ",")
+			}
+			param := Param{Name: p.parseName()}
+			if seen[param.Name] {
+				p.error("duplicate parameter %v", param.Name)
+			}
+			seen[param.Name] = true
+			if p.current.Is(Identifier) {
+				param.Type = p.parseName()
+			}
+			sig.Params = append(sig.Params, param)
+		}
+		p.expect(Bracket, ")")
+	} else {
+		sig.Value = true
+	}
+	if p.current.Is(Identifier) {
+		sig.Out = p.parseName()
+	}
+
+	if !p.current.Is(EOF) {
+		p.error("unexpected token %v", p.current)
+	}
+
+	if p.err != nil {
+		return nil, p.err.Bind(source)
+	}
+	return sig, nil
+}
+
+func (p *parser) parseName() string {
+	token := p.current
+	p.expect(Identifier)
+	return token.Value
+}
@@ -0,0 +1,179 @@
+package expr
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/ilius/expr/ast"
+	"github.com/ilius/expr/conf"
+	"github.com/ilius/expr/parser"
+)
+
+// VetIssue describes how an expression is affected by a change of env.
+type VetIssue struct {
+	// Index is the index of the expression in the corpus.
+	Index int
+	Input string
+	// Err is the error of checking the expression with the new env, if
+	// it doesn't check anymore.
+	Err error
+	// OldType and NewType are result types of the expression, if both
+	// envs check it and the types differ.
+	OldType reflect.Type
+	NewType reflect.Type
+	// Removed are variables, fields and methods used by the expression,
+	// which the new env doesn't have, like "User.Email" or "Orders[].Total".
+	Removed []string
+}
+
+// Vet checks a corpus of expressions with the old and the new env, given
+// as Env, Schema or EnvFromSnapshot options, to find expressions broken by
+// a change of env types, before they fail at run time. Other options are
+// used with both envs. Expressions which don't check with the old env are
+// not reported.
+func Vet(corpus []string, oldEnv, newEnv Option, ops ...Option) ([]VetIssue, error) {
+	oldConf, err := newConfig(append([]Option{oldEnv}, ops...))
+	if err != nil {
+		return nil, err
+	}
+	newConf, err := newConfig(append([]Option{newEnv}, ops...))
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []VetIssue
+	for i, input := range corpus {
+		oldTree, oldType, err := check(input, oldConf)
+		if err != nil {
+			continue
+		}
+		newTree, newType, err := check(input, newConf)
+		issue := VetIssue{Index: i, Input: input, Err: err}
+		if err == nil && typeString(oldType) != typeString(newType) {
+			issue.OldType = oldType
+			issue.NewType = newType
+		}
+		if newTree != nil {
+			issue.Removed = removed(oldTree, newTree, oldConf, newConf)
+		}
+		if issue.Err != nil || issue.OldType != nil || issue.NewType != nil || len(issue.Removed) > 0 {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+func typeString(t reflect.Type) string {
+	if t == nil {
+		return "nil"
+	}
+	return t.String()
+}
+
+// removed returns references of the old tree missing in the new tree.
+func removed(oldTree, newTree *parser.Tree, oldConf, newConf *conf.Config) []string {
+	before := newReferences(&oldTree.Node, oldConf.Types)
+	after := newReferences(&newTree.Node, newConf.Types)
+
+	var out []string
+	for p := range before.paths {
+		if !after.paths[p] {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// references collects variables of env, and fields and methods of their
+// values, used by an expression, as paths like "User.Address.City".
+type references struct {
+	types conf.TypesTable
+	paths map[string]bool
+	// elements are paths of elements pointed by # in closures.
+	elements map[*ast.PointerNode]string
+	builtins []*ast.BuiltinNode
+}
+
+func newReferences(node *ast.Node, types conf.TypesTable) *references {
+	r := &references{
+		types:    types,
+		paths:    make(map[string]bool),
+		elements: make(map[*ast.PointerNode]string),
+	}
+	ast.Walk(node, r)
+
+	// Builtins are walked after their arguments, so outer builtins are at
+	// the end. Pointers of nested closures are set by inner builtins later.
+	for i := len(r.builtins) - 1; i >= 0; i-- {
+		b := r.builtins[i]
+		collection := r.path(b.Arguments[0])
+		for _, arg := range b.Arguments[1:] {
+			if c, ok := arg.(*ast.ClosureNode); ok {
+				ast.Walk(&c.Node, pointers{r.elements, collection})
+			}
+		}
+	}
+
+	r.builtins = nil
+	ast.Walk(node, r)
+	return r
+}
+
+func (r *references) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.BuiltinNode:
+		if len(n.Arguments) > 1 {
+			r.builtins = append(r.builtins, n)
+		}
+	case *ast.IdentifierNode, *ast.MemberNode:
+		if p := r.path(*node); p != "" {
+			r.paths[p] = true
+		}
+	}
+}
+
+// path returns the path of the node, or an empty string if the node is not
+// a variable of env or a field or method of it, resolved by the checker.
+func (r *references) path(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		if _, ok := r.types[n.Value]; ok {
+			return n.Value
+		}
+	case *ast.PointerNode:
+		if p := r.elements[n]; p != "" {
+			return p + "[]"
+		}
+	case *ast.ChainNode:
+		return r.path(n.Node)
+	case *ast.MemberNode:
+		base := r.path(n.Node)
+		if base == "" {
+			return ""
+		}
+		if n.Name != "" {
+			return base + "." + n.Name
+		}
+		if s, ok := n.Property.(*ast.StringNode); ok {
+			if t := n.Node.Type(); t == nil || t.Kind() == reflect.Interface {
+				return base + "." + s.Value // Not known until run time.
+			}
+			return ""
+		}
+		return base + "[]"
+	}
+	return ""
+}
+
+// pointers sets the collection of pointers of a closure.
+type pointers struct {
+	elements   map[*ast.PointerNode]string
+	collection string
+}
+
+func (p pointers) Visit(node *ast.Node) {
+	if n, ok := (*node).(*ast.PointerNode); ok && n.Name == "" {
+		p.elements[n] = p.collection
+	}
+}
@@ -0,0 +1,97 @@
+package vm
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// MaxCallDepth limits nested calls of functions added with expr.Define,
+// so endless recursion fails with an error.
+var MaxCallDepth = 100
+
+// Definition is a function written in the expression language, added with
+// expr.Define. Its body is compiled once into a separate program, which runs
+// with the environment of the caller.
+type Definition struct {
+	Name    string
+	Params  []string
+	Type    reflect.Type // func type with parameter and result types
+	Body    string
+	Program *Program
+	// Value is true for named expressions of libraries, which are used
+	// without parentheses, like `pricing.isVip`.
+	Value bool
+
+	mu sync.Mutex
+	// compiled holds definitions compiled from this one by key, see Compiled.
+	compiled map[string]map[string]*Definition
+}
+
+// Copy returns a copy of the definition without its program, to be compiled.
+func (d *Definition) Copy() *Definition {
+	return &Definition{
+		Name:   d.Name,
+		Params: d.Params,
+		Type:   d.Type,
+		Body:   d.Body,
+		Value:  d.Value,
+	}
+}
+
+// Compiled returns definitions compiled by compile, reusing the result of an
+// earlier call with the same key, so definitions added once are compiled once
+// for every set of options they are used with.
+func (d *Definition) Compiled(key string, compile func() (map[string]*Definition, error)) (map[string]*Definition, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if set, ok := d.compiled[key]; ok {
+		return set, nil
+	}
+	set, err := compile()
+	if err != nil {
+		return nil, err
+	}
+	if d.compiled == nil {
+		d.compiled = make(map[string]map[string]*Definition)
+	}
+	d.compiled[key] = set
+	return set, nil
+}
+
+// Param returns the index of the parameter with the name, or -1.
+func (d *Definition) Param(name string) int {
+	for i, param := range d.Params {
+		if param == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// callDefinition runs the body of the definition in a new VM, sharing the memory
+// budget and results of lazy values with the caller.
+func (vm *VM) callDefinition(d *Definition, params []interface{}, env interface{}) interface{} {
+	if vm.depth >= MaxCallDepth {
+		panic(fmt.Sprintf("maximum call depth %v exceeded in %v", MaxCallDepth, d.Name))
+	}
+	if vm.lazy == nil {
+		vm.lazy = make(map[string]interface{})
+	}
+	sub := &VM{
+		stack:        make([]interface{}, 0, 2),
+		params:       params,
+		depth:        vm.depth + 1,
+		memory:       vm.memory,
+		memoryBudget: vm.memoryBudget,
+		lazy:         vm.lazy,
+	}
+	for !sub.run(d.Program, env) {
+		// An error was caught by try, continue with its handler.
+	}
+	vm.memory = sub.memory
+	if len(sub.stack) > 0 {
+		return sub.pop()
+	}
+	return nil
+}
@@ -45,6 +45,10 @@ const (
 	OpContains
 	OpStartsWith
 	OpEndsWith
+	OpUnion
+	OpIntersect
+	OpSubsetOf
+	OpSupersetOf
 	OpSlice
 	OpCall
 	OpCall0
@@ -66,5 +70,30 @@ const (
 	OpGetLen
 	OpPointer
 	OpBegin
+	OpSort
+	OpSortBy
+	OpTry
+	OpTryEnd
+	OpError
+	OpCatchEnd
+	OpLoadParam
+	OpCallDefinition
+	OpAssign
+	OpLoadChange
+	OpMutate
+	OpChanges
+	OpCallOperator
+	OpLazy
+	OpIs
+	OpInvoke
 	OpEnd // This opcode must be at the end of this list.
 )
+
+// Flags of the argument of arithmetic opcodes.
+const (
+	// ArithmeticChecked makes integer operations panic with
+	// runtime.OverflowError instead of wrapping around.
+	ArithmeticChecked = 1 << iota
+	// ArithmeticIntegerDivision makes OpDivide of integers return an integer.
+	ArithmeticIntegerDivision
+)
@@ -19,6 +19,9 @@ type Program struct {
 	Bytecode  []Opcode
 	Arguments []int
 	Functions []Function
+	// Definitions are functions added with expr.Define, called with
+	// OpCallDefinition.
+	Definitions []*Definition
 }
 
 func (program *Program) Disassemble() string {
@@ -58,6 +61,9 @@ func (program *Program) Disassemble() string {
 			if method, ok := c.(*runtime.Method); ok {
 				c = fmt.Sprintf("{%v %v}", method.Name, method.Index)
 			}
+			if target, ok := c.(*runtime.Target); ok {
+				c = target.Name
+			}
 			out += fmt.Sprintf("%v\t%v\t%v\t%v\n", pp, label, arg, c)
 		}
 		builtIn := func(label string) {
@@ -195,6 +201,18 @@ func (program *Program) Disassemble() string {
 		case OpEndsWith:
 			code("OpEndsWith")
 
+		case OpUnion:
+			code("OpUnion")
+
+		case OpIntersect:
+			code("OpIntersect")
+
+		case OpSubsetOf:
+			code("OpSubsetOf")
+
+		case OpSupersetOf:
+			code("OpSupersetOf")
+
 		case OpSlice:
 			code("OpSlice")
 
@@ -258,6 +276,54 @@ func (program *Program) Disassemble() string {
 		case OpBegin:
 			code("OpBegin")
 
+		case OpSort:
+			argument("OpSort")
+
+		case OpSortBy:
+			argument("OpSortBy")
+
+		case OpTry:
+			jump("OpTry")
+
+		case OpTryEnd:
+			jump("OpTryEnd")
+
+		case OpError:
+			code("OpError")
+
+		case OpCatchEnd:
+			code("OpCatchEnd")
+
+		case OpLoadParam:
+			argument("OpLoadParam")
+
+		case OpCallDefinition:
+			argument("OpCallDefinition")
+
+		case OpAssign:
+			constant("OpAssign")
+
+		case OpLoadChange:
+			constant("OpLoadChange")
+
+		case OpMutate:
+			code("OpMutate")
+
+		case OpChanges:
+			code("OpChanges")
+
+		case OpCallOperator:
+			constant("OpCallOperator")
+
+		case OpLazy:
+			constant("OpLazy")
+
+		case OpIs:
+			code("OpIs")
+
+		case OpInvoke:
+			code("OpInvoke")
+
 		case OpEnd:
 			code("OpEnd")
 
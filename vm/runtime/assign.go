@@ -0,0 +1,131 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Target is a variable of the environment assigned by actions.
+type Target struct {
+	Name string
+	Type reflect.Type // nil if not known at compile time
+}
+
+// Convert returns the value converted to the type of the target, so
+// integers assigned to float variables become floats.
+func (t *Target) Convert(value interface{}) interface{} {
+	if value == nil || t.Type == nil || t.Type.Kind() == reflect.Interface {
+		return value
+	}
+	return convertTo(value, t.Type, t.Name).Interface()
+}
+
+func convertTo(value interface{}, t reflect.Type, name string) reflect.Value {
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+			return reflect.Zero(t)
+		}
+		panic(fmt.Sprintf("cannot use nil as %v in assignment to %v", t, name))
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return v
+	}
+	if isNumberKind(v.Kind()) && isNumberKind(t.Kind()) {
+		if !fitsNumber(v, t) {
+			panic(fmt.Sprintf("cannot use %v as %v in assignment to %v", value, t, name))
+		}
+		return v.Convert(t)
+	}
+	if t == bigIntType && isInteger(value) {
+		return reflect.ValueOf(ToBigInt(value))
+	}
+	if t == decimalType && isNumber(value) {
+		return reflect.ValueOf(ToDecimal(value))
+	}
+	if d, ok := value.(Decimal); ok && (t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64) {
+		return reflect.ValueOf(d.Float64()).Convert(t)
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && t.Kind() == reflect.Slice {
+		out := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(convertTo(v.Index(i).Interface(), t.Elem(), name))
+		}
+		return out
+	}
+	panic(fmt.Sprintf("cannot use %T as %v in assignment to %v", value, t, name))
+}
+
+// fitsNumber reports whether the number v is converted to the number type t
+// without changing its value, so it is integral and in the range of integer
+// types. Converting to floats only loses precision, unless out of range.
+func fitsNumber(v reflect.Value, t reflect.Type) bool {
+	zero := reflect.Zero(t)
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return !zero.OverflowInt(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return v.Uint() <= math.MaxInt64 && !zero.OverflowInt(int64(v.Uint()))
+		}
+		f := v.Float()
+		return f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 && !zero.OverflowInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int() >= 0 && !zero.OverflowUint(uint64(v.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return !zero.OverflowUint(v.Uint())
+		}
+		f := v.Float()
+		return f == math.Trunc(f) && f >= 0 && f < math.MaxUint64 && !zero.OverflowUint(uint64(f))
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return math.IsInf(f, 0) || math.IsNaN(f) || !zero.OverflowFloat(f)
+	}
+	return true
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// Assign sets a field of a struct env, which must be passed as a pointer,
+// or a key of a map env.
+func Assign(env interface{}, name string, value interface{}) {
+	v := reflect.ValueOf(env)
+	switch {
+	case v.Kind() == reflect.Map:
+		key := reflect.ValueOf(name)
+		if !key.Type().ConvertibleTo(v.Type().Key()) {
+			panic(fmt.Sprintf("cannot assign to %v: env keys are %v", name, v.Type().Key()))
+		}
+		v.SetMapIndex(key.Convert(v.Type().Key()), convertTo(value, v.Type().Elem(), name))
+
+	case v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct:
+		s := v.Elem()
+		field, ok := fieldByName(s.Type(), name)
+		if !ok {
+			panic(fmt.Sprintf("cannot assign to unknown field %v", name))
+		}
+		f, ok := fieldByIndexSafe(s, field.Index)
+		if !ok || !f.CanSet() {
+			panic(fmt.Sprintf("cannot assign to %v", name))
+		}
+		f.Set(convertTo(value, f.Type(), name))
+
+	default:
+		panic(fmt.Sprintf("cannot assign to %v: env must be a map or a pointer to struct (got %T)", name, env))
+	}
+}
@@ -0,0 +1,89 @@
+package runtime_test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+type assignEnv struct {
+	Count int
+	Small int8
+	Size  uint16
+	Ratio float32
+	Rate  float64
+	Total *big.Int
+	Price runtime.Decimal
+	Tags  []string
+	Meta  map[string]interface{}
+	inner int
+}
+
+func TestAssign(t *testing.T) {
+	is := is.New(t)
+	env := &assignEnv{}
+	runtime.Assign(env, "Count", 3)
+	runtime.Assign(env, "Rate", 2)
+	runtime.Assign(env, "Total", 6)
+	runtime.Assign(env, "Price", 1.5)
+	runtime.Assign(env, "Tags", []interface{}{"a", "b"})
+	runtime.Assign(env, "Meta", nil)
+	is.Equal(3, env.Count)
+	is.Equal(2.0, env.Rate)
+	is.Equal(big.NewInt(6), env.Total)
+	is.Equal("1.5", env.Price.String())
+	is.Equal([]string{"a", "b"}, env.Tags)
+	is.True(env.Meta == nil)
+
+	runtime.Assign(env, "Small", -128)
+	runtime.Assign(env, "Size", 2.0)
+	runtime.Assign(env, "Ratio", 1<<40)
+	is.Equal(int8(-128), env.Small)
+	is.Equal(uint16(2), env.Size)
+	is.Equal(float32(1<<40), env.Ratio)
+
+	m := map[string]int{}
+	runtime.Assign(m, "a", 1.0)
+	is.Equal(map[string]int{"a": 1}, m)
+}
+
+func TestAssign_invalid(t *testing.T) {
+	tests := []struct {
+		fn  func()
+		err string
+	}{
+		{func() { runtime.Assign(&assignEnv{}, "Count", "3") }, "cannot use string as int in assignment to Count"},
+		{func() { runtime.Assign(&assignEnv{}, "Count", nil) }, "cannot use nil as int in assignment to Count"},
+		{func() { runtime.Assign(&assignEnv{}, "Tags", []interface{}{1}) }, "cannot use int as string in assignment to Tags"},
+		{func() { runtime.Assign(&assignEnv{}, "Small", 1000) }, "cannot use 1000 as int8 in assignment to Small"},
+		{func() { runtime.Assign(&assignEnv{}, "Small", uint64(200)) }, "cannot use 200 as int8 in assignment to Small"},
+		{func() { runtime.Assign(&assignEnv{}, "Size", -1) }, "cannot use -1 as uint16 in assignment to Size"},
+		{func() { runtime.Assign(&assignEnv{}, "Size", 1e6) }, "cannot use 1e+06 as uint16 in assignment to Size"},
+		{func() { runtime.Assign(&assignEnv{}, "Count", 1.5) }, "cannot use 1.5 as int in assignment to Count"},
+		{func() { runtime.Assign(&assignEnv{}, "Count", 1e19) }, "cannot use 1e+19 as int in assignment to Count"},
+		{func() { runtime.Assign(&assignEnv{}, "Ratio", 1e39) }, "cannot use 1e+39 as float32 in assignment to Ratio"},
+		{func() { runtime.Assign(&assignEnv{}, "Missing", 1) }, "cannot assign to unknown field Missing"},
+		{func() { runtime.Assign(&assignEnv{}, "inner", 1) }, "cannot assign to inner"},
+		{func() { runtime.Assign(assignEnv{}, "Count", 1) }, "cannot assign to Count: env must be a map or a pointer to struct (got runtime_test.assignEnv)"},
+		{func() { runtime.Assign(map[int]int{}, "a", 1) }, "cannot assign to a: env keys are int"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.err).Equal(tt.err, recovered(tt.fn))
+	}
+}
+
+func TestTarget_Convert(t *testing.T) {
+	is := is.New(t)
+	target := &runtime.Target{Name: "Rate", Type: reflect.TypeOf(0.0)}
+	is.Equal(2.0, target.Convert(2))
+	is.Equal(nil, target.Convert(nil))
+	is.Equal(1.5, target.Convert(runtime.NewDecimal(15, 1)))
+
+	// Targets of unknown type take values as is.
+	target = &runtime.Target{Name: "Any"}
+	is.Equal(2, target.Convert(2))
+}
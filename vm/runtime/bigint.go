@@ -0,0 +1,82 @@
+package runtime
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+var bigIntType = reflect.TypeOf(&big.Int{})
+
+// ToBigInt converts integers, decimals and strings to *big.Int. Decimals
+// are truncated toward zero.
+func ToBigInt(v interface{}) *big.Int {
+	switch x := v.(type) {
+	case *big.Int:
+		return x
+	case int, int8, int16, int32, int64:
+		return big.NewInt(ToInt64(x))
+	case uint, uint8, uint16, uint32, uint64:
+		return new(big.Int).SetUint64(toUint64(x))
+	case Decimal:
+		return new(big.Int).Quo(x.int(), pow10(x.scale))
+	case string:
+		i, ok := new(big.Int).SetString(x, 0)
+		if !ok {
+			panic(fmt.Sprintf("invalid operation: bigint(%s)", x))
+		}
+		return i
+	}
+	panic(fmt.Sprintf("invalid operation: bigint(%T)", v))
+}
+
+// bigToInt converts x to int, and panics if it is out of the range of int.
+func bigToInt(x *big.Int) int {
+	if !x.IsInt64() || int64(int(x.Int64())) != x.Int64() {
+		panic(fmt.Sprintf("cannot convert %v to int (out of range)", x))
+	}
+	return int(x.Int64())
+}
+
+func isInteger(v interface{}) bool {
+	switch v.(type) {
+	case *big.Int, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+	return false
+}
+
+// toBigInts converts a and b to *big.Int if one of them is a *big.Int and
+// the other one is an integer.
+func toBigInts(a, b interface{}) (*big.Int, *big.Int, bool) {
+	_, aOk := a.(*big.Int)
+	_, bOk := b.(*big.Int)
+	if !(aOk || bOk) || !isInteger(a) || !isInteger(b) {
+		return nil, nil, false
+	}
+	return ToBigInt(a), ToBigInt(b), true
+}
+
+// bigToFloats converts a and b to float64 if one of them is a *big.Int and
+// the other one is a float.
+func bigToFloats(a, b interface{}) (float64, float64, bool) {
+	_, aOk := a.(*big.Int)
+	_, bOk := b.(*big.Int)
+	if !(aOk || bOk) || !isNumber(a) || !isNumber(b) {
+		return 0, 0, false
+	}
+	return ToFloat64(a), ToFloat64(b), true
+}
+
+func bigFloat(x *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(x).Float64()
+	return f
+}
+
+func bigQuo(x, y *big.Int) float64 {
+	if y.Sign() == 0 {
+		panic("integer divide by zero")
+	}
+	f, _ := new(big.Rat).SetFrac(x, y).Float64()
+	return f
+}
@@ -0,0 +1,141 @@
+package runtime_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func TestToBigInt(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{42, "42"},
+		{int8(-8), "-8"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{big.NewInt(7), "7"},
+		{runtime.NewDecimal(-1999, 2), "-19"},
+		{"100000000000000000000", "100000000000000000000"},
+		{"0x10", "16"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%#v", tt.value).Equal(tt.want, runtime.ToBigInt(tt.value).String())
+	}
+
+	is := is.New(t)
+	is.Equal("invalid operation: bigint(1.5)", recovered(func() { runtime.ToBigInt("1.5") }))
+	is.Equal("invalid operation: bigint(float64)", recovered(func() { runtime.ToBigInt(1.5) }))
+}
+
+func TestBigInt_operators(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	tests := []struct {
+		got  interface{}
+		want interface{}
+	}{
+		{runtime.Add(huge, 1), "100000000000000000001"},
+		{runtime.Add(uint8(1), huge), "100000000000000000001"},
+		{runtime.Multiply(huge, uint32(2)), "200000000000000000000"},
+		{runtime.Subtract(huge, huge), "0"},
+		{runtime.Modulo(huge, 7), "2"},
+		{runtime.Negate(huge), "-100000000000000000000"},
+		{runtime.DivideInt(huge, 3), "33333333333333333333"},
+		{runtime.Divide(huge, 4), 2.5e19},
+		{runtime.Add(huge, 0.5), 1e20 + 0.5},
+		{runtime.Less(huge, 1e21), true},
+		{runtime.More(huge, 3), true},
+		{runtime.Equal(huge, new(big.Int).Set(huge)), true},
+		{runtime.Equal(huge, 1e20), true},
+		{runtime.Equal(big.NewInt(1), 1), true},
+		{runtime.Equal(big.NewInt(1), "1"), false},
+	}
+	for i, tt := range tests {
+		is := is.New(t)
+		if b, ok := tt.got.(*big.Int); ok {
+			is.Msg("%v", i).Equal(tt.want, b.String())
+			continue
+		}
+		is.Msg("%v", i).Equal(tt.want, tt.got)
+	}
+
+	is := is.New(t)
+	is.Equal("integer divide by zero", recovered(func() { runtime.Divide(huge, 0) }))
+}
+
+func TestToInt_out_of_range(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000000", 10)
+	is := is.New(t)
+	is.Equal(-5, runtime.ToInt(big.NewInt(-5)))
+	is.Equal(int64(math.MaxInt64), runtime.ToInt64(big.NewInt(math.MaxInt64)))
+	is.Equal(19, runtime.NewDecimal(1999, 2).Int())
+
+	is.Equal("cannot convert 100000000000000000000000 to int (out of range)", recovered(func() { runtime.ToInt(huge) }))
+	is.Equal("cannot convert 100000000000000000000000 to int64 (out of range)", recovered(func() { runtime.ToInt64(huge) }))
+	is.Equal("cannot convert 100000000000000000000000 to int (out of range)", recovered(func() { runtime.ToDecimal(huge).Int() }))
+	is.Equal("cannot convert 18446744073709551615 to int (out of range)", recovered(func() { runtime.ToInt(uint64(math.MaxUint64)) }))
+	is.Equal("cannot convert 9223372036854775808 to int64 (out of range)", recovered(func() { runtime.ToInt64(uint(1 << 63)) }))
+}
+
+func TestUnsigned_operators(t *testing.T) {
+	u := uint64(1 << 63)
+	tests := []struct {
+		got  interface{}
+		want interface{}
+	}{
+		// Operations done in int with unsigned operands larger than the
+		// maximum int are done with *big.Int.
+		{runtime.More(u, 0), true},
+		{runtime.Less(-1, u), true},
+		{runtime.Equal(uint64(math.MaxUint64), -1), false},
+		{runtime.Equal(u, big.NewInt(0).SetUint64(u)), true},
+		{runtime.Add(u, 1), "9223372036854775809"},
+		{runtime.Subtract(uint(0), u), "-9223372036854775808"},
+		{runtime.Multiply(int8(2), u), "18446744073709551616"},
+		{runtime.Modulo(u, 10), "8"},
+		{runtime.DivideInt(u, uint8(2)), "4611686018427387904"},
+		{runtime.Divide(u, 2), 4.611686018427388e18},
+		// Operations which keep the type don't need it.
+		{runtime.Modulo(u, uint64(10)), uint64(8)},
+		{runtime.More(u, uint64(1)), true},
+		{runtime.Add(uint64(1), 2), 3},
+	}
+	for i, tt := range tests {
+		is := is.New(t)
+		if b, ok := tt.got.(*big.Int); ok {
+			is.Msg("%v", i).Equal(tt.want, b.String())
+			continue
+		}
+		is.Msg("%v", i).Equal(tt.want, tt.got)
+	}
+
+	is := is.New(t)
+	is.True(!runtime.NewSet([]interface{}{-1}).Has(uint64(math.MaxUint64)))
+	is.True(runtime.NewSet([]interface{}{new(big.Int).SetUint64(u)}).Has(u))
+}
+
+func TestSized_operators(t *testing.T) {
+	tests := []struct {
+		got  interface{}
+		want interface{}
+	}{
+		// Results of operations which can overflow are promoted to int.
+		{runtime.Add(uint8(200), uint8(100)), 300},
+		{runtime.Subtract(uint32(0), uint32(2)), -2},
+		{runtime.Multiply(int16(32000), int16(2)), 64000},
+		{runtime.Add(uint32(2), 5000000000), 5000000002},
+		{runtime.DivideInt(int8(-128), int8(-1)), 128},
+		// Others keep the type.
+		{runtime.Modulo(uint32(5), uint32(2)), uint32(1)},
+		{runtime.DivideInt(uint32(5), uint32(2)), uint32(2)},
+		{runtime.Less(uint8(200), int8(-1)), false},
+	}
+	for i, tt := range tests {
+		is := is.New(t)
+		is.Msg("%v", i).Equal(tt.want, tt.got)
+	}
+}
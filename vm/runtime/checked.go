@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// OverflowError is the error of checked arithmetic, raised when the result
+// of an integer operation does not fit into int.
+type OverflowError struct {
+	Op       string
+	Operands []interface{}
+}
+
+func (e *OverflowError) Error() string {
+	if len(e.Operands) == 1 {
+		return fmt.Sprintf("integer overflow: %v%v", e.Op, e.Operands[0])
+	}
+	return fmt.Sprintf("integer overflow: %v %v %v", e.Operands[0], e.Op, e.Operands[1])
+}
+
+func overflow(op string, operands ...interface{}) *OverflowError {
+	return &OverflowError{Op: op, Operands: operands}
+}
+
+func uintToInt(x uint64, op string, a, b interface{}) int {
+	if x > math.MaxInt64 {
+		panic(overflow(op, a, b))
+	}
+	return int(x)
+}
+
+func addInt(x, y int, a, b interface{}) int {
+	z := x + y
+	if (z > x) != (y > 0) {
+		panic(overflow("+", a, b))
+	}
+	return z
+}
+
+func subtractInt(x, y int, a, b interface{}) int {
+	z := x - y
+	if (z < x) != (y > 0) {
+		panic(overflow("-", a, b))
+	}
+	return z
+}
+
+func multiplyInt(x, y int, a, b interface{}) int {
+	if x == 0 || y == 0 {
+		return 0
+	}
+	z := x * y
+	// The minimum int is the only non-zero number equal to its negation,
+	// and the only one which multiplied by -1 gives the same z/y.
+	if z/y != x || (y == -1 && x == -x) {
+		panic(overflow("*", a, b))
+	}
+	return z
+}
+
+func divideInt(x, y int, a, b interface{}) int {
+	if y == -1 && x != 0 && x == -x {
+		panic(overflow("/", a, b))
+	}
+	return x / y
+}
+
+func inRange(x, min, max int, op string, a, b interface{}) int {
+	if x < min || x > max {
+		panic(overflow(op, a, b))
+	}
+	return x
+}
+
+func addUint(x, y uint64, a, b interface{}) uint64 {
+	z := x + y
+	if z < x {
+		panic(overflow("+", a, b))
+	}
+	return z
+}
+
+func subtractUint(x, y uint64, a, b interface{}) uint64 {
+	if x < y {
+		panic(overflow("-", a, b))
+	}
+	return x - y
+}
+
+func multiplyUint(x, y uint64, a, b interface{}) uint64 {
+	z := x * y
+	if x != 0 && z/x != y {
+		panic(overflow("*", a, b))
+	}
+	return z
+}
+
+func divideUint(x, y uint64, _, _ interface{}) uint64 {
+	return x / y
+}
+
+// NegateChecked is Negate, which panics with OverflowError if the result
+// does not fit into the type of the operand.
+func NegateChecked(i interface{}) interface{} {
+	switch v := i.(type) {
+	case int:
+		if v != 0 && v == -v {
+			panic(overflow("-", i))
+		}
+	case int8:
+		if v != 0 && v == -v {
+			panic(overflow("-", i))
+		}
+	case int16:
+		if v != 0 && v == -v {
+			panic(overflow("-", i))
+		}
+	case int32:
+		if v != 0 && v == -v {
+			panic(overflow("-", i))
+		}
+	case int64:
+		if v != 0 && v == -v {
+			panic(overflow("-", i))
+		}
+	case uint, uint8, uint16, uint32, uint64:
+		if reflect.ValueOf(v).Uint() != 0 {
+			panic(overflow("-", i))
+		}
+	}
+	return Negate(i)
+}
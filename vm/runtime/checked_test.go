@@ -0,0 +1,94 @@
+package runtime_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func TestChecked(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(a, b interface{}) interface{}
+		a, b interface{}
+		want interface{}
+	}{
+		{"+", runtime.AddChecked, 1, 2, 3},
+		{"+", runtime.AddChecked, math.MaxInt64 - 1, 1, math.MaxInt64},
+		{"-", runtime.SubtractChecked, math.MinInt64 + 1, 1, math.MinInt64},
+		{"*", runtime.MultiplyChecked, math.MinInt64 / 2, 2, math.MinInt64},
+		{"*", runtime.MultiplyChecked, 0, math.MinInt64, 0},
+		{"/", runtime.DivideIntChecked, math.MinInt64, 1, math.MinInt64},
+		{"/", runtime.DivideIntChecked, -7, 2, -3},
+		// Sized integers keep their type.
+		{"+", runtime.AddChecked, uint8(200), uint8(55), uint8(255)},
+		{"-", runtime.SubtractChecked, int16(-32767), int16(1), int16(-32768)},
+		{"+", runtime.AddChecked, uint64(1), 2, 3},
+		{"+", runtime.AddChecked, 1.5, 2, 3.5},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%v %v %v", tt.a, tt.name, tt.b).Equal(tt.want, tt.fn(tt.a, tt.b))
+	}
+}
+
+func TestChecked_overflow(t *testing.T) {
+	tests := []struct {
+		fn  func()
+		err string
+	}{
+		{func() { runtime.AddChecked(math.MaxInt64, 1) }, "integer overflow: 9223372036854775807 + 1"},
+		{func() { runtime.SubtractChecked(math.MinInt64, 1) }, "integer overflow: -9223372036854775808 - 1"},
+		{func() { runtime.MultiplyChecked(math.MaxInt64, 2) }, "integer overflow: 9223372036854775807 * 2"},
+		{func() { runtime.MultiplyChecked(math.MinInt64, -1) }, "integer overflow: -9223372036854775808 * -1"},
+		{func() { runtime.DivideIntChecked(math.MinInt64, -1) }, "integer overflow: -9223372036854775808 / -1"},
+		{func() { runtime.AddChecked(uint64(math.MaxUint64), 1) }, "integer overflow: 18446744073709551615 + 1"},
+		{func() { runtime.AddChecked(uint8(200), uint8(56)) }, "integer overflow: 200 + 56"},
+		{func() { runtime.SubtractChecked(uint32(1), uint32(2)) }, "integer overflow: 1 - 2"},
+		{func() { runtime.MultiplyChecked(int8(64), int8(2)) }, "integer overflow: 64 * 2"},
+		{func() { runtime.NegateChecked(math.MinInt64) }, "integer overflow: --9223372036854775808"},
+		{func() { runtime.NegateChecked(int8(math.MinInt8)) }, "integer overflow: --128"},
+		{func() { runtime.NegateChecked(uint(1)) }, "integer overflow: -1"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.err).Equal(tt.err, recovered(tt.fn))
+	}
+}
+
+func TestOverflowError(t *testing.T) {
+	is := is.New(t)
+	defer func() {
+		err, ok := recover().(*runtime.OverflowError)
+		is.True(ok)
+		is.Equal("+", err.Op)
+		is.Equal([]interface{}{math.MaxInt64, 1}, err.Operands)
+	}()
+	runtime.AddChecked(math.MaxInt64, 1)
+}
+
+func TestNegateChecked(t *testing.T) {
+	is := is.New(t)
+	is.Equal(-1, runtime.NegateChecked(1))
+	is.Equal(int8(127), runtime.NegateChecked(int8(-127)))
+	is.Equal(uint(0), runtime.NegateChecked(uint(0)))
+}
+
+func TestDivideInt(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		want interface{}
+	}{
+		{7, -2, -3},
+		{7, uint8(3), 2},
+		{uint8(7), uint8(2), uint8(3)},
+		{7, 2.0, 3.5},
+		{-7, 2, -3},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%v / %v", tt.a, tt.b).Equal(tt.want, runtime.DivideInt(tt.a, tt.b))
+	}
+}
@@ -0,0 +1,41 @@
+package runtime
+
+import "reflect"
+
+// Fetcher is implemented by values with dynamic fields, like lazily loaded
+// or database-backed objects. Fetch returns the value of the key, or false
+// if there is no such key. Both obj.key and obj["key"] call Fetch, unless
+// key is a declared field or method of obj, and "key" in obj reports
+// whether Fetch found the key.
+type Fetcher interface {
+	Fetch(key interface{}) (interface{}, bool)
+}
+
+// Lener is implemented by custom collections supporting len().
+type Lener interface {
+	Len() int
+}
+
+// Iterable is implemented by custom collections supporting loop builtins
+// (all, filter, map, ...), sorting, the in operator and index access, like
+// c[0] or c[-1]. Elements are accessed by index, from 0 to Len() - 1,
+// without copying the collection.
+type Iterable interface {
+	Lener
+	Index(i int) interface{}
+}
+
+var (
+	FetcherType  = reflect.TypeOf((*Fetcher)(nil)).Elem()
+	LenerType    = reflect.TypeOf((*Lener)(nil)).Elem()
+	IterableType = reflect.TypeOf((*Iterable)(nil)).Elem()
+)
+
+func inIterable(needle interface{}, it Iterable) bool {
+	for i := 0; i < it.Len(); i++ {
+		if Equal(it.Index(i), needle) {
+			return true
+		}
+	}
+	return false
+}
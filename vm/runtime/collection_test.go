@@ -0,0 +1,54 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+type record map[string]interface{}
+
+func (r record) Fetch(key interface{}) (interface{}, bool) {
+	v, ok := r[key.(string)]
+	return v, ok
+}
+
+type queue struct {
+	items []int
+}
+
+func (q *queue) Len() int                { return len(q.items) }
+func (q *queue) Index(i int) interface{} { return q.items[i] }
+
+type document struct {
+	record
+	Name string
+}
+
+func TestFetch_Fetcher(t *testing.T) {
+	is := is.New(t)
+	r := record{"Name": "Anna"}
+	is.Equal("Anna", runtime.Fetch(r, "Name"))
+	is.Equal(nil, runtime.Fetch(r, "Missing"))
+	is.True(runtime.In("Name", r))
+	is.True(!runtime.In("Missing", r))
+
+	// Declared fields take precedence over dynamic ones.
+	d := &document{record: record{"Name": "fetched", "Year": 2020}, Name: "Who"}
+	is.Equal("Who", runtime.Fetch(d, "Name"))
+	is.Equal(2020, runtime.Fetch(d, "Year"))
+}
+
+func TestFetch_Iterable(t *testing.T) {
+	is := is.New(t)
+	q := &queue{items: []int{10, 20, 30}}
+	is.Equal(10, runtime.Fetch(q, 0))
+	is.Equal(30, runtime.Fetch(q, -1))
+	is.Equal(3, runtime.Len(q))
+	is.True(runtime.In(20, q))
+	is.True(runtime.In(20.0, q))
+	is.True(!runtime.In(40, q))
+	is.Equal("index out of range: 3 (length 3)", recovered(func() { runtime.Fetch(q, 3) }))
+	is.Equal("index out of range: -4 (length 3)", recovered(func() { runtime.Fetch(q, -4) }))
+}
@@ -0,0 +1,309 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DecimalDivisionScale is the number of digits after the decimal point
+// kept by division of decimals, which result does not terminate earlier.
+var DecimalDivisionScale = 16
+
+// MaxDecimalScale limits exponents of parsed decimals, like 1e5d, and the
+// scale and number of digits of decimal powers, so 1e99999999d is an error
+// instead of a number of a hundred million digits.
+var MaxDecimalScale = 10000
+
+// Decimal is an arbitrary-precision decimal number, like 12.50d. The zero
+// value is 0. Decimals are immutable, so they can be safely shared.
+type Decimal struct {
+	coef  *big.Int // the value is coef * 10^-scale
+	scale int
+}
+
+var (
+	bigTen      = big.NewInt(10)
+	decimalType = reflect.TypeOf(Decimal{})
+)
+
+// NewDecimal returns the decimal unscaled * 10^-scale, so NewDecimal(1250, 2)
+// is 12.50.
+func NewDecimal(unscaled int64, scale int) Decimal {
+	if scale < 0 {
+		return Decimal{coef: new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale))}
+	}
+	return Decimal{coef: big.NewInt(unscaled), scale: scale}
+}
+
+// ParseDecimal parses a decimal like "-12.50", "1_000" or "1.5e3". The
+// number of digits after the point is kept, so "12.50" prints as 12.50.
+func ParseDecimal(s string) (Decimal, error) {
+	text := strings.Replace(s, "_", "", -1)
+	exp := 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		e, err := strconv.Atoi(text[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		if e > MaxDecimalScale || e < -MaxDecimalScale {
+			return Decimal{}, fmt.Errorf("decimal %q is out of range", s)
+		}
+		exp = e
+		text = text[:i]
+	}
+	scale := 0
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		scale = len(text) - i - 1
+		text = text[:i] + text[i+1:]
+	}
+	if text == "" || text == "+" || text == "-" || strings.ContainsAny(text[1:], "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	coef, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	scale -= exp
+	if scale > MaxDecimalScale || scale < -MaxDecimalScale {
+		return Decimal{}, fmt.Errorf("decimal %q is out of range", s)
+	}
+	if scale < 0 {
+		return Decimal{coef: coef.Mul(coef, pow10(-scale))}, nil
+	}
+	return Decimal{coef: coef, scale: scale}, nil
+}
+
+// ToDecimal converts numbers and strings to decimal. Floats are converted
+// by their shortest representation, so 0.1 becomes 0.1d.
+func ToDecimal(v interface{}) Decimal {
+	switch x := v.(type) {
+	case Decimal:
+		return x
+	case int, int8, int16, int32, int64:
+		return Decimal{coef: big.NewInt(ToInt64(x))}
+	case uint, uint8, uint16, uint32, uint64:
+		return Decimal{coef: new(big.Int).SetUint64(toUint64(x))}
+	case *big.Int:
+		return Decimal{coef: x}
+	case float32:
+		return floatToDecimal(float64(x), 32)
+	case float64:
+		return floatToDecimal(x, 64)
+	case string:
+		d, err := ParseDecimal(x)
+		if err != nil {
+			panic(err.Error())
+		}
+		return d
+	}
+	panic(fmt.Sprintf("invalid operation: decimal(%T)", v))
+}
+
+func toUint64(v interface{}) uint64 {
+	switch x := v.(type) {
+	case uint:
+		return uint64(x)
+	case uint8:
+		return uint64(x)
+	case uint16:
+		return uint64(x)
+	case uint32:
+		return uint64(x)
+	case uint64:
+		return x
+	}
+	panic(fmt.Sprintf("invalid operation: uint64(%T)", v))
+}
+
+func floatToDecimal(f float64, bitSize int) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic(fmt.Sprintf("cannot convert %v to decimal", f))
+	}
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'g', -1, bitSize))
+	if err != nil {
+		panic(err.Error())
+	}
+	return d
+}
+
+// toDecimals converts a and b to decimals if one of them is a decimal and
+// the other one is a number.
+func toDecimals(a, b interface{}) (Decimal, Decimal, bool) {
+	_, aOk := a.(Decimal)
+	_, bOk := b.(Decimal)
+	if !(aOk || bOk) || !isNumber(a) || !isNumber(b) {
+		return Decimal{}, Decimal{}, false
+	}
+	return ToDecimal(a), ToDecimal(b), true
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case Decimal, *big.Int, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// rescale returns the coefficient of d with the given scale, which must not
+// be less than the scale of d.
+func (d Decimal) rescale(scale int) *big.Int {
+	if scale == d.scale {
+		return d.int()
+	}
+	return new(big.Int).Mul(d.int(), pow10(scale-d.scale))
+}
+
+func align(a, b Decimal) (*big.Int, *big.Int, int) {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	return a.rescale(scale), b.rescale(scale), scale
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	x, y, scale := align(d, o)
+	return Decimal{coef: new(big.Int).Add(x, y), scale: scale}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	x, y, scale := align(d, o)
+	return Decimal{coef: new(big.Int).Sub(x, y), scale: scale}
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Quo returns d / o rounded half away from zero to DecimalDivisionScale
+// digits, or to the scale of the operands if it is larger. Trailing zeros
+// beyond the scale of the operands are removed, so 10.00d / 4 is 2.50d.
+func (d Decimal) Quo(o Decimal) Decimal {
+	if o.Sign() == 0 {
+		panic("decimal divide by zero")
+	}
+	min := d.scale
+	if o.scale > min {
+		min = o.scale
+	}
+	scale := DecimalDivisionScale
+	if min > scale {
+		scale = min
+	}
+	// d / o = (x * 10^(scale + o.scale - d.scale)) / y * 10^-scale
+	x := d.int()
+	shift := scale + o.scale - d.scale
+	if shift >= 0 {
+		x = new(big.Int).Mul(x, pow10(shift))
+	}
+	y := o.int()
+	if shift < 0 {
+		y = new(big.Int).Mul(y, pow10(-shift))
+	}
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(new(big.Int).Abs(y)) >= 0 {
+		if x.Sign() == y.Sign() {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+	for scale > min {
+		next, rem := new(big.Int).QuoRem(q, bigTen, new(big.Int))
+		if rem.Sign() != 0 {
+			break
+		}
+		q = next
+		scale--
+	}
+	return Decimal{coef: q, scale: scale}
+}
+
+// Pow returns d ** n. The result of a negative n is rounded as by Quo.
+// It panics if the scale or the number of digits of the result would be
+// more than MaxDecimalScale.
+func (d Decimal) Pow(n int) Decimal {
+	if n < -MaxDecimalScale {
+		panic(fmt.Sprintf("decimal power out of range: %v ** %v", d, n))
+	}
+	if n < 0 {
+		return NewDecimal(1, 0).Quo(d.Pow(-n))
+	}
+	// The number of digits of d is estimated by its bit length, as
+	// log10(2) is about 0.3.
+	digits := (d.int().BitLen()*3 + 9) / 10
+	if n > 0 && (d.scale > MaxDecimalScale/n || digits > MaxDecimalScale/n) {
+		panic(fmt.Sprintf("decimal power out of range: %v ** %v", d, n))
+	}
+	return Decimal{coef: new(big.Int).Exp(d.int(), big.NewInt(int64(n)), nil), scale: d.scale * n}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// Cmp compares the values of d and o, so 1.50d and 1.5d are equal.
+func (d Decimal) Cmp(o Decimal) int {
+	x, y, _ := align(d, o)
+	return x.Cmp(y)
+}
+
+// Float64 returns the nearest float64 value of d.
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(d.int(), pow10(d.scale)).Float64()
+	return f
+}
+
+// Int returns the integer part of d, truncated toward zero. It panics if
+// the integer part is out of the range of int.
+func (d Decimal) Int() int {
+	return bigToInt(new(big.Int).Quo(d.int(), pow10(d.scale)))
+}
+
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.int()).String()
+	if d.scale > 0 {
+		if len(s) <= d.scale {
+			s = strings.Repeat("0", d.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.scale] + "." + s[len(s)-d.scale:]
+	}
+	if d.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Size returns the number of machine words of d, used for memory budget
+// accounting.
+func (d Decimal) Size() int {
+	return len(d.int().Bits()) + 1
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
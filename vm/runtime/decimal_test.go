@@ -0,0 +1,171 @@
+package runtime_test
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func dec(s string) runtime.Decimal {
+	d, err := runtime.ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   string
+	}{
+		{"12.50", "12.50", ""},
+		{"-0.01", "-0.01", ""},
+		{"+3", "3", ""},
+		{"1_000.5", "1000.5", ""},
+		{"1.5e3", "1500", ""},
+		{"15e-3", "0.015", ""},
+		{".5", "0.5", ""},
+		{"", "", `invalid decimal ""`},
+		{"-", "", `invalid decimal "-"`},
+		{"1.2.3", "", `invalid decimal "1.2.3"`},
+		{"1-2", "", `invalid decimal "1-2"`},
+		{"1ex", "", `invalid decimal "1ex"`},
+		{"1e10000", "1" + strings.Repeat("0", 10000), ""},
+		{"1e10001", "", `decimal "1e10001" is out of range`},
+		{"1e-99999999", "", `decimal "1e-99999999" is out of range`},
+		{"0.01e-9999", "", `decimal "0.01e-9999" is out of range`},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		d, err := runtime.ParseDecimal(tt.input)
+		if tt.err != "" {
+			is.Msg(tt.input).ErrMsg(err, tt.err)
+			continue
+		}
+		is.Msg(tt.input).NotErr(err)
+		is.Msg(tt.input).Equal(tt.want, d.String())
+	}
+}
+
+func TestToDecimal(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{3, "3"},
+		{int8(-3), "-3"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{big.NewInt(42), "42"},
+		{0.1, "0.1"},
+		{float32(0.1), "0.1"},
+		{1e20, "100000000000000000000"},
+		{"19.990", "19.990"},
+		{runtime.NewDecimal(1250, 2), "12.50"},
+		{runtime.NewDecimal(5, -2), "500"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%#v", tt.value).Equal(tt.want, runtime.ToDecimal(tt.value).String())
+	}
+
+	is := is.New(t)
+	is.Equal("cannot convert NaN to decimal", recovered(func() { runtime.ToDecimal(math.NaN()) }))
+	is.Equal("invalid operation: decimal(bool)", recovered(func() { runtime.ToDecimal(true) }))
+	is.Equal(`invalid decimal "x"`, recovered(func() { runtime.ToDecimal("x") }))
+}
+
+func TestDecimal_arithmetic(t *testing.T) {
+	tests := []struct {
+		got  runtime.Decimal
+		want string
+	}{
+		{dec("0.1").Add(dec("0.2")), "0.3"},
+		{dec("19.99").Add(dec("0.01")), "20.00"},
+		{dec("19.99").Sub(dec("20")), "-0.01"},
+		{dec("19.99").Mul(dec("3")), "59.97"},
+		{dec("1.5").Mul(dec("1.5")), "2.25"},
+		{dec("19.99").Neg(), "-19.99"},
+		{dec("-19.99").Abs(), "19.99"},
+		{dec("10.00").Quo(dec("4")), "2.50"},
+		{dec("1").Quo(dec("3")), "0.3333333333333333"},
+		{dec("2").Quo(dec("3")), "0.6666666666666667"},
+		{dec("-2").Quo(dec("3")), "-0.6666666666666667"},
+		{dec("1").Quo(dec("8")), "0.125"},
+		{dec("1.00000000000000000001").Quo(dec("1")), "1.00000000000000000001"},
+		{dec("2").Pow(2), "4"},
+		{dec("19.99").Pow(2), "399.6001"},
+		{dec("1.5").Pow(0), "1"},
+		{dec("2").Pow(-2), "0.25"},
+		{dec("3").Pow(-1), "0.3333333333333333"},
+	}
+	for i, tt := range tests {
+		is := is.New(t)
+		is.Msg("%v", i).Equal(tt.want, tt.got.String())
+	}
+
+	is := is.New(t)
+	is.Equal("decimal divide by zero", recovered(func() { dec("1").Quo(dec("0.00")) }))
+	is.Equal("decimal divide by zero", recovered(func() { dec("0").Pow(-1) }))
+	is.Equal("decimal power out of range: 1.5 ** 10000000", recovered(func() { dec("1.5").Pow(10000000) }))
+	is.Equal("decimal power out of range: 2 ** 100000", recovered(func() { dec("2").Pow(100000) }))
+	is.Equal("decimal power out of range: 2 ** -100000", recovered(func() { dec("2").Pow(-100000) }))
+	is.Equal(3011, len(dec("2").Pow(10000).String()))
+}
+
+func TestDecimal_Cmp(t *testing.T) {
+	is := is.New(t)
+	is.Equal(0, dec("1.50").Cmp(dec("1.5")))
+	is.Equal(-1, dec("19.98").Cmp(dec("19.99")))
+	is.Equal(1, dec("0").Cmp(dec("-0.001")))
+	is.Equal(0, runtime.Decimal{}.Cmp(dec("0.00")))
+	is.Equal(-1, dec("-1").Sign())
+}
+
+func TestDecimal_conversions(t *testing.T) {
+	is := is.New(t)
+	is.Equal(19.99, dec("19.99").Float64())
+	is.Equal(19, dec("19.99").Int())
+	is.Equal(-19, dec("-19.99").Int())
+	is.Equal("0.05", dec("0.05").String())
+	is.Equal("0", runtime.Decimal{}.String())
+	b, err := dec("1.50").MarshalJSON()
+	is.NotErr(err)
+	is.Equal("1.50", string(b))
+}
+
+func TestDecimal_operators(t *testing.T) {
+	tests := []struct {
+		got  interface{}
+		want interface{}
+	}{
+		{runtime.Equal(dec("1.5"), 1.5), true},
+		{runtime.Equal(dec("1"), 1), true},
+		{runtime.Less(dec("19.99"), 20), true},
+		{runtime.More(dec("19.99"), 19.98), true},
+		{runtime.Add(dec("19.99"), 0.01), dec("20.00")},
+		{runtime.Multiply(dec("19.99"), 0.1), dec("1.999")},
+		{runtime.Divide(1, dec("4")), dec("0.25")},
+		{runtime.Exponent(dec("1.1"), 2), dec("1.21")},
+		{runtime.Exponent(2, 0.5), math.Sqrt2},
+	}
+	for i, tt := range tests {
+		is := is.New(t)
+		if d, ok := tt.want.(runtime.Decimal); ok {
+			is.Msg("%v", i).Equal(d.String(), tt.got.(runtime.Decimal).String())
+			continue
+		}
+		is.Msg("%v", i).Equal(tt.want, tt.got)
+	}
+
+	is := is.New(t)
+	is.Equal("invalid operation: decimal ** float64 (decimal powers need an integer exponent)",
+		recovered(func() { runtime.Exponent(dec("2"), 0.5) }))
+	is.Equal("invalid operation: int ** decimal (decimal powers need an integer exponent)",
+		recovered(func() { runtime.Exponent(2, dec("2")) }))
+}
@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+)
+
+func toBytes(v interface{}, fn string) []byte {
+	switch x := v.(type) {
+	case string:
+		return []byte(x)
+	case []byte:
+		return x
+	}
+	panic(fmt.Sprintf("invalid argument for %v (type %T)", fn, v))
+}
+
+// toKey is like toBytes, but also accepts integers, which are used
+// in their decimal form.
+func toKey(v interface{}, fn string) []byte {
+	switch x := v.(type) {
+	case int, int8, int16, int32, int64:
+		return []byte(strconv.FormatInt(ToInt64(x), 10))
+	case uint, uint8, uint16, uint32, uint64:
+		return []byte(fmt.Sprint(x))
+	}
+	return toBytes(v, fn)
+}
+
+func SHA256(v interface{}) string {
+	sum := sha256.Sum256(toBytes(v, "sha256"))
+	return hex.EncodeToString(sum[:])
+}
+
+func MD5(v interface{}) string {
+	sum := md5.Sum(toBytes(v, "md5"))
+	return hex.EncodeToString(sum[:])
+}
+
+// Hash returns the 64-bit FNV-1a hash of v with the sign bit cleared,
+// so the result can be used with the % operator.
+func Hash(v interface{}) int {
+	h := fnv.New64a()
+	_, _ = h.Write(toKey(v, "hash"))
+	return int(h.Sum64() & (1<<63 - 1))
+}
+
+// Bucket returns a number in [0, n) for the key: the 32-bit FNV-1a hash
+// of the key modulo n. The result must never change between releases,
+// as it is used for assigning users to experiment groups.
+func Bucket(key, n interface{}) int {
+	buckets := ToInt(n)
+	if buckets <= 0 {
+		panic(fmt.Sprintf("invalid number of buckets %v", buckets))
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(toKey(key, "bucket"))
+	return int(h.Sum32() % uint32(buckets))
+}
+
+func Base64Encode(v interface{}) string {
+	return base64.StdEncoding.EncodeToString(toBytes(v, "base64Encode"))
+}
+
+func Base64Decode(v interface{}) string {
+	b, err := base64.StdEncoding.DecodeString(string(toBytes(v, "base64Decode")))
+	if err != nil {
+		panic(fmt.Sprintf("cannot decode base64: %v", err))
+	}
+	return string(b)
+}
+
+func HexEncode(v interface{}) string {
+	return hex.EncodeToString(toBytes(v, "hexEncode"))
+}
+
+func HexDecode(v interface{}) string {
+	b, err := hex.DecodeString(string(toBytes(v, "hexDecode")))
+	if err != nil {
+		panic(fmt.Sprintf("cannot decode hex: %v", err))
+	}
+	return string(b)
+}
+
+func URLEncode(v interface{}) string {
+	return url.QueryEscape(string(toBytes(v, "urlEncode")))
+}
+
+func URLDecode(v interface{}) string {
+	s, err := url.QueryUnescape(string(toBytes(v, "urlDecode")))
+	if err != nil {
+		panic(fmt.Sprintf("cannot decode URL: %v", err))
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func TestHashing(t *testing.T) {
+	is := is.New(t)
+	is.Equal("ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", runtime.SHA256("abc"))
+	is.Equal(runtime.SHA256("hi"), runtime.SHA256([]byte("hi")))
+	is.Equal("900150983cd24fb0d6963f7d28e17f72", runtime.MD5("abc"))
+	is.Equal(7430836138530658123, runtime.Hash("abc"))
+	is.Equal(runtime.Hash("42"), runtime.Hash(42))
+	is.Equal(runtime.Hash("42"), runtime.Hash(uint8(42)))
+	is.Equal(runtime.Hash("-1"), runtime.Hash(int64(-1)))
+}
+
+// TestBucket pins results of Bucket, which must never change.
+func TestBucket(t *testing.T) {
+	tests := []struct {
+		key  interface{}
+		n    int
+		want int
+	}{
+		{"user-1", 100, 0},
+		{"user-2", 100, 57},
+		{"user-3", 100, 38},
+		{42, 100, 11},
+		{"user-2", 1, 0},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%v", tt.key).Equal(tt.want, runtime.Bucket(tt.key, tt.n))
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	is := is.New(t)
+	is.Equal("aGVsbG8/", runtime.Base64Encode("hello?"))
+	is.Equal("hello?", runtime.Base64Decode("aGVsbG8/"))
+	is.Equal("6869", runtime.HexEncode([]byte("hi")))
+	is.Equal("hi", runtime.HexDecode("6869"))
+	is.Equal("a+b%26c%3Dd", runtime.URLEncode("a b&c=d"))
+	is.Equal("a b&c=d", runtime.URLDecode("a+b%26c%3Dd"))
+}
+
+func TestEncoding_invalid(t *testing.T) {
+	tests := []struct {
+		fn  func()
+		err string
+	}{
+		{func() { runtime.SHA256(1) }, "invalid argument for sha256 (type int)"},
+		{func() { runtime.Hash(1.5) }, "invalid argument for hash (type float64)"},
+		{func() { runtime.Bucket("a", 0) }, "invalid number of buckets 0"},
+		{func() { runtime.Base64Decode("%%%") }, "cannot decode base64: illegal base64 data at input byte 0"},
+		{func() { runtime.HexDecode("zz") }, "cannot decode hex: encoding/hex: invalid byte: U+007A 'z'"},
+		{func() { runtime.URLDecode("%zz") }, `cannot decode URL: invalid URL escape "%zz"`},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.err).Equal(tt.err, recovered(tt.fn))
+	}
+}
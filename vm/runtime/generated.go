@@ -4,6 +4,9 @@ package runtime
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"net"
 	"reflect"
 	"time"
 )
@@ -13,24 +16,51 @@ func Equal(a, b interface{}) bool {
 	case uint:
 		switch y := b.(type) {
 		case uint:
-			return int(x) == int(y)
+			return uint(x) == uint(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case float32:
 			return float64(x) == float64(y)
@@ -40,14 +70,20 @@ func Equal(a, b interface{}) bool {
 	case uint8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint8:
-			return int(x) == int(y)
+			return uint8(x) == uint8(y)
 		case uint16:
 			return int(x) == int(y)
 		case uint32:
 			return int(x) == int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int:
 			return int(x) == int(y)
@@ -67,14 +103,20 @@ func Equal(a, b interface{}) bool {
 	case uint16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint8:
 			return int(x) == int(y)
 		case uint16:
-			return int(x) == int(y)
+			return uint16(x) == uint16(y)
 		case uint32:
 			return int(x) == int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int:
 			return int(x) == int(y)
@@ -94,14 +136,20 @@ func Equal(a, b interface{}) bool {
 	case uint32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint8:
 			return int(x) == int(y)
 		case uint16:
 			return int(x) == int(y)
 		case uint32:
-			return int(x) == int(y)
+			return uint32(x) == uint32(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int:
 			return int(x) == int(y)
@@ -121,24 +169,51 @@ func Equal(a, b interface{}) bool {
 	case uint64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint64:
-			return int(x) == int(y)
+			return uint64(x) == uint64(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case float32:
 			return float64(x) == float64(y)
@@ -148,6 +223,9 @@ func Equal(a, b interface{}) bool {
 	case int:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint8:
 			return int(x) == int(y)
@@ -156,6 +234,9 @@ func Equal(a, b interface{}) bool {
 		case uint32:
 			return int(x) == int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int:
 			return int(x) == int(y)
@@ -175,6 +256,9 @@ func Equal(a, b interface{}) bool {
 	case int8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint8:
 			return int(x) == int(y)
@@ -183,11 +267,14 @@ func Equal(a, b interface{}) bool {
 		case uint32:
 			return int(x) == int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int:
 			return int(x) == int(y)
 		case int8:
-			return int(x) == int(y)
+			return int8(x) == int8(y)
 		case int16:
 			return int(x) == int(y)
 		case int32:
@@ -202,6 +289,9 @@ func Equal(a, b interface{}) bool {
 	case int16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint8:
 			return int(x) == int(y)
@@ -210,13 +300,16 @@ func Equal(a, b interface{}) bool {
 		case uint32:
 			return int(x) == int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int:
 			return int(x) == int(y)
 		case int8:
 			return int(x) == int(y)
 		case int16:
-			return int(x) == int(y)
+			return int16(x) == int16(y)
 		case int32:
 			return int(x) == int(y)
 		case int64:
@@ -229,6 +322,9 @@ func Equal(a, b interface{}) bool {
 	case int32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint8:
 			return int(x) == int(y)
@@ -237,6 +333,9 @@ func Equal(a, b interface{}) bool {
 		case uint32:
 			return int(x) == int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int:
 			return int(x) == int(y)
@@ -245,7 +344,7 @@ func Equal(a, b interface{}) bool {
 		case int16:
 			return int(x) == int(y)
 		case int32:
-			return int(x) == int(y)
+			return int32(x) == int32(y)
 		case int64:
 			return int(x) == int(y)
 		case float32:
@@ -256,6 +355,9 @@ func Equal(a, b interface{}) bool {
 	case int64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case uint8:
 			return int(x) == int(y)
@@ -264,6 +366,9 @@ func Equal(a, b interface{}) bool {
 		case uint32:
 			return int(x) == int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Equal(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) == int(y)
 		case int:
 			return int(x) == int(y)
@@ -274,7 +379,7 @@ func Equal(a, b interface{}) bool {
 		case int32:
 			return int(x) == int(y)
 		case int64:
-			return int(x) == int(y)
+			return int64(x) == int64(y)
 		case float32:
 			return float64(x) == float64(y)
 		case float64:
@@ -344,6 +449,25 @@ func Equal(a, b interface{}) bool {
 		case time.Time:
 			return x.Equal(y)
 		}
+	case net.IP:
+		switch y := b.(type) {
+		case net.IP:
+			return x.Equal(y)
+		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) == 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) == 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) == 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Equal(x, y)
 	}
 	if IsNil(a) && IsNil(b) {
 		return true
@@ -356,24 +480,51 @@ func Less(a, b interface{}) bool {
 	case uint:
 		switch y := b.(type) {
 		case uint:
-			return int(x) < int(y)
+			return uint(x) < uint(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case float32:
 			return float64(x) < float64(y)
@@ -383,14 +534,20 @@ func Less(a, b interface{}) bool {
 	case uint8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint8:
-			return int(x) < int(y)
+			return uint8(x) < uint8(y)
 		case uint16:
 			return int(x) < int(y)
 		case uint32:
 			return int(x) < int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int:
 			return int(x) < int(y)
@@ -410,14 +567,20 @@ func Less(a, b interface{}) bool {
 	case uint16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint8:
 			return int(x) < int(y)
 		case uint16:
-			return int(x) < int(y)
+			return uint16(x) < uint16(y)
 		case uint32:
 			return int(x) < int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int:
 			return int(x) < int(y)
@@ -437,14 +600,20 @@ func Less(a, b interface{}) bool {
 	case uint32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint8:
 			return int(x) < int(y)
 		case uint16:
 			return int(x) < int(y)
 		case uint32:
-			return int(x) < int(y)
+			return uint32(x) < uint32(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int:
 			return int(x) < int(y)
@@ -464,24 +633,51 @@ func Less(a, b interface{}) bool {
 	case uint64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint64:
-			return int(x) < int(y)
+			return uint64(x) < uint64(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case float32:
 			return float64(x) < float64(y)
@@ -491,6 +687,9 @@ func Less(a, b interface{}) bool {
 	case int:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint8:
 			return int(x) < int(y)
@@ -499,6 +698,9 @@ func Less(a, b interface{}) bool {
 		case uint32:
 			return int(x) < int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int:
 			return int(x) < int(y)
@@ -518,6 +720,9 @@ func Less(a, b interface{}) bool {
 	case int8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint8:
 			return int(x) < int(y)
@@ -526,11 +731,14 @@ func Less(a, b interface{}) bool {
 		case uint32:
 			return int(x) < int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int:
 			return int(x) < int(y)
 		case int8:
-			return int(x) < int(y)
+			return int8(x) < int8(y)
 		case int16:
 			return int(x) < int(y)
 		case int32:
@@ -545,6 +753,9 @@ func Less(a, b interface{}) bool {
 	case int16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint8:
 			return int(x) < int(y)
@@ -553,13 +764,16 @@ func Less(a, b interface{}) bool {
 		case uint32:
 			return int(x) < int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int:
 			return int(x) < int(y)
 		case int8:
 			return int(x) < int(y)
 		case int16:
-			return int(x) < int(y)
+			return int16(x) < int16(y)
 		case int32:
 			return int(x) < int(y)
 		case int64:
@@ -572,6 +786,9 @@ func Less(a, b interface{}) bool {
 	case int32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint8:
 			return int(x) < int(y)
@@ -580,6 +797,9 @@ func Less(a, b interface{}) bool {
 		case uint32:
 			return int(x) < int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int:
 			return int(x) < int(y)
@@ -588,7 +808,7 @@ func Less(a, b interface{}) bool {
 		case int16:
 			return int(x) < int(y)
 		case int32:
-			return int(x) < int(y)
+			return int32(x) < int32(y)
 		case int64:
 			return int(x) < int(y)
 		case float32:
@@ -599,6 +819,9 @@ func Less(a, b interface{}) bool {
 	case int64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case uint8:
 			return int(x) < int(y)
@@ -607,6 +830,9 @@ func Less(a, b interface{}) bool {
 		case uint32:
 			return int(x) < int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Less(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) < int(y)
 		case int:
 			return int(x) < int(y)
@@ -617,7 +843,7 @@ func Less(a, b interface{}) bool {
 		case int32:
 			return int(x) < int(y)
 		case int64:
-			return int(x) < int(y)
+			return int64(x) < int64(y)
 		case float32:
 			return float64(x) < float64(y)
 		case float64:
@@ -687,6 +913,20 @@ func Less(a, b interface{}) bool {
 		case time.Time:
 			return x.Before(y)
 		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) < 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) < 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) < 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Less(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T < %T", a, b))
 }
@@ -696,24 +936,51 @@ func More(a, b interface{}) bool {
 	case uint:
 		switch y := b.(type) {
 		case uint:
-			return int(x) > int(y)
+			return uint(x) > uint(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case float32:
 			return float64(x) > float64(y)
@@ -723,14 +990,20 @@ func More(a, b interface{}) bool {
 	case uint8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint8:
-			return int(x) > int(y)
+			return uint8(x) > uint8(y)
 		case uint16:
 			return int(x) > int(y)
 		case uint32:
 			return int(x) > int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int:
 			return int(x) > int(y)
@@ -750,14 +1023,20 @@ func More(a, b interface{}) bool {
 	case uint16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint8:
 			return int(x) > int(y)
 		case uint16:
-			return int(x) > int(y)
+			return uint16(x) > uint16(y)
 		case uint32:
 			return int(x) > int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int:
 			return int(x) > int(y)
@@ -777,14 +1056,20 @@ func More(a, b interface{}) bool {
 	case uint32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint8:
 			return int(x) > int(y)
 		case uint16:
 			return int(x) > int(y)
 		case uint32:
-			return int(x) > int(y)
+			return uint32(x) > uint32(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int:
 			return int(x) > int(y)
@@ -804,24 +1089,51 @@ func More(a, b interface{}) bool {
 	case uint64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint64:
-			return int(x) > int(y)
+			return uint64(x) > uint64(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case float32:
 			return float64(x) > float64(y)
@@ -831,6 +1143,9 @@ func More(a, b interface{}) bool {
 	case int:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint8:
 			return int(x) > int(y)
@@ -839,6 +1154,9 @@ func More(a, b interface{}) bool {
 		case uint32:
 			return int(x) > int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int:
 			return int(x) > int(y)
@@ -858,6 +1176,9 @@ func More(a, b interface{}) bool {
 	case int8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint8:
 			return int(x) > int(y)
@@ -866,11 +1187,14 @@ func More(a, b interface{}) bool {
 		case uint32:
 			return int(x) > int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int:
 			return int(x) > int(y)
 		case int8:
-			return int(x) > int(y)
+			return int8(x) > int8(y)
 		case int16:
 			return int(x) > int(y)
 		case int32:
@@ -885,6 +1209,9 @@ func More(a, b interface{}) bool {
 	case int16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint8:
 			return int(x) > int(y)
@@ -893,13 +1220,16 @@ func More(a, b interface{}) bool {
 		case uint32:
 			return int(x) > int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int:
 			return int(x) > int(y)
 		case int8:
 			return int(x) > int(y)
 		case int16:
-			return int(x) > int(y)
+			return int16(x) > int16(y)
 		case int32:
 			return int(x) > int(y)
 		case int64:
@@ -912,6 +1242,9 @@ func More(a, b interface{}) bool {
 	case int32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint8:
 			return int(x) > int(y)
@@ -920,6 +1253,9 @@ func More(a, b interface{}) bool {
 		case uint32:
 			return int(x) > int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int:
 			return int(x) > int(y)
@@ -928,7 +1264,7 @@ func More(a, b interface{}) bool {
 		case int16:
 			return int(x) > int(y)
 		case int32:
-			return int(x) > int(y)
+			return int32(x) > int32(y)
 		case int64:
 			return int(x) > int(y)
 		case float32:
@@ -939,6 +1275,9 @@ func More(a, b interface{}) bool {
 	case int64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case uint8:
 			return int(x) > int(y)
@@ -947,6 +1286,9 @@ func More(a, b interface{}) bool {
 		case uint32:
 			return int(x) > int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return More(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) > int(y)
 		case int:
 			return int(x) > int(y)
@@ -957,7 +1299,7 @@ func More(a, b interface{}) bool {
 		case int32:
 			return int(x) > int(y)
 		case int64:
-			return int(x) > int(y)
+			return int64(x) > int64(y)
 		case float32:
 			return float64(x) > float64(y)
 		case float64:
@@ -1027,6 +1369,20 @@ func More(a, b interface{}) bool {
 		case time.Time:
 			return x.After(y)
 		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) > 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) > 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) > 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return More(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T > %T", a, b))
 }
@@ -1036,24 +1392,51 @@ func LessOrEqual(a, b interface{}) bool {
 	case uint:
 		switch y := b.(type) {
 		case uint:
-			return int(x) <= int(y)
+			return uint(x) <= uint(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case float32:
 			return float64(x) <= float64(y)
@@ -1063,14 +1446,20 @@ func LessOrEqual(a, b interface{}) bool {
 	case uint8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint8:
-			return int(x) <= int(y)
+			return uint8(x) <= uint8(y)
 		case uint16:
 			return int(x) <= int(y)
 		case uint32:
 			return int(x) <= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int:
 			return int(x) <= int(y)
@@ -1090,14 +1479,20 @@ func LessOrEqual(a, b interface{}) bool {
 	case uint16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint8:
 			return int(x) <= int(y)
 		case uint16:
-			return int(x) <= int(y)
+			return uint16(x) <= uint16(y)
 		case uint32:
 			return int(x) <= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int:
 			return int(x) <= int(y)
@@ -1117,14 +1512,20 @@ func LessOrEqual(a, b interface{}) bool {
 	case uint32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint8:
 			return int(x) <= int(y)
 		case uint16:
 			return int(x) <= int(y)
 		case uint32:
-			return int(x) <= int(y)
+			return uint32(x) <= uint32(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int:
 			return int(x) <= int(y)
@@ -1144,24 +1545,51 @@ func LessOrEqual(a, b interface{}) bool {
 	case uint64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint64:
-			return int(x) <= int(y)
+			return uint64(x) <= uint64(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case float32:
 			return float64(x) <= float64(y)
@@ -1171,6 +1599,9 @@ func LessOrEqual(a, b interface{}) bool {
 	case int:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint8:
 			return int(x) <= int(y)
@@ -1179,6 +1610,9 @@ func LessOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) <= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int:
 			return int(x) <= int(y)
@@ -1198,6 +1632,9 @@ func LessOrEqual(a, b interface{}) bool {
 	case int8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint8:
 			return int(x) <= int(y)
@@ -1206,11 +1643,14 @@ func LessOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) <= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int:
 			return int(x) <= int(y)
 		case int8:
-			return int(x) <= int(y)
+			return int8(x) <= int8(y)
 		case int16:
 			return int(x) <= int(y)
 		case int32:
@@ -1225,6 +1665,9 @@ func LessOrEqual(a, b interface{}) bool {
 	case int16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint8:
 			return int(x) <= int(y)
@@ -1233,13 +1676,16 @@ func LessOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) <= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int:
 			return int(x) <= int(y)
 		case int8:
 			return int(x) <= int(y)
 		case int16:
-			return int(x) <= int(y)
+			return int16(x) <= int16(y)
 		case int32:
 			return int(x) <= int(y)
 		case int64:
@@ -1252,6 +1698,9 @@ func LessOrEqual(a, b interface{}) bool {
 	case int32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint8:
 			return int(x) <= int(y)
@@ -1260,6 +1709,9 @@ func LessOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) <= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int:
 			return int(x) <= int(y)
@@ -1268,7 +1720,7 @@ func LessOrEqual(a, b interface{}) bool {
 		case int16:
 			return int(x) <= int(y)
 		case int32:
-			return int(x) <= int(y)
+			return int32(x) <= int32(y)
 		case int64:
 			return int(x) <= int(y)
 		case float32:
@@ -1279,6 +1731,9 @@ func LessOrEqual(a, b interface{}) bool {
 	case int64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case uint8:
 			return int(x) <= int(y)
@@ -1287,6 +1742,9 @@ func LessOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) <= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return LessOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) <= int(y)
 		case int:
 			return int(x) <= int(y)
@@ -1297,7 +1755,7 @@ func LessOrEqual(a, b interface{}) bool {
 		case int32:
 			return int(x) <= int(y)
 		case int64:
-			return int(x) <= int(y)
+			return int64(x) <= int64(y)
 		case float32:
 			return float64(x) <= float64(y)
 		case float64:
@@ -1367,6 +1825,20 @@ func LessOrEqual(a, b interface{}) bool {
 		case time.Time:
 			return x.Before(y) || x.Equal(y)
 		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) <= 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) <= 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) <= 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return LessOrEqual(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T <= %T", a, b))
 }
@@ -1376,24 +1848,51 @@ func MoreOrEqual(a, b interface{}) bool {
 	case uint:
 		switch y := b.(type) {
 		case uint:
-			return int(x) >= int(y)
+			return uint(x) >= uint(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case float32:
 			return float64(x) >= float64(y)
@@ -1403,14 +1902,20 @@ func MoreOrEqual(a, b interface{}) bool {
 	case uint8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint8:
-			return int(x) >= int(y)
+			return uint8(x) >= uint8(y)
 		case uint16:
 			return int(x) >= int(y)
 		case uint32:
 			return int(x) >= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int:
 			return int(x) >= int(y)
@@ -1430,14 +1935,20 @@ func MoreOrEqual(a, b interface{}) bool {
 	case uint16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint8:
 			return int(x) >= int(y)
 		case uint16:
-			return int(x) >= int(y)
+			return uint16(x) >= uint16(y)
 		case uint32:
 			return int(x) >= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int:
 			return int(x) >= int(y)
@@ -1457,14 +1968,20 @@ func MoreOrEqual(a, b interface{}) bool {
 	case uint32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint8:
 			return int(x) >= int(y)
 		case uint16:
 			return int(x) >= int(y)
 		case uint32:
-			return int(x) >= int(y)
+			return uint32(x) >= uint32(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int:
 			return int(x) >= int(y)
@@ -1484,24 +2001,51 @@ func MoreOrEqual(a, b interface{}) bool {
 	case uint64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint64:
-			return int(x) >= int(y)
+			return uint64(x) >= uint64(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case float32:
 			return float64(x) >= float64(y)
@@ -1511,6 +2055,9 @@ func MoreOrEqual(a, b interface{}) bool {
 	case int:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint8:
 			return int(x) >= int(y)
@@ -1519,6 +2066,9 @@ func MoreOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) >= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int:
 			return int(x) >= int(y)
@@ -1538,6 +2088,9 @@ func MoreOrEqual(a, b interface{}) bool {
 	case int8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint8:
 			return int(x) >= int(y)
@@ -1546,11 +2099,14 @@ func MoreOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) >= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int:
 			return int(x) >= int(y)
 		case int8:
-			return int(x) >= int(y)
+			return int8(x) >= int8(y)
 		case int16:
 			return int(x) >= int(y)
 		case int32:
@@ -1565,6 +2121,9 @@ func MoreOrEqual(a, b interface{}) bool {
 	case int16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint8:
 			return int(x) >= int(y)
@@ -1573,13 +2132,16 @@ func MoreOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) >= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int:
 			return int(x) >= int(y)
 		case int8:
 			return int(x) >= int(y)
 		case int16:
-			return int(x) >= int(y)
+			return int16(x) >= int16(y)
 		case int32:
 			return int(x) >= int(y)
 		case int64:
@@ -1592,6 +2154,9 @@ func MoreOrEqual(a, b interface{}) bool {
 	case int32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint8:
 			return int(x) >= int(y)
@@ -1600,6 +2165,9 @@ func MoreOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) >= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int:
 			return int(x) >= int(y)
@@ -1608,7 +2176,7 @@ func MoreOrEqual(a, b interface{}) bool {
 		case int16:
 			return int(x) >= int(y)
 		case int32:
-			return int(x) >= int(y)
+			return int32(x) >= int32(y)
 		case int64:
 			return int(x) >= int(y)
 		case float32:
@@ -1619,6 +2187,9 @@ func MoreOrEqual(a, b interface{}) bool {
 	case int64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case uint8:
 			return int(x) >= int(y)
@@ -1627,6 +2198,9 @@ func MoreOrEqual(a, b interface{}) bool {
 		case uint32:
 			return int(x) >= int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return MoreOrEqual(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) >= int(y)
 		case int:
 			return int(x) >= int(y)
@@ -1637,7 +2211,7 @@ func MoreOrEqual(a, b interface{}) bool {
 		case int32:
 			return int(x) >= int(y)
 		case int64:
-			return int(x) >= int(y)
+			return int64(x) >= int64(y)
 		case float32:
 			return float64(x) >= float64(y)
 		case float64:
@@ -1707,6 +2281,20 @@ func MoreOrEqual(a, b interface{}) bool {
 		case time.Time:
 			return x.After(y) || x.Equal(y)
 		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) >= 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) >= 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) >= 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return MoreOrEqual(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T >= %T", a, b))
 }
@@ -1716,24 +2304,54 @@ func Add(a, b interface{}) interface{} {
 	case uint:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case float32:
 			return float64(x) + float64(y)
@@ -1743,6 +2361,9 @@ func Add(a, b interface{}) interface{} {
 	case uint8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
 			return int(x) + int(y)
@@ -1751,6 +2372,9 @@ func Add(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) + int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
 			return int(x) + int(y)
@@ -1770,6 +2394,9 @@ func Add(a, b interface{}) interface{} {
 	case uint16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
 			return int(x) + int(y)
@@ -1778,6 +2405,9 @@ func Add(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) + int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
 			return int(x) + int(y)
@@ -1797,6 +2427,9 @@ func Add(a, b interface{}) interface{} {
 	case uint32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
 			return int(x) + int(y)
@@ -1805,6 +2438,9 @@ func Add(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) + int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
 			return int(x) + int(y)
@@ -1824,24 +2460,54 @@ func Add(a, b interface{}) interface{} {
 	case uint64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case float32:
 			return float64(x) + float64(y)
@@ -1851,6 +2517,9 @@ func Add(a, b interface{}) interface{} {
 	case int:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
 			return int(x) + int(y)
@@ -1859,6 +2528,9 @@ func Add(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) + int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
 			return int(x) + int(y)
@@ -1878,6 +2550,9 @@ func Add(a, b interface{}) interface{} {
 	case int8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
 			return int(x) + int(y)
@@ -1886,6 +2561,9 @@ func Add(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) + int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
 			return int(x) + int(y)
@@ -1905,6 +2583,9 @@ func Add(a, b interface{}) interface{} {
 	case int16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
 			return int(x) + int(y)
@@ -1913,6 +2594,9 @@ func Add(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) + int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
 			return int(x) + int(y)
@@ -1932,6 +2616,9 @@ func Add(a, b interface{}) interface{} {
 	case int32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
 			return int(x) + int(y)
@@ -1940,6 +2627,9 @@ func Add(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) + int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
 			return int(x) + int(y)
@@ -1959,6 +2649,9 @@ func Add(a, b interface{}) interface{} {
 	case int64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case uint8:
 			return int(x) + int(y)
@@ -1967,6 +2660,9 @@ func Add(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) + int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Add(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) + int(y)
 		case int:
 			return int(x) + int(y)
@@ -2053,6 +2749,15 @@ func Add(a, b interface{}) interface{} {
 			return y.Add(x)
 		}
 	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Add(y)
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Add(x, y)
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Add(x, y)
+	}
 	panic(fmt.Sprintf("invalid operation: %T + %T", a, b))
 }
 
@@ -2061,24 +2766,54 @@ func Subtract(a, b interface{}) interface{} {
 	case uint:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case float32:
 			return float64(x) - float64(y)
@@ -2088,6 +2823,9 @@ func Subtract(a, b interface{}) interface{} {
 	case uint8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
 			return int(x) - int(y)
@@ -2096,6 +2834,9 @@ func Subtract(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) - int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
 			return int(x) - int(y)
@@ -2115,6 +2856,9 @@ func Subtract(a, b interface{}) interface{} {
 	case uint16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
 			return int(x) - int(y)
@@ -2123,6 +2867,9 @@ func Subtract(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) - int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
 			return int(x) - int(y)
@@ -2142,6 +2889,9 @@ func Subtract(a, b interface{}) interface{} {
 	case uint32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
 			return int(x) - int(y)
@@ -2150,6 +2900,9 @@ func Subtract(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) - int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
 			return int(x) - int(y)
@@ -2169,24 +2922,54 @@ func Subtract(a, b interface{}) interface{} {
 	case uint64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case float32:
 			return float64(x) - float64(y)
@@ -2196,6 +2979,9 @@ func Subtract(a, b interface{}) interface{} {
 	case int:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
 			return int(x) - int(y)
@@ -2204,6 +2990,9 @@ func Subtract(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) - int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
 			return int(x) - int(y)
@@ -2223,6 +3012,9 @@ func Subtract(a, b interface{}) interface{} {
 	case int8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
 			return int(x) - int(y)
@@ -2231,6 +3023,9 @@ func Subtract(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) - int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
 			return int(x) - int(y)
@@ -2250,6 +3045,9 @@ func Subtract(a, b interface{}) interface{} {
 	case int16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
 			return int(x) - int(y)
@@ -2258,6 +3056,9 @@ func Subtract(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) - int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
 			return int(x) - int(y)
@@ -2277,6 +3078,9 @@ func Subtract(a, b interface{}) interface{} {
 	case int32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
 			return int(x) - int(y)
@@ -2285,6 +3089,9 @@ func Subtract(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) - int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
 			return int(x) - int(y)
@@ -2304,6 +3111,9 @@ func Subtract(a, b interface{}) interface{} {
 	case int64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case uint8:
 			return int(x) - int(y)
@@ -2312,6 +3122,9 @@ func Subtract(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) - int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Subtract(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) - int(y)
 		case int:
 			return int(x) - int(y)
@@ -2387,6 +3200,20 @@ func Subtract(a, b interface{}) interface{} {
 		case time.Time:
 			return x.Sub(y)
 		}
+	case Set:
+		switch y := b.(type) {
+		case Set:
+			return x.Difference(y)
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Sub(y)
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Sub(x, y)
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Subtract(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T - %T", a, b))
 }
@@ -2396,24 +3223,54 @@ func Multiply(a, b interface{}) interface{} {
 	case uint:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case float32:
 			return float64(x) * float64(y)
@@ -2423,6 +3280,9 @@ func Multiply(a, b interface{}) interface{} {
 	case uint8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
 			return int(x) * int(y)
@@ -2431,6 +3291,9 @@ func Multiply(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) * int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
 			return int(x) * int(y)
@@ -2450,6 +3313,9 @@ func Multiply(a, b interface{}) interface{} {
 	case uint16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
 			return int(x) * int(y)
@@ -2458,6 +3324,9 @@ func Multiply(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) * int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
 			return int(x) * int(y)
@@ -2477,6 +3346,9 @@ func Multiply(a, b interface{}) interface{} {
 	case uint32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
 			return int(x) * int(y)
@@ -2485,6 +3357,9 @@ func Multiply(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) * int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
 			return int(x) * int(y)
@@ -2504,24 +3379,54 @@ func Multiply(a, b interface{}) interface{} {
 	case uint64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case float32:
 			return float64(x) * float64(y)
@@ -2531,6 +3436,9 @@ func Multiply(a, b interface{}) interface{} {
 	case int:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
 			return int(x) * int(y)
@@ -2539,6 +3447,9 @@ func Multiply(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) * int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
 			return int(x) * int(y)
@@ -2558,6 +3469,9 @@ func Multiply(a, b interface{}) interface{} {
 	case int8:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
 			return int(x) * int(y)
@@ -2566,6 +3480,9 @@ func Multiply(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) * int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
 			return int(x) * int(y)
@@ -2585,6 +3502,9 @@ func Multiply(a, b interface{}) interface{} {
 	case int16:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
 			return int(x) * int(y)
@@ -2593,6 +3513,9 @@ func Multiply(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) * int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
 			return int(x) * int(y)
@@ -2612,6 +3535,9 @@ func Multiply(a, b interface{}) interface{} {
 	case int32:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
 			return int(x) * int(y)
@@ -2620,6 +3546,9 @@ func Multiply(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) * int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
 			return int(x) * int(y)
@@ -2639,6 +3568,9 @@ func Multiply(a, b interface{}) interface{} {
 	case int64:
 		switch y := b.(type) {
 		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case uint8:
 			return int(x) * int(y)
@@ -2647,6 +3579,9 @@ func Multiply(a, b interface{}) interface{} {
 		case uint32:
 			return int(x) * int(y)
 		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Multiply(ToBigInt(x), ToBigInt(y))
+			}
 			return int(x) * int(y)
 		case int:
 			return int(x) * int(y)
@@ -2718,10 +3653,19 @@ func Multiply(a, b interface{}) interface{} {
 			return float64(x) * float64(y)
 		}
 	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Mul(y)
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Mul(x, y)
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Multiply(x, y)
+	}
 	panic(fmt.Sprintf("invalid operation: %T * %T", a, b))
 }
 
-func Divide(a, b interface{}) float64 {
+func Divide(a, b interface{}) interface{} {
 	switch x := a.(type) {
 	case uint:
 		switch y := b.(type) {
@@ -3048,241 +3992,1642 @@ func Divide(a, b interface{}) float64 {
 			return float64(x) / float64(y)
 		}
 	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Quo(y)
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return bigQuo(x, y)
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Divide(x, y)
+	}
 	panic(fmt.Sprintf("invalid operation: %T / %T", a, b))
 }
 
-func Modulo(a, b interface{}) int {
+// DivideInt divides integers with an integer result, truncated toward zero.
+// Other numbers are divided as by Divide.
+func DivideInt(a, b interface{}) interface{} {
 	switch x := a.(type) {
 	case uint:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			return uint(x) / uint(y)
 		case uint8:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint16:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint32:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint64:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		}
 	case uint8:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint8:
-			return int(x) % int(y)
+			return uint8(x) / uint8(y)
 		case uint16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint64:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		}
 	case uint16:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint16:
-			return int(x) % int(y)
+			return uint16(x) / uint16(y)
 		case uint32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint64:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		}
 	case uint32:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint32:
-			return int(x) % int(y)
+			return uint32(x) / uint32(y)
 		case uint64:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		}
 	case uint64:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint8:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint16:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint32:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint64:
-			return int(x) % int(y)
+			return uint64(x) / uint64(y)
 		case int:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			if uint64(x) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		}
 	case int:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint64:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		}
 	case int8:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint64:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		}
 	case int16:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint64:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		}
 	case int32:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint64:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		}
 	case int64:
 		switch y := b.(type) {
 		case uint:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case uint8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case uint64:
-			return int(x) % int(y)
+			if uint64(y) > math.MaxInt64 {
+				return DivideInt(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) / int(y)
 		case int:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int8:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int16:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int32:
-			return int(x) % int(y)
+			return int(x) / int(y)
 		case int64:
-			return int(x) % int(y)
+			return int(x) / int(y)
+		}
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Quo(x, y)
+	}
+	return Divide(a, b)
+}
+
+func AddChecked(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return uint(addUint(uint64(x), uint64(y), a, b))
+		case uint8:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case uint16:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case uint32:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case uint64:
+			return addInt(uintToInt(uint64(x), "+", a, b), uintToInt(uint64(y), "+", a, b), a, b)
+		case int:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case int8:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case int16:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case int32:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case int64:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case uint8:
+			return uint8(inRange(addInt(int(x), int(y), a, b), 0, math.MaxUint8, "+", a, b))
+		case uint16:
+			return addInt(int(x), int(y), a, b)
+		case uint32:
+			return addInt(int(x), int(y), a, b)
+		case uint64:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case int:
+			return addInt(int(x), int(y), a, b)
+		case int8:
+			return addInt(int(x), int(y), a, b)
+		case int16:
+			return addInt(int(x), int(y), a, b)
+		case int32:
+			return addInt(int(x), int(y), a, b)
+		case int64:
+			return addInt(int(x), int(y), a, b)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case uint8:
+			return addInt(int(x), int(y), a, b)
+		case uint16:
+			return uint16(inRange(addInt(int(x), int(y), a, b), 0, math.MaxUint16, "+", a, b))
+		case uint32:
+			return addInt(int(x), int(y), a, b)
+		case uint64:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case int:
+			return addInt(int(x), int(y), a, b)
+		case int8:
+			return addInt(int(x), int(y), a, b)
+		case int16:
+			return addInt(int(x), int(y), a, b)
+		case int32:
+			return addInt(int(x), int(y), a, b)
+		case int64:
+			return addInt(int(x), int(y), a, b)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case uint8:
+			return addInt(int(x), int(y), a, b)
+		case uint16:
+			return addInt(int(x), int(y), a, b)
+		case uint32:
+			return uint32(inRange(addInt(int(x), int(y), a, b), 0, math.MaxUint32, "+", a, b))
+		case uint64:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case int:
+			return addInt(int(x), int(y), a, b)
+		case int8:
+			return addInt(int(x), int(y), a, b)
+		case int16:
+			return addInt(int(x), int(y), a, b)
+		case int32:
+			return addInt(int(x), int(y), a, b)
+		case int64:
+			return addInt(int(x), int(y), a, b)
 		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return addInt(uintToInt(uint64(x), "+", a, b), uintToInt(uint64(y), "+", a, b), a, b)
+		case uint8:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case uint16:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case uint32:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case uint64:
+			return uint64(addUint(uint64(x), uint64(y), a, b))
+		case int:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case int8:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case int16:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case int32:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		case int64:
+			return addInt(uintToInt(uint64(x), "+", a, b), int(y), a, b)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case uint8:
+			return addInt(int(x), int(y), a, b)
+		case uint16:
+			return addInt(int(x), int(y), a, b)
+		case uint32:
+			return addInt(int(x), int(y), a, b)
+		case uint64:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case int:
+			return addInt(int(x), int(y), a, b)
+		case int8:
+			return addInt(int(x), int(y), a, b)
+		case int16:
+			return addInt(int(x), int(y), a, b)
+		case int32:
+			return addInt(int(x), int(y), a, b)
+		case int64:
+			return addInt(int(x), int(y), a, b)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case uint8:
+			return addInt(int(x), int(y), a, b)
+		case uint16:
+			return addInt(int(x), int(y), a, b)
+		case uint32:
+			return addInt(int(x), int(y), a, b)
+		case uint64:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case int:
+			return addInt(int(x), int(y), a, b)
+		case int8:
+			return int8(inRange(addInt(int(x), int(y), a, b), math.MinInt8, math.MaxInt8, "+", a, b))
+		case int16:
+			return addInt(int(x), int(y), a, b)
+		case int32:
+			return addInt(int(x), int(y), a, b)
+		case int64:
+			return addInt(int(x), int(y), a, b)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case uint8:
+			return addInt(int(x), int(y), a, b)
+		case uint16:
+			return addInt(int(x), int(y), a, b)
+		case uint32:
+			return addInt(int(x), int(y), a, b)
+		case uint64:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case int:
+			return addInt(int(x), int(y), a, b)
+		case int8:
+			return addInt(int(x), int(y), a, b)
+		case int16:
+			return int16(inRange(addInt(int(x), int(y), a, b), math.MinInt16, math.MaxInt16, "+", a, b))
+		case int32:
+			return addInt(int(x), int(y), a, b)
+		case int64:
+			return addInt(int(x), int(y), a, b)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case uint8:
+			return addInt(int(x), int(y), a, b)
+		case uint16:
+			return addInt(int(x), int(y), a, b)
+		case uint32:
+			return addInt(int(x), int(y), a, b)
+		case uint64:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case int:
+			return addInt(int(x), int(y), a, b)
+		case int8:
+			return addInt(int(x), int(y), a, b)
+		case int16:
+			return addInt(int(x), int(y), a, b)
+		case int32:
+			return int32(inRange(addInt(int(x), int(y), a, b), math.MinInt32, math.MaxInt32, "+", a, b))
+		case int64:
+			return addInt(int(x), int(y), a, b)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case uint8:
+			return addInt(int(x), int(y), a, b)
+		case uint16:
+			return addInt(int(x), int(y), a, b)
+		case uint32:
+			return addInt(int(x), int(y), a, b)
+		case uint64:
+			return addInt(int(x), uintToInt(uint64(y), "+", a, b), a, b)
+		case int:
+			return addInt(int(x), int(y), a, b)
+		case int8:
+			return addInt(int(x), int(y), a, b)
+		case int16:
+			return addInt(int(x), int(y), a, b)
+		case int32:
+			return addInt(int(x), int(y), a, b)
+		case int64:
+			return int64(addInt(int(x), int(y), a, b))
+		}
+	}
+	return Add(a, b)
+}
+
+func SubtractChecked(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return uint(subtractUint(uint64(x), uint64(y), a, b))
+		case uint8:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case uint16:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case uint32:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case uint64:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), uintToInt(uint64(y), "-", a, b), a, b)
+		case int:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case int8:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case int16:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case int32:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case int64:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case uint8:
+			return uint8(inRange(subtractInt(int(x), int(y), a, b), 0, math.MaxUint8, "-", a, b))
+		case uint16:
+			return subtractInt(int(x), int(y), a, b)
+		case uint32:
+			return subtractInt(int(x), int(y), a, b)
+		case uint64:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case int:
+			return subtractInt(int(x), int(y), a, b)
+		case int8:
+			return subtractInt(int(x), int(y), a, b)
+		case int16:
+			return subtractInt(int(x), int(y), a, b)
+		case int32:
+			return subtractInt(int(x), int(y), a, b)
+		case int64:
+			return subtractInt(int(x), int(y), a, b)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case uint8:
+			return subtractInt(int(x), int(y), a, b)
+		case uint16:
+			return uint16(inRange(subtractInt(int(x), int(y), a, b), 0, math.MaxUint16, "-", a, b))
+		case uint32:
+			return subtractInt(int(x), int(y), a, b)
+		case uint64:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case int:
+			return subtractInt(int(x), int(y), a, b)
+		case int8:
+			return subtractInt(int(x), int(y), a, b)
+		case int16:
+			return subtractInt(int(x), int(y), a, b)
+		case int32:
+			return subtractInt(int(x), int(y), a, b)
+		case int64:
+			return subtractInt(int(x), int(y), a, b)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case uint8:
+			return subtractInt(int(x), int(y), a, b)
+		case uint16:
+			return subtractInt(int(x), int(y), a, b)
+		case uint32:
+			return uint32(inRange(subtractInt(int(x), int(y), a, b), 0, math.MaxUint32, "-", a, b))
+		case uint64:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case int:
+			return subtractInt(int(x), int(y), a, b)
+		case int8:
+			return subtractInt(int(x), int(y), a, b)
+		case int16:
+			return subtractInt(int(x), int(y), a, b)
+		case int32:
+			return subtractInt(int(x), int(y), a, b)
+		case int64:
+			return subtractInt(int(x), int(y), a, b)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), uintToInt(uint64(y), "-", a, b), a, b)
+		case uint8:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case uint16:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case uint32:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case uint64:
+			return uint64(subtractUint(uint64(x), uint64(y), a, b))
+		case int:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case int8:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case int16:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case int32:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		case int64:
+			return subtractInt(uintToInt(uint64(x), "-", a, b), int(y), a, b)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case uint8:
+			return subtractInt(int(x), int(y), a, b)
+		case uint16:
+			return subtractInt(int(x), int(y), a, b)
+		case uint32:
+			return subtractInt(int(x), int(y), a, b)
+		case uint64:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case int:
+			return subtractInt(int(x), int(y), a, b)
+		case int8:
+			return subtractInt(int(x), int(y), a, b)
+		case int16:
+			return subtractInt(int(x), int(y), a, b)
+		case int32:
+			return subtractInt(int(x), int(y), a, b)
+		case int64:
+			return subtractInt(int(x), int(y), a, b)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case uint8:
+			return subtractInt(int(x), int(y), a, b)
+		case uint16:
+			return subtractInt(int(x), int(y), a, b)
+		case uint32:
+			return subtractInt(int(x), int(y), a, b)
+		case uint64:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case int:
+			return subtractInt(int(x), int(y), a, b)
+		case int8:
+			return int8(inRange(subtractInt(int(x), int(y), a, b), math.MinInt8, math.MaxInt8, "-", a, b))
+		case int16:
+			return subtractInt(int(x), int(y), a, b)
+		case int32:
+			return subtractInt(int(x), int(y), a, b)
+		case int64:
+			return subtractInt(int(x), int(y), a, b)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case uint8:
+			return subtractInt(int(x), int(y), a, b)
+		case uint16:
+			return subtractInt(int(x), int(y), a, b)
+		case uint32:
+			return subtractInt(int(x), int(y), a, b)
+		case uint64:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case int:
+			return subtractInt(int(x), int(y), a, b)
+		case int8:
+			return subtractInt(int(x), int(y), a, b)
+		case int16:
+			return int16(inRange(subtractInt(int(x), int(y), a, b), math.MinInt16, math.MaxInt16, "-", a, b))
+		case int32:
+			return subtractInt(int(x), int(y), a, b)
+		case int64:
+			return subtractInt(int(x), int(y), a, b)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case uint8:
+			return subtractInt(int(x), int(y), a, b)
+		case uint16:
+			return subtractInt(int(x), int(y), a, b)
+		case uint32:
+			return subtractInt(int(x), int(y), a, b)
+		case uint64:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case int:
+			return subtractInt(int(x), int(y), a, b)
+		case int8:
+			return subtractInt(int(x), int(y), a, b)
+		case int16:
+			return subtractInt(int(x), int(y), a, b)
+		case int32:
+			return int32(inRange(subtractInt(int(x), int(y), a, b), math.MinInt32, math.MaxInt32, "-", a, b))
+		case int64:
+			return subtractInt(int(x), int(y), a, b)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case uint8:
+			return subtractInt(int(x), int(y), a, b)
+		case uint16:
+			return subtractInt(int(x), int(y), a, b)
+		case uint32:
+			return subtractInt(int(x), int(y), a, b)
+		case uint64:
+			return subtractInt(int(x), uintToInt(uint64(y), "-", a, b), a, b)
+		case int:
+			return subtractInt(int(x), int(y), a, b)
+		case int8:
+			return subtractInt(int(x), int(y), a, b)
+		case int16:
+			return subtractInt(int(x), int(y), a, b)
+		case int32:
+			return subtractInt(int(x), int(y), a, b)
+		case int64:
+			return int64(subtractInt(int(x), int(y), a, b))
+		}
+	}
+	return Subtract(a, b)
+}
+
+func MultiplyChecked(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return uint(multiplyUint(uint64(x), uint64(y), a, b))
+		case uint8:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case uint16:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case uint32:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case uint64:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), uintToInt(uint64(y), "*", a, b), a, b)
+		case int:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case int8:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case int16:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case int32:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case int64:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case uint8:
+			return uint8(inRange(multiplyInt(int(x), int(y), a, b), 0, math.MaxUint8, "*", a, b))
+		case uint16:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint32:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint64:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case int:
+			return multiplyInt(int(x), int(y), a, b)
+		case int8:
+			return multiplyInt(int(x), int(y), a, b)
+		case int16:
+			return multiplyInt(int(x), int(y), a, b)
+		case int32:
+			return multiplyInt(int(x), int(y), a, b)
+		case int64:
+			return multiplyInt(int(x), int(y), a, b)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case uint8:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint16:
+			return uint16(inRange(multiplyInt(int(x), int(y), a, b), 0, math.MaxUint16, "*", a, b))
+		case uint32:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint64:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case int:
+			return multiplyInt(int(x), int(y), a, b)
+		case int8:
+			return multiplyInt(int(x), int(y), a, b)
+		case int16:
+			return multiplyInt(int(x), int(y), a, b)
+		case int32:
+			return multiplyInt(int(x), int(y), a, b)
+		case int64:
+			return multiplyInt(int(x), int(y), a, b)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case uint8:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint16:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint32:
+			return uint32(inRange(multiplyInt(int(x), int(y), a, b), 0, math.MaxUint32, "*", a, b))
+		case uint64:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case int:
+			return multiplyInt(int(x), int(y), a, b)
+		case int8:
+			return multiplyInt(int(x), int(y), a, b)
+		case int16:
+			return multiplyInt(int(x), int(y), a, b)
+		case int32:
+			return multiplyInt(int(x), int(y), a, b)
+		case int64:
+			return multiplyInt(int(x), int(y), a, b)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), uintToInt(uint64(y), "*", a, b), a, b)
+		case uint8:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case uint16:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case uint32:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case uint64:
+			return uint64(multiplyUint(uint64(x), uint64(y), a, b))
+		case int:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case int8:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case int16:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case int32:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		case int64:
+			return multiplyInt(uintToInt(uint64(x), "*", a, b), int(y), a, b)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case uint8:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint16:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint32:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint64:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case int:
+			return multiplyInt(int(x), int(y), a, b)
+		case int8:
+			return multiplyInt(int(x), int(y), a, b)
+		case int16:
+			return multiplyInt(int(x), int(y), a, b)
+		case int32:
+			return multiplyInt(int(x), int(y), a, b)
+		case int64:
+			return multiplyInt(int(x), int(y), a, b)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case uint8:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint16:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint32:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint64:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case int:
+			return multiplyInt(int(x), int(y), a, b)
+		case int8:
+			return int8(inRange(multiplyInt(int(x), int(y), a, b), math.MinInt8, math.MaxInt8, "*", a, b))
+		case int16:
+			return multiplyInt(int(x), int(y), a, b)
+		case int32:
+			return multiplyInt(int(x), int(y), a, b)
+		case int64:
+			return multiplyInt(int(x), int(y), a, b)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case uint8:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint16:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint32:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint64:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case int:
+			return multiplyInt(int(x), int(y), a, b)
+		case int8:
+			return multiplyInt(int(x), int(y), a, b)
+		case int16:
+			return int16(inRange(multiplyInt(int(x), int(y), a, b), math.MinInt16, math.MaxInt16, "*", a, b))
+		case int32:
+			return multiplyInt(int(x), int(y), a, b)
+		case int64:
+			return multiplyInt(int(x), int(y), a, b)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case uint8:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint16:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint32:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint64:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case int:
+			return multiplyInt(int(x), int(y), a, b)
+		case int8:
+			return multiplyInt(int(x), int(y), a, b)
+		case int16:
+			return multiplyInt(int(x), int(y), a, b)
+		case int32:
+			return int32(inRange(multiplyInt(int(x), int(y), a, b), math.MinInt32, math.MaxInt32, "*", a, b))
+		case int64:
+			return multiplyInt(int(x), int(y), a, b)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case uint8:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint16:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint32:
+			return multiplyInt(int(x), int(y), a, b)
+		case uint64:
+			return multiplyInt(int(x), uintToInt(uint64(y), "*", a, b), a, b)
+		case int:
+			return multiplyInt(int(x), int(y), a, b)
+		case int8:
+			return multiplyInt(int(x), int(y), a, b)
+		case int16:
+			return multiplyInt(int(x), int(y), a, b)
+		case int32:
+			return multiplyInt(int(x), int(y), a, b)
+		case int64:
+			return int64(multiplyInt(int(x), int(y), a, b))
+		}
+	}
+	return Multiply(a, b)
+}
+
+func DivideIntChecked(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return uint(divideUint(uint64(x), uint64(y), a, b))
+		case uint8:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case uint16:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case uint32:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case uint64:
+			return divideInt(uintToInt(uint64(x), "/", a, b), uintToInt(uint64(y), "/", a, b), a, b)
+		case int:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case int8:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case int16:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case int32:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case int64:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case uint8:
+			return uint8(inRange(divideInt(int(x), int(y), a, b), 0, math.MaxUint8, "/", a, b))
+		case uint16:
+			return divideInt(int(x), int(y), a, b)
+		case uint32:
+			return divideInt(int(x), int(y), a, b)
+		case uint64:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case int:
+			return divideInt(int(x), int(y), a, b)
+		case int8:
+			return divideInt(int(x), int(y), a, b)
+		case int16:
+			return divideInt(int(x), int(y), a, b)
+		case int32:
+			return divideInt(int(x), int(y), a, b)
+		case int64:
+			return divideInt(int(x), int(y), a, b)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case uint8:
+			return divideInt(int(x), int(y), a, b)
+		case uint16:
+			return uint16(inRange(divideInt(int(x), int(y), a, b), 0, math.MaxUint16, "/", a, b))
+		case uint32:
+			return divideInt(int(x), int(y), a, b)
+		case uint64:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case int:
+			return divideInt(int(x), int(y), a, b)
+		case int8:
+			return divideInt(int(x), int(y), a, b)
+		case int16:
+			return divideInt(int(x), int(y), a, b)
+		case int32:
+			return divideInt(int(x), int(y), a, b)
+		case int64:
+			return divideInt(int(x), int(y), a, b)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case uint8:
+			return divideInt(int(x), int(y), a, b)
+		case uint16:
+			return divideInt(int(x), int(y), a, b)
+		case uint32:
+			return uint32(inRange(divideInt(int(x), int(y), a, b), 0, math.MaxUint32, "/", a, b))
+		case uint64:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case int:
+			return divideInt(int(x), int(y), a, b)
+		case int8:
+			return divideInt(int(x), int(y), a, b)
+		case int16:
+			return divideInt(int(x), int(y), a, b)
+		case int32:
+			return divideInt(int(x), int(y), a, b)
+		case int64:
+			return divideInt(int(x), int(y), a, b)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return divideInt(uintToInt(uint64(x), "/", a, b), uintToInt(uint64(y), "/", a, b), a, b)
+		case uint8:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case uint16:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case uint32:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case uint64:
+			return uint64(divideUint(uint64(x), uint64(y), a, b))
+		case int:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case int8:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case int16:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case int32:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		case int64:
+			return divideInt(uintToInt(uint64(x), "/", a, b), int(y), a, b)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case uint8:
+			return divideInt(int(x), int(y), a, b)
+		case uint16:
+			return divideInt(int(x), int(y), a, b)
+		case uint32:
+			return divideInt(int(x), int(y), a, b)
+		case uint64:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case int:
+			return divideInt(int(x), int(y), a, b)
+		case int8:
+			return divideInt(int(x), int(y), a, b)
+		case int16:
+			return divideInt(int(x), int(y), a, b)
+		case int32:
+			return divideInt(int(x), int(y), a, b)
+		case int64:
+			return divideInt(int(x), int(y), a, b)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case uint8:
+			return divideInt(int(x), int(y), a, b)
+		case uint16:
+			return divideInt(int(x), int(y), a, b)
+		case uint32:
+			return divideInt(int(x), int(y), a, b)
+		case uint64:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case int:
+			return divideInt(int(x), int(y), a, b)
+		case int8:
+			return int8(inRange(divideInt(int(x), int(y), a, b), math.MinInt8, math.MaxInt8, "/", a, b))
+		case int16:
+			return divideInt(int(x), int(y), a, b)
+		case int32:
+			return divideInt(int(x), int(y), a, b)
+		case int64:
+			return divideInt(int(x), int(y), a, b)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case uint8:
+			return divideInt(int(x), int(y), a, b)
+		case uint16:
+			return divideInt(int(x), int(y), a, b)
+		case uint32:
+			return divideInt(int(x), int(y), a, b)
+		case uint64:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case int:
+			return divideInt(int(x), int(y), a, b)
+		case int8:
+			return divideInt(int(x), int(y), a, b)
+		case int16:
+			return int16(inRange(divideInt(int(x), int(y), a, b), math.MinInt16, math.MaxInt16, "/", a, b))
+		case int32:
+			return divideInt(int(x), int(y), a, b)
+		case int64:
+			return divideInt(int(x), int(y), a, b)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case uint8:
+			return divideInt(int(x), int(y), a, b)
+		case uint16:
+			return divideInt(int(x), int(y), a, b)
+		case uint32:
+			return divideInt(int(x), int(y), a, b)
+		case uint64:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case int:
+			return divideInt(int(x), int(y), a, b)
+		case int8:
+			return divideInt(int(x), int(y), a, b)
+		case int16:
+			return divideInt(int(x), int(y), a, b)
+		case int32:
+			return int32(inRange(divideInt(int(x), int(y), a, b), math.MinInt32, math.MaxInt32, "/", a, b))
+		case int64:
+			return divideInt(int(x), int(y), a, b)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case uint8:
+			return divideInt(int(x), int(y), a, b)
+		case uint16:
+			return divideInt(int(x), int(y), a, b)
+		case uint32:
+			return divideInt(int(x), int(y), a, b)
+		case uint64:
+			return divideInt(int(x), uintToInt(uint64(y), "/", a, b), a, b)
+		case int:
+			return divideInt(int(x), int(y), a, b)
+		case int8:
+			return divideInt(int(x), int(y), a, b)
+		case int16:
+			return divideInt(int(x), int(y), a, b)
+		case int32:
+			return divideInt(int(x), int(y), a, b)
+		case int64:
+			return int64(divideInt(int(x), int(y), a, b))
+		}
+	}
+	return DivideInt(a, b)
+}
+
+func Modulo(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return uint(x) % uint(y)
+		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint64:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint8:
+			return uint8(x) % uint8(y)
+		case uint16:
+			return int(x) % int(y)
+		case uint32:
+			return int(x) % int(y)
+		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int:
+			return int(x) % int(y)
+		case int8:
+			return int(x) % int(y)
+		case int16:
+			return int(x) % int(y)
+		case int32:
+			return int(x) % int(y)
+		case int64:
+			return int(x) % int(y)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint8:
+			return int(x) % int(y)
+		case uint16:
+			return uint16(x) % uint16(y)
+		case uint32:
+			return int(x) % int(y)
+		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int:
+			return int(x) % int(y)
+		case int8:
+			return int(x) % int(y)
+		case int16:
+			return int(x) % int(y)
+		case int32:
+			return int(x) % int(y)
+		case int64:
+			return int(x) % int(y)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint8:
+			return int(x) % int(y)
+		case uint16:
+			return int(x) % int(y)
+		case uint32:
+			return uint32(x) % uint32(y)
+		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int:
+			return int(x) % int(y)
+		case int8:
+			return int(x) % int(y)
+		case int16:
+			return int(x) % int(y)
+		case int32:
+			return int(x) % int(y)
+		case int64:
+			return int(x) % int(y)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			if uint64(x) > math.MaxInt64 || uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint8:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint16:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint32:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint64:
+			return uint64(x) % uint64(y)
+		case int:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int8:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int16:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int32:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int64:
+			if uint64(x) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint8:
+			return int(x) % int(y)
+		case uint16:
+			return int(x) % int(y)
+		case uint32:
+			return int(x) % int(y)
+		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int:
+			return int(x) % int(y)
+		case int8:
+			return int(x) % int(y)
+		case int16:
+			return int(x) % int(y)
+		case int32:
+			return int(x) % int(y)
+		case int64:
+			return int(x) % int(y)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint8:
+			return int(x) % int(y)
+		case uint16:
+			return int(x) % int(y)
+		case uint32:
+			return int(x) % int(y)
+		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int:
+			return int(x) % int(y)
+		case int8:
+			return int8(x) % int8(y)
+		case int16:
+			return int(x) % int(y)
+		case int32:
+			return int(x) % int(y)
+		case int64:
+			return int(x) % int(y)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint8:
+			return int(x) % int(y)
+		case uint16:
+			return int(x) % int(y)
+		case uint32:
+			return int(x) % int(y)
+		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int:
+			return int(x) % int(y)
+		case int8:
+			return int(x) % int(y)
+		case int16:
+			return int16(x) % int16(y)
+		case int32:
+			return int(x) % int(y)
+		case int64:
+			return int(x) % int(y)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint8:
+			return int(x) % int(y)
+		case uint16:
+			return int(x) % int(y)
+		case uint32:
+			return int(x) % int(y)
+		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int:
+			return int(x) % int(y)
+		case int8:
+			return int(x) % int(y)
+		case int16:
+			return int(x) % int(y)
+		case int32:
+			return int32(x) % int32(y)
+		case int64:
+			return int(x) % int(y)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case uint8:
+			return int(x) % int(y)
+		case uint16:
+			return int(x) % int(y)
+		case uint32:
+			return int(x) % int(y)
+		case uint64:
+			if uint64(y) > math.MaxInt64 {
+				return Modulo(ToBigInt(x), ToBigInt(y))
+			}
+			return int(x) % int(y)
+		case int:
+			return int(x) % int(y)
+		case int8:
+			return int(x) % int(y)
+		case int16:
+			return int(x) % int(y)
+		case int32:
+			return int(x) % int(y)
+		case int64:
+			return int64(x) % int64(y)
+		}
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Rem(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T %% %T", a, b))
 }
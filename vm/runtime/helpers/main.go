@@ -15,6 +15,7 @@ func main() {
 			Funcs(template.FuncMap{
 				"cases":          func(op string) string { return cases(op, false) },
 				"cases_int_only": func(op string) string { return cases(op, true) },
+				"cases_checked":  checkedCases,
 			}).
 			Parse(helpers),
 	).Execute(&b, types)
@@ -64,12 +65,116 @@ func cases(op string, noFloat bool) string {
 			t := "int"
 			if aIsFloat || bIsFloat {
 				t = "float64"
+			} else if a == b && keepsSize(op, a) {
+				t = a
 			}
 			echo(`case %v:`, b)
-			if op == "/" {
+			if op == "/" && !noFloat {
 				echo(`return float64(x) / float64(y)`)
-			} else {
-				echo(`return %v(x) %v %v(y)`, t, op, t)
+				continue
+			}
+			if t == "int" {
+				if guard := unsignedGuard(a, b); guard != "" {
+					echo(`if %v {`, guard)
+					echo(`return %v(ToBigInt(x), ToBigInt(y))`, functions[op])
+					echo(`}`)
+				}
+			}
+			echo(`return %v(x) %v %v(y)`, t, op, t)
+		}
+		echo(`}`)
+	}
+	return strings.TrimRight(out, "\n")
+}
+
+// functions are the names of the runtime functions of operators, which
+// are called again with *big.Int operands by unsignedGuard cases.
+var functions = map[string]string{
+	"==": "Equal",
+	"<":  "Less",
+	">":  "More",
+	"<=": "LessOrEqual",
+	">=": "MoreOrEqual",
+	"+":  "Add",
+	"-":  "Subtract",
+	"*":  "Multiply",
+	"/":  "DivideInt",
+	"%":  "Modulo",
+}
+
+// unsignedGuard returns the condition on which an operation of integers
+// of types a and b, done in int, is done with *big.Int instead: if one of
+// the operands is unsigned and larger than the maximum int.
+func unsignedGuard(a, b string) string {
+	var guards []string
+	if a == "uint" || a == "uint64" {
+		guards = append(guards, "uint64(x) > math.MaxInt64")
+	}
+	if b == "uint" || b == "uint64" {
+		guards = append(guards, "uint64(y) > math.MaxInt64")
+	}
+	return strings.Join(guards, " || ")
+}
+
+// keepsSize reports whether an operation on two integers of type t is done
+// in the type. Only operations which can't overflow are, others are done in
+// int, unless they are checked.
+func keepsSize(op, t string) bool {
+	switch op {
+	case "==", "<", ">", "<=", ">=", "%":
+		return true
+	case "/":
+		return strings.HasPrefix(t, "uint")
+	}
+	return false
+}
+
+// bounds are the minimum and maximum values of sized integers.
+var bounds = map[string][2]string{
+	"int8":   {"math.MinInt8", "math.MaxInt8"},
+	"int16":  {"math.MinInt16", "math.MaxInt16"},
+	"int32":  {"math.MinInt32", "math.MaxInt32"},
+	"uint8":  {"0", "math.MaxUint8"},
+	"uint16": {"0", "math.MaxUint16"},
+	"uint32": {"0", "math.MaxUint32"},
+}
+
+// checkedCases are like cases_int_only, but the operation is done by the
+// fn+"Int" helper, which panics with OverflowError if the result does not
+// fit into int. Unsigned operands larger than the maximum int overflow as
+// well. Operands of the same type are checked against the range of the type
+// and keep it.
+func checkedCases(op, fn string) string {
+	var out string
+	echo := func(s string, xs ...interface{}) {
+		out += fmt.Sprintf(s, xs...) + "\n"
+	}
+	operand := func(t, v string) string {
+		if t == "uint" || t == "uint64" {
+			return fmt.Sprintf("uintToInt(uint64(%v), %q, a, b)", v, op)
+		}
+		return fmt.Sprintf("int(%v)", v)
+	}
+	for _, a := range types {
+		if strings.HasPrefix(a, "float") {
+			continue
+		}
+		echo(`case %v:`, a)
+		echo(`switch y := b.(type) {`)
+		for _, b := range types {
+			if strings.HasPrefix(b, "float") {
+				continue
+			}
+			echo(`case %v:`, b)
+			switch {
+			case a != b || a == "int":
+				echo(`return %vInt(%v, %v, a, b)`, fn, operand(a, "x"), operand(b, "y"))
+			case a == "uint" || a == "uint64":
+				echo(`return %v(%vUint(uint64(x), uint64(y), a, b))`, a, fn)
+			case a == "int64":
+				echo(`return int64(%vInt(int(x), int(y), a, b))`, fn)
+			default:
+				echo(`return %v(inRange(%vInt(int(x), int(y), a, b), %v, %v, %q, a, b))`, a, fn, bounds[a][0], bounds[a][1], op)
 			}
 		}
 		echo(`}`)
@@ -83,6 +188,9 @@ package runtime
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"net"
 	"reflect"
 	"time"
 )
@@ -100,6 +208,25 @@ func Equal(a, b interface{}) bool {
 		case time.Time:
 			return x.Equal(y)
 		}
+	case net.IP:
+		switch y := b.(type) {
+		case net.IP:
+			return x.Equal(y)
+		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) == 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) == 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) == 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Equal(x, y)
 	}
 	if IsNil(a) && IsNil(b) {
 		return true
@@ -120,6 +247,20 @@ func Less(a, b interface{}) bool {
 		case time.Time:
 			return x.Before(y)
 		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) < 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) < 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) < 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Less(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T < %T", a, b))
 }
@@ -137,6 +278,20 @@ func More(a, b interface{}) bool {
 		case time.Time:
 			return x.After(y)
 		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) > 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) > 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) > 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return More(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T > %T", a, b))
 }
@@ -154,6 +309,20 @@ func LessOrEqual(a, b interface{}) bool {
 		case time.Time:
 			return x.Before(y) || x.Equal(y)
 		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) <= 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) <= 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) <= 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return LessOrEqual(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T <= %T", a, b))
 }
@@ -171,6 +340,20 @@ func MoreOrEqual(a, b interface{}) bool {
 		case time.Time:
 			return x.After(y) || x.Equal(y)
 		}
+	case *Version:
+		switch y := b.(type) {
+		case *Version:
+			return x.Compare(y) >= 0
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Cmp(y) >= 0
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return x.Cmp(y) >= 0
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return MoreOrEqual(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T >= %T", a, b))
 }
@@ -194,6 +377,15 @@ func Add(a, b interface{}) interface{} {
 			return y.Add(x)
 		}
 	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Add(y)
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Add(x, y)
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Add(x, y)
+	}
 	panic(fmt.Sprintf("invalid operation: %T + %T", a, b))
 }
 
@@ -205,6 +397,20 @@ func Subtract(a, b interface{}) interface{} {
 		case time.Time:
 			return x.Sub(y)
 		}
+	case Set:
+		switch y := b.(type) {
+		case Set:
+			return x.Difference(y)
+		}
+	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Sub(y)
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Sub(x, y)
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Subtract(x, y)
 	}
 	panic(fmt.Sprintf("invalid operation: %T - %T", a, b))
 }
@@ -213,20 +419,81 @@ func Multiply(a, b interface{}) interface{} {
 	switch x := a.(type) {
 	{{ cases "*" }}
 	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Mul(y)
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Mul(x, y)
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Multiply(x, y)
+	}
 	panic(fmt.Sprintf("invalid operation: %T * %T", a, b))
 }
 
-func Divide(a, b interface{}) float64 {
+func Divide(a, b interface{}) interface{} {
 	switch x := a.(type) {
 	{{ cases "/" }}
 	}
+	if x, y, ok := toDecimals(a, b); ok {
+		return x.Quo(y)
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return bigQuo(x, y)
+	}
+	if x, y, ok := bigToFloats(a, b); ok {
+		return Divide(x, y)
+	}
 	panic(fmt.Sprintf("invalid operation: %T / %T", a, b))
 }
 
-func Modulo(a, b interface{}) int {
+// DivideInt divides integers with an integer result, truncated toward zero.
+// Other numbers are divided as by Divide.
+func DivideInt(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	{{ cases_int_only "/" }}
+	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Quo(x, y)
+	}
+	return Divide(a, b)
+}
+
+func AddChecked(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	{{ cases_checked "+" "add" }}
+	}
+	return Add(a, b)
+}
+
+func SubtractChecked(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	{{ cases_checked "-" "subtract" }}
+	}
+	return Subtract(a, b)
+}
+
+func MultiplyChecked(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	{{ cases_checked "*" "multiply" }}
+	}
+	return Multiply(a, b)
+}
+
+func DivideIntChecked(a, b interface{}) interface{} {
+	switch x := a.(type) {
+	{{ cases_checked "/" "divide" }}
+	}
+	return DivideInt(a, b)
+}
+
+func Modulo(a, b interface{}) interface{} {
 	switch x := a.(type) {
 	{{ cases_int_only "%" }}
 	}
+	if x, y, ok := toBigInts(a, b); ok {
+		return new(big.Int).Rem(x, y)
+	}
 	panic(fmt.Sprintf("invalid operation: %T %% %T", a, b))
 }
 `
@@ -0,0 +1,190 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+func ToJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("cannot encode %T to JSON: %v", v, err))
+	}
+	return string(b)
+}
+
+// FromJSON decodes a JSON document into map[string]interface{},
+// []interface{} and scalar values. Integral numbers are decoded as int,
+// other numbers as float64. The second return value is the number of
+// decoded values, used for memory budget accounting.
+func FromJSON(a interface{}) (interface{}, int) {
+	var s string
+	switch x := a.(type) {
+	case string:
+		s = x
+	case []byte:
+		s = string(x)
+	case json.RawMessage:
+		s = string(x)
+	default:
+		panic(fmt.Sprintf("invalid argument for fromJSON (type %T)", a))
+	}
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		panic(fmt.Sprintf("cannot decode JSON: %v", err))
+	}
+	if d.More() {
+		panic("cannot decode JSON: unexpected data after top-level value")
+	}
+	return normalizeJSON(v)
+}
+
+func normalizeJSON(v interface{}) (interface{}, int) {
+	size := 1
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := strconv.Atoi(string(x)); err == nil {
+			return i, size
+		}
+		f, err := x.Float64()
+		if err != nil {
+			panic(fmt.Sprintf("cannot decode JSON number %v", x))
+		}
+		return f, size
+	case []interface{}:
+		for i, e := range x {
+			n := 0
+			x[i], n = normalizeJSON(e)
+			size += n
+		}
+	case map[string]interface{}:
+		for k, e := range x {
+			n := 0
+			x[k], n = normalizeJSON(e)
+			size += n
+		}
+	}
+	return v, size
+}
+
+// JSONPath is a parsed path like $.a.b[0] or $['a'].b[-1].
+type JSONPath struct {
+	Source string
+	Steps  []interface{} // string for keys, int for indexes
+}
+
+func (p *JSONPath) String() string {
+	return p.Source
+}
+
+func ParseJSONPath(s string) (*JSONPath, error) {
+	p := &JSONPath{Source: s}
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with $", s)
+	}
+	rest := s[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("invalid JSON path %q: empty key", s)
+			}
+			for _, r := range key {
+				if !isJSONKeyRune(r) {
+					return nil, fmt.Errorf("invalid JSON path %q: unexpected %q in key %q", s, r, key)
+				}
+			}
+			p.Steps = append(p.Steps, key)
+			rest = rest[end+1:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSON path %q: unclosed bracket", s)
+			}
+			inner := rest[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				p.Steps = append(p.Steps, inner[1:len(inner)-1])
+			} else {
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid JSON path %q: bad index %q", s, inner)
+				}
+				p.Steps = append(p.Steps, i)
+			}
+			rest = rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q: unexpected %q", s, rest[0])
+		}
+	}
+	return p, nil
+}
+
+// isJSONKeyRune reports whether r can be used in a key after a dot.
+// Other keys are written in brackets, like $['a b'].
+func isJSONKeyRune(r rune) bool {
+	return r == '_' || r == '-' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Find returns the value located by the path, or nil if there is no such value.
+func (p *JSONPath) Find(doc interface{}) interface{} {
+	v := doc
+	for _, step := range p.Steps {
+		switch s := step.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			v = m[s]
+		case int:
+			a, ok := v.([]interface{})
+			if !ok {
+				return nil
+			}
+			if s < 0 {
+				s = len(a) + s
+			}
+			if s < 0 || s >= len(a) {
+				return nil
+			}
+			v = a[s]
+		}
+	}
+	return v
+}
+
+// QueryJSON applies path (a string or *JSONPath) to doc. If doc is a string,
+// it is decoded first, and the number of decoded values is returned as the size.
+func QueryJSON(doc, path interface{}) (interface{}, int) {
+	var p *JSONPath
+	switch x := path.(type) {
+	case *JSONPath:
+		p = x
+	case string:
+		var err error
+		p, err = ParseJSONPath(x)
+		if err != nil {
+			panic(err)
+		}
+	default:
+		panic(fmt.Sprintf("invalid argument for jsonPath (type %T)", path))
+	}
+	size := 0
+	switch doc.(type) {
+	case string, []byte, json.RawMessage:
+		doc, size = FromJSON(doc)
+	}
+	return p.Find(doc), size
+}
@@ -0,0 +1,122 @@
+package runtime_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+		size  int
+	}{
+		{`42`, 42, 1},
+		{`1.5`, 1.5, 1},
+		{`1e3`, 1000.0, 1},
+		{`"a"`, "a", 1},
+		{`null`, nil, 1},
+		{`[1, 2.5, "b"]`, []interface{}{1, 2.5, "b"}, 4},
+		{`{"a": {"b": [true]}}`, map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{true}}}, 4},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		got, size := runtime.FromJSON(tt.input)
+		is.Msg(tt.input).Equal(tt.want, got)
+		is.Msg(tt.input).Equal(tt.size, size)
+	}
+}
+
+func TestFromJSON_invalid(t *testing.T) {
+	tests := []struct {
+		input interface{}
+		err   string
+	}{
+		{`{"a": 1`, "cannot decode JSON: unexpected EOF"},
+		{`1 2`, "cannot decode JSON: unexpected data after top-level value"},
+		{42, "invalid argument for fromJSON (type int)"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%v", tt.input).Equal(tt.err, recovered(func() { runtime.FromJSON(tt.input) }))
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	is := is.New(t)
+	is.Equal(`{"a":[1,2]}`, runtime.ToJSON(map[string]interface{}{"a": []int{1, 2}}))
+	is.Equal(`1.50`, runtime.ToJSON(runtime.NewDecimal(150, 2)))
+}
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		steps string
+		err   string
+	}{
+		{"$", "[]", ""},
+		{"$.a.b[0]", "[a b 0]", ""},
+		{"$['a'].b[-1]", "[a b -1]", ""},
+		{`$["a.b"]`, "[a.b]", ""},
+		{"$.first_name.x-y.é1", "[first_name x-y é1]", ""},
+		{"a.b", "", `invalid JSON path "a.b": must start with $`},
+		{"$..a", "", `invalid JSON path "$..a": empty key`},
+		{"$[x", "", `invalid JSON path "$[x": unclosed bracket`},
+		{"$[x]", "", `invalid JSON path "$[x]": bad index "x"`},
+		{"$a", "", `invalid JSON path "$a": unexpected 'a'`},
+		{"$.a]", "", `invalid JSON path "$.a]": unexpected ']' in key "a]"`},
+		{"$.a b", "", `invalid JSON path "$.a b": unexpected ' ' in key "a b"`},
+		{"$.a'", "", `invalid JSON path "$.a'": unexpected '\'' in key "a'"`},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		p, err := runtime.ParseJSONPath(tt.path)
+		if tt.err != "" {
+			is.Msg(tt.path).ErrMsg(err, tt.err)
+			continue
+		}
+		is.Msg(tt.path).NotErr(err)
+		is.Msg(tt.path).Equal(tt.steps, fmt.Sprint(p.Steps))
+		is.Msg(tt.path).Equal(tt.path, p.String())
+	}
+}
+
+func TestJSONPath_Find(t *testing.T) {
+	doc, _ := runtime.FromJSON(`{"user": {"name": "Bob", "tags": ["a", "b"]}}`)
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"$.user.name", "Bob"},
+		{"$.user.tags[0]", "a"},
+		{"$.user.tags[-1]", "b"},
+		{"$.user.tags[2]", nil},
+		{"$.user.tags[-3]", nil},
+		{"$.user.name.first", nil},
+		{"$.user[0]", nil},
+		{"$.missing", nil},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		p, err := runtime.ParseJSONPath(tt.path)
+		is.Msg(tt.path).NotErr(err)
+		is.Msg(tt.path).Equal(tt.want, p.Find(doc))
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	is := is.New(t)
+
+	got, size := runtime.QueryJSON(`{"a": [1, 2]}`, "$.a[1]")
+	is.Equal(2, got)
+	is.Equal(4, size)
+
+	p, err := runtime.ParseJSONPath("$.a")
+	is.NotErr(err)
+	got, size = runtime.QueryJSON(map[string]interface{}{"a": 1.5}, p)
+	is.Equal(1.5, got)
+	is.Equal(0, size)
+}
@@ -0,0 +1,115 @@
+package runtime
+
+import (
+	"fmt"
+	"net"
+)
+
+// ToIP converts a string or net.IP to net.IP. IPv4 addresses are always
+// returned in their 4-byte form, so equal addresses compare as equal.
+func ToIP(v interface{}) net.IP {
+	var ip net.IP
+	switch x := v.(type) {
+	case net.IP:
+		ip = x
+	case string:
+		ip = net.ParseIP(x)
+		if ip == nil {
+			panic(fmt.Sprintf("invalid IP address %q", x))
+		}
+	default:
+		panic(fmt.Sprintf("invalid argument for ip (type %T)", v))
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+// parseIP is like ToIP, but reports whether v is an IP address instead
+// of panicking.
+func parseIP(v interface{}) (net.IP, bool) {
+	switch x := v.(type) {
+	case net.IP:
+		return x, true
+	case string:
+		ip := net.ParseIP(x)
+		return ip, ip != nil
+	}
+	return nil, false
+}
+
+// ToCIDR converts a string in CIDR notation or *net.IPNet to *net.IPNet.
+func ToCIDR(v interface{}) *net.IPNet {
+	switch x := v.(type) {
+	case *net.IPNet:
+		return x
+	case string:
+		_, n, err := net.ParseCIDR(x)
+		if err != nil {
+			panic(err)
+		}
+		return n
+	}
+	panic(fmt.Sprintf("invalid argument for cidr (type %T)", v))
+}
+
+// IPTrie is a binary prefix trie of networks, used for fast
+// `ip in [cidr(...), ...]` lookups of constant lists.
+type IPTrie struct {
+	v4 ipTrieNode
+	v6 ipTrieNode
+}
+
+type ipTrieNode struct {
+	children [2]*ipTrieNode
+	terminal bool
+}
+
+func NewIPTrie(nets []*net.IPNet) *IPTrie {
+	t := &IPTrie{}
+	for _, n := range nets {
+		t.Insert(n)
+	}
+	return t
+}
+
+func (t *IPTrie) Insert(n *net.IPNet) {
+	ones, _ := n.Mask.Size()
+	ip := n.IP
+	node := &t.v6
+	if ip4 := ip.To4(); ip4 != nil && len(n.Mask) == net.IPv4len {
+		ip = ip4
+		node = &t.v4
+	}
+	for i := 0; i < ones; i++ {
+		bit := ip[i/8] >> (7 - uint(i%8)) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &ipTrieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.terminal = true
+}
+
+func (t *IPTrie) Contains(ip net.IP) bool {
+	node := &t.v6
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		node = &t.v4
+	}
+	for i := 0; i < len(ip)*8; i++ {
+		if node.terminal {
+			return true
+		}
+		node = node.children[ip[i/8]>>(7-uint(i%8))&1]
+		if node == nil {
+			return false
+		}
+	}
+	return node.terminal
+}
+
+func (t *IPTrie) String() string {
+	return "IPTrie"
+}
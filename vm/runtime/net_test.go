@@ -0,0 +1,92 @@
+package runtime_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func TestToIP(t *testing.T) {
+	is := is.New(t)
+	is.Equal(net.IP{10, 0, 0, 1}, runtime.ToIP("10.0.0.1"))
+	is.Equal(net.IP{10, 0, 0, 1}, runtime.ToIP(net.ParseIP("10.0.0.1")))
+	is.Equal(net.ParseIP("2001:db8::1"), runtime.ToIP("2001:db8::1"))
+}
+
+func TestToCIDR(t *testing.T) {
+	is := is.New(t)
+	n := runtime.ToCIDR("192.168.1.10/16")
+	is.Equal("192.168.0.0/16", n.String())
+	is.True(runtime.ToCIDR(n) == n)
+}
+
+func TestNet_invalid(t *testing.T) {
+	tests := []struct {
+		fn  func()
+		err string
+	}{
+		{func() { runtime.ToIP("300.0.0.1") }, `invalid IP address "300.0.0.1"`},
+		{func() { runtime.ToIP(1) }, "invalid argument for ip (type int)"},
+		{func() { runtime.ToCIDR("10.0.0.0/33") }, "invalid CIDR address: 10.0.0.0/33"},
+		{func() { runtime.ToCIDR(nil) }, "invalid argument for cidr (type <nil>)"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.err).Equal(tt.err, recovered(tt.fn))
+	}
+}
+
+func TestIPTrie_Contains(t *testing.T) {
+	trie := runtime.NewIPTrie([]*net.IPNet{
+		runtime.ToCIDR("10.0.0.0/8"),
+		runtime.ToCIDR("192.168.1.0/24"),
+		runtime.ToCIDR("2001:db8::/32"),
+	})
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"11.0.0.1", false},
+		{"192.168.1.255", true},
+		{"192.168.2.1", false},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+		{"::ffff:10.0.0.1", true},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.ip).Equal(tt.want, trie.Contains(runtime.ToIP(tt.ip)))
+	}
+
+	is := is.New(t)
+	all := runtime.NewIPTrie([]*net.IPNet{runtime.ToCIDR("0.0.0.0/0")})
+	is.True(all.Contains(runtime.ToIP("0.0.0.1")))
+	is.True(!all.Contains(runtime.ToIP("::1")))
+}
+
+func TestIn_networks(t *testing.T) {
+	trie := runtime.NewIPTrie([]*net.IPNet{runtime.ToCIDR("10.0.0.0/8")})
+	mixed := []interface{}{runtime.ToCIDR("10.0.0.0/8"), "foo"}
+	tests := []struct {
+		needle interface{}
+		array  interface{}
+		want   bool
+	}{
+		{"10.0.0.1", mixed, true},
+		{net.ParseIP("10.0.0.1"), mixed, true},
+		{"11.0.0.1", mixed, false},
+		{"foo", mixed, true},
+		{"bar", mixed, false},
+		{42, mixed, false},
+		{"10.0.0.1", trie, true},
+		{"foo", trie, false},
+		{nil, trie, false},
+	}
+	for _, tt := range tests {
+		is := is.New(t).Msg("%v in %v", tt.needle, tt.array)
+		is.Equal(tt.want, runtime.In(tt.needle, tt.array))
+	}
+}
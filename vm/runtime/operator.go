@@ -0,0 +1,68 @@
+package runtime
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// OperatorMethod is a method of the left operand which implements a binary
+// operator for its type, like Add for + or Compare for <.
+type OperatorMethod struct {
+	Operator string
+	Name     string
+	Index    int
+}
+
+func (m *OperatorMethod) String() string {
+	return fmt.Sprintf("%v (%v)", m.Operator, m.Name)
+}
+
+// Call calls the method of a with b. Results of Equal and Compare methods
+// are converted to the result of the operator. Nil pointers are compared
+// by == and != without calling the method, as nil is only equal to nil.
+func (m *OperatorMethod) Call(a, b interface{}) interface{} {
+	if m.Operator == "==" || m.Operator == "!=" {
+		if x, y := isNilPointer(a), isNilPointer(b); x || y {
+			return (x && y) != (m.Operator == "!=")
+		}
+	}
+	method := reflect.ValueOf(a).Method(m.Index)
+	in := method.Type().In(0)
+	arg := reflect.ValueOf(b)
+	switch {
+	case b == nil:
+		arg = reflect.Zero(in)
+	case arg.Type() != in && isNumberKind(arg.Kind()) && isNumberKind(in.Kind()):
+		arg = arg.Convert(in)
+	}
+	out := method.Call([]reflect.Value{arg})[0]
+	switch m.Name {
+	case "Equal":
+		return out.Bool() != (m.Operator == "!=")
+	case "Compare":
+		c := out.Int()
+		switch m.Operator {
+		case "==":
+			return c == 0
+		case "!=":
+			return c != 0
+		case "<":
+			return c < 0
+		case ">":
+			return c > 0
+		case "<=":
+			return c <= 0
+		case ">=":
+			return c >= 0
+		}
+	}
+	return out.Interface()
+}
+
+func isNilPointer(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	r := reflect.ValueOf(v)
+	return r.Kind() == reflect.Ptr && r.IsNil()
+}
@@ -0,0 +1,61 @@
+package runtime_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+type node struct{ id int }
+
+func (n *node) Equal(o *node) bool  { return n.id == o.id }
+func (n *node) Compare(o *node) int { return n.id - o.id }
+
+func method(op, name string) *runtime.OperatorMethod {
+	m, _ := reflect.TypeOf(&node{}).MethodByName(name)
+	return &runtime.OperatorMethod{Operator: op, Name: name, Index: m.Index}
+}
+
+func TestOperatorMethod_Call(t *testing.T) {
+	a, b := &node{1}, &node{2}
+	tests := []struct {
+		op, name string
+		a, b     interface{}
+		want     interface{}
+	}{
+		{"==", "Equal", a, &node{1}, true},
+		{"!=", "Equal", a, b, true},
+		{"==", "Compare", a, b, false},
+		{"<", "Compare", a, b, true},
+		{">=", "Compare", a, b, false},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.op, tt.name).Equal(tt.want, method(tt.op, tt.name).Call(tt.a, tt.b))
+	}
+}
+
+func TestOperatorMethod_Call_nil(t *testing.T) {
+	var null *node
+	tests := []struct {
+		op   string
+		a, b interface{}
+		want bool
+	}{
+		{"==", null, &node{1}, false},
+		{"==", &node{1}, null, false},
+		{"==", &node{1}, nil, false},
+		{"==", null, null, true},
+		{"==", null, nil, true},
+		{"!=", null, &node{1}, true},
+		{"!=", null, null, false},
+	}
+	for _, name := range []string{"Equal", "Compare"} {
+		for _, tt := range tests {
+			is := is.New(t)
+			is.Msg(tt.op, name, tt.a, tt.b).Equal(tt.want, method(tt.op, name).Call(tt.a, tt.b))
+		}
+	}
+}
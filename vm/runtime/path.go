@@ -0,0 +1,248 @@
+package runtime
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Path is a parsed path of the get() and has() builtins, like "a.b[2].c"
+// or "a['key with dots'].b".
+type Path struct {
+	Source string
+	Steps  []PathStep
+}
+
+type PathStep struct {
+	Key     string
+	Index   int
+	IsIndex bool
+	// Struct and Field are set by Resolve if the step is known at compile
+	// time to access a field of Struct. Other types are looked up by name.
+	Struct reflect.Type
+	Field  []int
+}
+
+func (p *Path) String() string {
+	return p.Source
+}
+
+func ParsePath(s string) (*Path, error) {
+	p := &Path{Source: s}
+	rest := s
+	first := true
+	for len(rest) > 0 {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid path %q: unclosed bracket", s)
+			}
+			inner := rest[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				p.Steps = append(p.Steps, PathStep{Key: inner[1 : len(inner)-1]})
+			} else {
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: bad index %q", s, inner)
+				}
+				p.Steps = append(p.Steps, PathStep{Index: i, IsIndex: true})
+			}
+			rest = rest[end+1:]
+
+		case rest[0] == '.' || first:
+			if rest[0] == '.' {
+				rest = rest[1:]
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q: empty key", s)
+			}
+			p.Steps = append(p.Steps, PathStep{Key: rest[:end]})
+			rest = rest[end:]
+
+		default:
+			return nil, fmt.Errorf("invalid path %q: unexpected %q", s, rest[0])
+		}
+		first = false
+	}
+	if len(p.Steps) == 0 {
+		return nil, fmt.Errorf("invalid path %q: empty path", s)
+	}
+	return p, nil
+}
+
+// Resolve precomputes field indexes of steps accessing struct fields,
+// starting from a value of type t. Resolving stops at the first step
+// which type is not known at compile time.
+func (p *Path) Resolve(t reflect.Type) {
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil {
+			return
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			if step.IsIndex {
+				return
+			}
+			field, ok := fieldByName(t, step.Key)
+			if !ok {
+				return
+			}
+			step.Struct = t
+			step.Field = field.Index
+			t = field.Type
+		case reflect.Map, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		default:
+			return
+		}
+	}
+}
+
+// fieldByName finds a field by its name in the environment: the `expr` tag
+// if present, otherwise the Go name, as in conf.FieldName.
+func fieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	return t.FieldByNameFunc(func(fieldName string) bool {
+		field, _ := t.FieldByName(fieldName)
+		if tag := field.Tag.Get("expr"); tag != "" {
+			return tag == name
+		}
+		return fieldName == name
+	})
+}
+
+// Lookup returns the value located by the path and whether it exists.
+// It never panics.
+func (p *Path) Lookup(from interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(from)
+	for _, step := range p.Steps {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			if step.IsIndex {
+				return nil, false
+			}
+			index := step.Field
+			if step.Struct != v.Type() {
+				field, ok := fieldByName(v.Type(), step.Key)
+				if !ok {
+					return nil, false
+				}
+				index = field.Index
+			}
+			var ok bool
+			if v, ok = fieldByIndexSafe(v, index); !ok {
+				return nil, false
+			}
+
+		case reflect.Map:
+			key, ok := mapKey(v.Type().Key(), step)
+			if !ok {
+				return nil, false
+			}
+			v = v.MapIndex(key)
+			if !v.IsValid() {
+				return nil, false
+			}
+
+		case reflect.Slice, reflect.Array, reflect.String:
+			if !step.IsIndex {
+				return nil, false
+			}
+			i := step.Index
+			if i < 0 {
+				i += v.Len()
+			}
+			if i < 0 || i >= v.Len() {
+				return nil, false
+			}
+			v = v.Index(i)
+
+		default:
+			return nil, false
+		}
+	}
+	if !v.IsValid() || !v.CanInterface() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+func fieldByIndexSafe(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, v.CanInterface()
+}
+
+func mapKey(t reflect.Type, step PathStep) (reflect.Value, bool) {
+	var key reflect.Value
+	if step.IsIndex {
+		key = reflect.ValueOf(step.Index)
+	} else {
+		key = reflect.ValueOf(step.Key)
+	}
+	if key.Type().AssignableTo(t) {
+		return key, true
+	}
+	if key.Type().ConvertibleTo(t) && key.Kind() == t.Kind() {
+		return key.Convert(t), true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if step.IsIndex {
+			return key.Convert(t), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func toPath(path interface{}, fn string) *Path {
+	switch x := path.(type) {
+	case *Path:
+		return x
+	case string:
+		p, err := ParsePath(x)
+		if err != nil {
+			panic(err)
+		}
+		return p
+	}
+	panic(fmt.Sprintf("invalid path for %v (type %T)", fn, path))
+}
+
+// Get returns the value located by the path, or def if it doesn't exist
+// or is nil.
+func Get(from, path, def interface{}) interface{} {
+	v, ok := toPath(path, "get").Lookup(from)
+	if !ok || IsNil(v) {
+		return def
+	}
+	return v
+}
+
+// Has reports whether the value located by the path exists.
+func Has(from, path interface{}) bool {
+	_, ok := toPath(path, "has").Lookup(from)
+	return ok
+}
@@ -0,0 +1,143 @@
+package runtime_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+type address struct {
+	City string `expr:"city"`
+	Zip  *string
+}
+
+type user struct {
+	Name      string
+	Addresses []address `expr:"addresses"`
+	Manager   *user
+	Meta      map[string]interface{}
+	Scores    map[int]float64
+	secret    string
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []runtime.PathStep
+		err  string
+	}{
+		{"a", []runtime.PathStep{{Key: "a"}}, ""},
+		{"a.b[2].c", []runtime.PathStep{{Key: "a"}, {Key: "b"}, {Index: 2, IsIndex: true}, {Key: "c"}}, ""},
+		{"[-1]", []runtime.PathStep{{Index: -1, IsIndex: true}}, ""},
+		{"Meta['a.b']", []runtime.PathStep{{Key: "Meta"}, {Key: "a.b"}}, ""},
+		{"", nil, `invalid path "": empty path`},
+		{"a..b", nil, `invalid path "a..b": empty key`},
+		{"a[x]", nil, `invalid path "a[x]": bad index "x"`},
+		{"a[0", nil, `invalid path "a[0": unclosed bracket`},
+		{"a[0]b", nil, `invalid path "a[0]b": unexpected 'b'`},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		p, err := runtime.ParsePath(tt.path)
+		if tt.err != "" {
+			is.Msg(tt.path).ErrMsg(err, tt.err)
+			continue
+		}
+		is.Msg(tt.path).NotErr(err)
+		is.Msg(tt.path).True(reflect.DeepEqual(tt.want, p.Steps))
+		is.Msg(tt.path).Equal(tt.path, p.String())
+	}
+}
+
+func TestPath_Resolve(t *testing.T) {
+	is := is.New(t)
+	p, err := runtime.ParsePath("addresses[0].city")
+	is.NotErr(err)
+	p.Resolve(reflect.TypeOf(&user{}))
+	is.Equal([]int{1}, p.Steps[0].Field)
+	is.Equal([]int{0}, p.Steps[2].Field)
+	is.True(p.Steps[2].Struct == reflect.TypeOf(address{}))
+
+	// Resolving stops at values of unknown type.
+	p, err = runtime.ParsePath("Meta.a.b")
+	is.NotErr(err)
+	p.Resolve(reflect.TypeOf(user{}))
+	is.Equal([]int{3}, p.Steps[0].Field)
+	is.True(p.Steps[1].Field == nil)
+	is.True(p.Steps[2].Field == nil)
+}
+
+func TestGet(t *testing.T) {
+	dyn := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{1, 2, map[string]interface{}{"c": "deep"}},
+		},
+	}
+	u := user{
+		Name:      "ann",
+		Addresses: []address{{City: "Oslo"}, {City: "Rome"}},
+		Meta:      map[string]interface{}{"tags": []interface{}{"a", "b"}, "nil": nil, "a.b": 1},
+		Scores:    map[int]float64{2: 0.5},
+		secret:    "x",
+	}
+	var nobody *user
+
+	tests := []struct {
+		from interface{}
+		path string
+		want interface{}
+	}{
+		{dyn, "a.b[2].c", "deep"},
+		{dyn, "a.b[-1].c", "deep"},
+		{dyn, "a.b[5].c", "none"},
+		{dyn, "a.x.y.z", "none"},
+		{dyn, "a.b.c", "none"},
+		{dyn, "a.b[0].c", "none"},
+		{u, "addresses[1].city", "Rome"},
+		{u, "Addresses[1].City", "none"},
+		{u, "addresses[0].Zip", "none"},
+		{u, "Manager.Name", "none"},
+		{u, "Meta.tags[1]", "b"},
+		{u, "Meta['a.b']", 1},
+		{u, "Meta.nil", "none"},
+		{u, "Scores[2]", 0.5},
+		{u, "Scores[3]", "none"},
+		{u, "secret", "none"},
+		{&u, "Name", "ann"},
+		{nobody, "Name", "none"},
+		{nil, "Name", "none"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.path).Equal(tt.want, runtime.Get(tt.from, tt.path, "none"))
+	}
+}
+
+func TestHas(t *testing.T) {
+	u := &user{
+		Addresses: []address{{City: "Oslo"}},
+		Meta:      map[string]interface{}{"nil": nil},
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"addresses[0].city", true},
+		{"addresses[1].city", false},
+		{"Meta.nil", true},
+		{"Meta.missing", false},
+		{"Manager", true},
+		{"Manager.Name", false},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.path).Equal(tt.want, runtime.Has(u, tt.path))
+	}
+
+	is := is.New(t)
+	is.Equal(`invalid path "a[x]": bad index "x"`, recovered(func() { runtime.Has(u, "a[x]") }))
+	is.Equal("invalid path for has (type int)", recovered(func() { runtime.Has(u, 1) }))
+}
@@ -0,0 +1,60 @@
+package runtime
+
+import (
+	"fmt"
+	"regexp"
+)
+
+func toRegexp(re interface{}) *regexp.Regexp {
+	switch x := re.(type) {
+	case *regexp.Regexp:
+		return x
+	case string:
+		r, err := regexp.Compile(x)
+		if err != nil {
+			panic(err)
+		}
+		return r
+	}
+	panic(fmt.Sprintf("invalid regular expression (type %T)", re))
+}
+
+func toString(s interface{}, fn string) string {
+	str, ok := s.(string)
+	if !ok {
+		panic(fmt.Sprintf("invalid argument for %v (type %T)", fn, s))
+	}
+	return str
+}
+
+func FindAll(s, re interface{}) []string {
+	out := toRegexp(re).FindAllString(toString(s, "findAll"), -1)
+	if out == nil {
+		return []string{}
+	}
+	return out
+}
+
+// Capture returns named groups of the first match, or nil if there is no match.
+func Capture(s, re interface{}) map[string]interface{} {
+	r := toRegexp(re)
+	match := r.FindStringSubmatch(toString(s, "capture"))
+	if match == nil {
+		return nil
+	}
+	out := make(map[string]interface{})
+	for i, name := range r.SubexpNames() {
+		if i > 0 && name != "" {
+			out[name] = match[i]
+		}
+	}
+	return out
+}
+
+func ReplaceRegex(s, re, repl interface{}) string {
+	return toRegexp(re).ReplaceAllString(toString(s, "replaceRegex"), toString(repl, "replaceRegex"))
+}
+
+func Split(s, re interface{}) []string {
+	return toRegexp(re).Split(toString(s, "split"), -1)
+}
@@ -0,0 +1,53 @@
+package runtime_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func TestFindAll(t *testing.T) {
+	is := is.New(t)
+	is.Equal([]string{"2023", "2024"}, runtime.FindAll("2023-01, 2024-12", `\d{4}`))
+	is.Equal([]string{"2023", "2024"}, runtime.FindAll("2023-01, 2024-12", regexp.MustCompile(`\d{4}`)))
+	is.Equal([]string{}, runtime.FindAll("abc", `\d`))
+}
+
+func TestCapture(t *testing.T) {
+	is := is.New(t)
+	pattern := `(?P<year>\d{4})-(\d{2})-(?P<day>\d{2})`
+	is.Equal(map[string]interface{}{"year": "2023", "day": "15"}, runtime.Capture("on 2023-01-15", pattern))
+	is.Equal(map[string]interface{}{}, runtime.Capture("2023-01-15", `\d+`))
+	is.True(runtime.Capture("none", pattern) == nil)
+}
+
+func TestReplaceRegex(t *testing.T) {
+	is := is.New(t)
+	is.Equal("2023/01-15", runtime.ReplaceRegex("2023-01-15", `(\d{4})-`, "$1/"))
+	is.Equal("a-b-c", runtime.ReplaceRegex("a1b22c", `[0-9]+`, "-"))
+}
+
+func TestSplit(t *testing.T) {
+	is := is.New(t)
+	is.Equal([]string{"a", "b", "c"}, runtime.Split("a1b22c", `[0-9]+`))
+	is.Equal([]string{"a", "b"}, runtime.Split("a, b", `,\s*`))
+	is.Equal([]string{"abc"}, runtime.Split("abc", `,`))
+}
+
+func TestRegexp_invalid(t *testing.T) {
+	tests := []struct {
+		fn  func()
+		err string
+	}{
+		{func() { runtime.FindAll("abc", "[a-z") }, "error parsing regexp: missing closing ]: `[a-z`"},
+		{func() { runtime.Split("abc", 42) }, "invalid regular expression (type int)"},
+		{func() { runtime.Capture(42, "a") }, "invalid argument for capture (type int)"},
+		{func() { runtime.ReplaceRegex("a", "a", nil) }, "invalid argument for replaceRegex (type <nil>)"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.err).Equal(tt.err, recovered(tt.fn))
+	}
+}
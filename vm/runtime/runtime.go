@@ -5,8 +5,11 @@ package runtime
 import (
 	"fmt"
 	"math"
+	"math/big"
+	"net"
 	"reflect"
 	"strconv"
+	"time"
 )
 
 func Fetch(from, i interface{}) interface{} {
@@ -17,8 +20,8 @@ func Fetch(from, i interface{}) interface{} {
 	}
 
 	// Methods can be defined on any type.
-	if v.NumMethod() > 0 {
-		method := v.MethodByName(i.(string))
+	if name, ok := i.(string); ok && v.NumMethod() > 0 {
+		method := v.MethodByName(name)
 		if method.IsValid() {
 			return method.Interface()
 		}
@@ -32,6 +35,28 @@ func Fetch(from, i interface{}) interface{} {
 		kind = v.Kind()
 	}
 
+	// Declared fields of custom collections take precedence over their
+	// dynamic fields and elements.
+	if name, ok := i.(string); ok && kind == reflect.Struct {
+		if value := structField(v, name); value.IsValid() {
+			return value.Interface()
+		}
+	}
+	if f, ok := from.(Fetcher); ok {
+		value, _ := f.Fetch(i)
+		return value
+	}
+	if it, ok := from.(Iterable); ok && isInteger(i) && !isIndexable(kind) {
+		index := ToInt(i)
+		if index < 0 {
+			index = it.Len() + index
+		}
+		if index < 0 || index >= it.Len() {
+			panic(fmt.Sprintf("index out of range: %v (length %v)", i, it.Len()))
+		}
+		return it.Index(index)
+	}
+
 	// TODO: We can create separate opcodes for each of the cases below to make
 	// the little bit faster.
 	switch kind {
@@ -55,14 +80,7 @@ func Fetch(from, i interface{}) interface{} {
 		}
 
 	case reflect.Struct:
-		fieldName := i.(string)
-		value := v.FieldByNameFunc(func(name string) bool {
-			field, _ := v.Type().FieldByName(name)
-			if field.Tag.Get("expr") == fieldName {
-				return true
-			}
-			return name == fieldName
-		})
+		value := structField(v, i.(string))
 		if value.IsValid() {
 			return value.Interface()
 		}
@@ -70,6 +88,27 @@ func Fetch(from, i interface{}) interface{} {
 	panic(fmt.Sprintf("cannot fetch %v from %T", i, from))
 }
 
+// isIndexable reports whether values of the kind have builtin [] access.
+func isIndexable(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Array, reflect.Slice, reflect.String, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// structField returns the field of the struct v named, or tagged with,
+// fieldName. The returned value is invalid if there is no such field.
+func structField(v reflect.Value, fieldName string) reflect.Value {
+	return v.FieldByNameFunc(func(name string) bool {
+		field, _ := v.Type().FieldByName(name)
+		if field.Tag.Get("expr") == fieldName {
+			return true
+		}
+		return name == fieldName
+	})
+}
+
 type Field struct {
 	Index []int
 	Path  []string
@@ -78,6 +117,13 @@ type Field struct {
 func FetchField(from interface{}, field *Field) interface{} {
 	v := reflect.ValueOf(from)
 	kind := v.Kind()
+	if kind == reflect.Map {
+		// Objects described by a conf.Schema are maps at run time.
+		for _, name := range field.Path {
+			from = Fetch(from, name)
+		}
+		return from
+	}
 	if kind != reflect.Invalid {
 		if kind == reflect.Ptr {
 			v = reflect.Indirect(v)
@@ -132,6 +178,16 @@ func FetchMethod(from interface{}, method *Method) interface{} {
 	panic(fmt.Sprintf("cannot fetch %v from %T", method.Name, from))
 }
 
+// Invoke calls a lazy value, a func() T or func() (T, error), and returns
+// its result. A non-nil error panics.
+func Invoke(fn interface{}) interface{} {
+	out := reflect.ValueOf(fn).Call(nil)
+	if len(out) == 2 && !out[1].IsNil() {
+		panic(out[1].Interface())
+	}
+	return out[0].Interface()
+}
+
 func Deref(i interface{}) interface{} {
 	if i == nil {
 		return nil
@@ -203,14 +259,51 @@ func In(needle interface{}, array interface{}) bool {
 	if array == nil {
 		return false
 	}
+	switch x := array.(type) {
+	case *net.IPNet:
+		return x.Contains(ToIP(needle))
+	case *IPTrie:
+		// A list of networks only contains addresses.
+		ip, ok := parseIP(needle)
+		return ok && x.Contains(ip)
+	case Set:
+		return x.Has(needle)
+	case *VersionConstraint:
+		return x.Check(ToVersion(needle))
+	case Iterable:
+		return inIterable(needle, x)
+	case Fetcher:
+		_, ok := x.Fetch(needle)
+		return ok
+	case string:
+		if v, ok := needle.(*Version); ok {
+			return toVersionConstraint(x).Check(v)
+		}
+	}
 	v := reflect.ValueOf(array)
 
 	switch v.Kind() {
 
 	case reflect.Array, reflect.Slice:
+		// Networks contain the needle if it is an IP address, which is
+		// parsed at the first network. Other needles are compared to them.
+		var ip net.IP
+		parsed, isIP := false, false
 		for i := 0; i < v.Len(); i++ {
 			value := v.Index(i)
 			if value.IsValid() {
+				if n, ok := value.Interface().(*net.IPNet); ok {
+					if !parsed {
+						ip, isIP = parseIP(needle)
+						parsed = true
+					}
+					if isIP {
+						if n.Contains(ip) {
+							return true
+						}
+						continue
+					}
+				}
 				if Equal(value.Interface(), needle) {
 					return true
 				}
@@ -223,6 +316,12 @@ func In(needle interface{}, array interface{}) bool {
 		if !n.IsValid() {
 			panic(fmt.Sprintf("cannot use %T as index to %T", needle, array))
 		}
+		if key := v.Type().Key(); n.Type() != key && key.Kind() != reflect.Interface {
+			if n.Kind() != key.Kind() {
+				panic(fmt.Sprintf("cannot use %T as index to %T", needle, array))
+			}
+			n = n.Convert(key)
+		}
 		value := v.MapIndex(n)
 		if value.IsValid() {
 			return true
@@ -252,6 +351,9 @@ func In(needle interface{}, array interface{}) bool {
 }
 
 func Len(a interface{}) interface{} {
+	if l, ok := a.(Lener); ok {
+		return l.Len()
+	}
 	v := reflect.ValueOf(a)
 	switch v.Kind() {
 	case reflect.Array, reflect.Slice, reflect.Map, reflect.String:
@@ -287,12 +389,27 @@ func Negate(i interface{}) interface{} {
 		return -v
 	case uint64:
 		return -v
+	case Decimal:
+		return v.Neg()
+	case *big.Int:
+		return new(big.Int).Neg(v)
 	default:
 		panic(fmt.Sprintf("invalid operation: - %T", v))
 	}
 }
 
-func Exponent(a, b interface{}) float64 {
+// Exponent returns a ** b as float64, or as Decimal if a is a decimal, which
+// powers are exact and need an integer exponent.
+func Exponent(a, b interface{}) interface{} {
+	if d, ok := a.(Decimal); ok {
+		if _, ok := b.(*big.Int); ok || !isInteger(b) {
+			panic(fmt.Sprintf("invalid operation: decimal ** %T (decimal powers need an integer exponent)", b))
+		}
+		return d.Pow(ToInt(b))
+	}
+	if _, ok := b.(Decimal); ok {
+		panic(fmt.Sprintf("invalid operation: %T ** decimal (decimal powers need an integer exponent)", a))
+	}
 	return math.Pow(ToFloat64(a), ToFloat64(b))
 }
 
@@ -325,6 +442,9 @@ func ToInt(a interface{}) int {
 	case int64:
 		return int(x)
 	case uint:
+		if uint64(x) > math.MaxInt64 {
+			panic(fmt.Sprintf("cannot convert %v to int (out of range)", x))
+		}
 		return int(x)
 	case uint8:
 		return int(x)
@@ -333,6 +453,9 @@ func ToInt(a interface{}) int {
 	case uint32:
 		return int(x)
 	case uint64:
+		if uint64(x) > math.MaxInt64 {
+			panic(fmt.Sprintf("cannot convert %v to int (out of range)", x))
+		}
 		return int(x)
 	case string:
 		i, err := strconv.Atoi(x)
@@ -340,6 +463,10 @@ func ToInt(a interface{}) int {
 			panic(fmt.Sprintf("invalid operation: int(%s)", x))
 		}
 		return i
+	case Decimal:
+		return x.Int()
+	case *big.Int:
+		return bigToInt(x)
 	default:
 		panic(fmt.Sprintf("invalid operation: int(%T)", x))
 	}
@@ -362,6 +489,9 @@ func ToInt64(a interface{}) int64 {
 	case int64:
 		return x
 	case uint:
+		if uint64(x) > math.MaxInt64 {
+			panic(fmt.Sprintf("cannot convert %v to int64 (out of range)", x))
+		}
 		return int64(x)
 	case uint8:
 		return int64(x)
@@ -370,7 +500,15 @@ func ToInt64(a interface{}) int64 {
 	case uint32:
 		return int64(x)
 	case uint64:
+		if uint64(x) > math.MaxInt64 {
+			panic(fmt.Sprintf("cannot convert %v to int64 (out of range)", x))
+		}
 		return int64(x)
+	case *big.Int:
+		if !x.IsInt64() {
+			panic(fmt.Sprintf("cannot convert %v to int64 (out of range)", x))
+		}
+		return x.Int64()
 	default:
 		panic(fmt.Sprintf("invalid operation: int64(%T)", x))
 	}
@@ -408,6 +546,10 @@ func ToFloat64(a interface{}) float64 {
 			panic(fmt.Sprintf("invalid operation: float(%s)", x))
 		}
 		return f
+	case Decimal:
+		return x.Float64()
+	case *big.Int:
+		return bigFloat(x)
 	default:
 		panic(fmt.Sprintf("invalid operation: float(%T)", x))
 	}
@@ -500,6 +642,93 @@ func Abs(x interface{}) interface{} {
 		} else {
 			return x
 		}
+	case Decimal:
+		return x.(Decimal).Abs()
+	case *big.Int:
+		return new(big.Int).Abs(x.(*big.Int))
 	}
 	panic(fmt.Sprintf("invalid argument for abs (type %T)", x))
 }
+
+// TypeName returns a stable name of the dynamic type of v, as used by
+// the type() builtin and the `is` operator.
+func TypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case time.Time:
+		return "time"
+	case time.Duration:
+		return "duration"
+	case Decimal:
+		return "decimal"
+	case *big.Int:
+		return "bigint"
+	}
+	r := reflect.ValueOf(v)
+	if r.Kind() == reflect.Ptr {
+		if r.IsNil() {
+			return "nil"
+		}
+		return TypeName(r.Elem().Interface())
+	}
+	switch r.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.String:
+		return r.Kind().String()
+	case reflect.Float64:
+		return "float"
+	case reflect.Array, reflect.Slice:
+		return "array"
+	case reflect.Map:
+		return "map"
+	case reflect.Struct:
+		return "struct"
+	case reflect.Func:
+		return "func"
+	}
+	return "unknown"
+}
+
+// exactTypes are types of names, for which the `is` operator tests the
+// exact type of a value, so the value can be used as of the type.
+var exactTypes = map[string]reflect.Type{
+	"bool":     reflect.TypeOf(false),
+	"int":      reflect.TypeOf(0),
+	"int8":     reflect.TypeOf(int8(0)),
+	"int16":    reflect.TypeOf(int16(0)),
+	"int32":    reflect.TypeOf(int32(0)),
+	"int64":    reflect.TypeOf(int64(0)),
+	"uint":     reflect.TypeOf(uint(0)),
+	"uint8":    reflect.TypeOf(uint8(0)),
+	"uint16":   reflect.TypeOf(uint16(0)),
+	"uint32":   reflect.TypeOf(uint32(0)),
+	"uint64":   reflect.TypeOf(uint64(0)),
+	"float32":  reflect.TypeOf(float32(0)),
+	"float":    reflect.TypeOf(float64(0)),
+	"string":   reflect.TypeOf(""),
+	"time":     reflect.TypeOf(time.Time{}),
+	"duration": reflect.TypeOf(time.Duration(0)),
+	"decimal":  reflect.TypeOf(Decimal{}),
+	"bigint":   reflect.TypeOf(&big.Int{}),
+}
+
+// ExactType returns the type tested by `v is name`, if the test is exact.
+// Other names, like array or map, are tested by the kind of the value.
+func ExactType(name string) (reflect.Type, bool) {
+	t, ok := exactTypes[name]
+	return t, ok
+}
+
+// Is implements the `is` operator. Values of named types, like
+// `type MyInt int`, and pointers are not of the exact types, although
+// TypeName reports them by their kind.
+func Is(v interface{}, name string) bool {
+	if t, ok := exactTypes[name]; ok {
+		return reflect.TypeOf(v) == t
+	}
+	return TypeName(v) == name
+}
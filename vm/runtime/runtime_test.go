@@ -0,0 +1,95 @@
+package runtime_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+type myInt int
+
+// recovered returns the message of the panic of fn, or "" if fn doesn't panic.
+func recovered(fn func()) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint(r)
+		}
+	}()
+	fn()
+	return ""
+}
+
+func TestTypeName(t *testing.T) {
+	one := 1
+	var nilPtr *int
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, "nil"},
+		{true, "bool"},
+		{1, "int"},
+		{int32(1), "int32"},
+		{uint8(1), "uint8"},
+		{myInt(1), "int"},
+		{&one, "int"},
+		{nilPtr, "nil"},
+		{float32(1), "float32"},
+		{1.5, "float"},
+		{"a", "string"},
+		{[]int{1}, "array"},
+		{[1]string{"a"}, "array"},
+		{map[string]int{}, "map"},
+		{struct{}{}, "struct"},
+		{func() {}, "func"},
+		{time.Second, "duration"},
+		{time.Time{}, "time"},
+		{runtime.NewDecimal(1, 0), "decimal"},
+		{big.NewInt(1), "bigint"},
+		{make(chan int), "unknown"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%#v", tt.value).Equal(tt.want, runtime.TypeName(tt.value))
+	}
+}
+
+func TestIs(t *testing.T) {
+	one := 1
+	tests := []struct {
+		value interface{}
+		name  string
+		want  bool
+	}{
+		{nil, "nil", true},
+		{1, "int", true},
+		{"1", "int", false},
+		{int64(1), "int", false},
+		{myInt(1), "int", false},
+		{&one, "int", false},
+		{1.5, "float", true},
+		{time.Second, "duration", true},
+		{time.Second, "int64", false},
+		{[]int{1}, "array", true},
+		{map[string]int{}, "map", true},
+		{myInt(1), "map", false},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%#v is %v", tt.value, tt.name).Equal(tt.want, runtime.Is(tt.value, tt.name))
+	}
+}
+
+func TestExactType(t *testing.T) {
+	is := is.New(t)
+	typ, ok := runtime.ExactType("uint16")
+	is.True(ok)
+	is.Equal("uint16", typ.String())
+
+	_, ok = runtime.ExactType("array")
+	is.True(!ok)
+}
@@ -0,0 +1,275 @@
+package runtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version, see https://semver.org.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 []string
+	Source              string
+}
+
+func (v *Version) String() string {
+	return v.Source
+}
+
+// ParseVersion parses versions like 1.2.3, v1.2.3 and 1.2.3-rc.1+build.5.
+// Build metadata is ignored.
+func ParseVersion(s string) (*Version, error) {
+	v, err := parseVersion(s, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: %v", s, err)
+	}
+	return v, nil
+}
+
+// parseVersion parses a version. If partial is true, minor and patch
+// numbers may be omitted, as in constraints like ^2.3.
+func parseVersion(s string, partial bool) (*Version, error) {
+	v := &Version{Source: s}
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.Pre = strings.Split(s[i+1:], ".")
+		for _, p := range v.Pre {
+			if p == "" {
+				return nil, fmt.Errorf("empty pre-release identifier")
+			}
+		}
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 || (!partial && len(parts) != 3) {
+		return nil, fmt.Errorf("expected MAJOR.MINOR.PATCH")
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (len(p) > 1 && p[0] == '0') {
+			return nil, fmt.Errorf("invalid number %q", p)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 according to the semver precedence rules.
+func (v *Version) Compare(o *Version) int {
+	if c := compareInt(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	// A version without pre-release identifiers has higher precedence.
+	switch {
+	case len(v.Pre) == 0 && len(o.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(o.Pre); i++ {
+		if c := comparePre(v.Pre[i], o.Pre[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(v.Pre), len(o.Pre))
+}
+
+func comparePre(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInt(x, y)
+	case errA == nil:
+		return -1 // Numeric identifiers have lower precedence.
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// ToVersion converts a string or *Version to *Version.
+func ToVersion(v interface{}) *Version {
+	switch x := v.(type) {
+	case *Version:
+		return x
+	case string:
+		version, err := ParseVersion(x)
+		if err != nil {
+			panic(err)
+		}
+		return version
+	}
+	panic(fmt.Sprintf("invalid argument for semver (type %T)", v))
+}
+
+// VersionConstraint is a range of versions, like "^2.3", "~1.2.0",
+// ">=1.0.0 <2.0.0" or "1.x || >=3.1.0".
+type VersionConstraint struct {
+	Source string
+	any    [][]versionComparison // disjunction of conjunctions
+}
+
+type versionComparison struct {
+	op      string
+	version *Version
+}
+
+func (c *VersionConstraint) String() string {
+	return c.Source
+}
+
+func ParseVersionConstraint(s string) (*VersionConstraint, error) {
+	c := &VersionConstraint{Source: s}
+	for _, alt := range strings.Split(s, "||") {
+		fields := strings.Fields(strings.Replace(alt, ",", " ", -1))
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q: empty range", s)
+		}
+		var all []versionComparison
+		for _, f := range fields {
+			cmps, err := parseComparison(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %v", s, err)
+			}
+			all = append(all, cmps...)
+		}
+		c.any = append(c.any, all)
+	}
+	return c, nil
+}
+
+func parseComparison(s string) ([]versionComparison, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op = prefix
+			s = s[len(prefix):]
+			break
+		}
+	}
+	s = strings.TrimPrefix(s, "v")
+	// Wildcards: 1.x, 1.2.* and * are ranges like ~1 and ~1.2.
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			if op != "" && op != "=" {
+				return nil, fmt.Errorf("unexpected wildcard in %q", op+s)
+			}
+			if i == 0 {
+				return nil, nil
+			}
+			op = "~"
+			parts = parts[:i]
+			s = strings.Join(parts, ".")
+			break
+		}
+	}
+	v, err := parseVersion(s, true)
+	if err != nil {
+		return nil, err
+	}
+	given := len(parts)
+
+	switch op {
+	case "", "=":
+		if given == 3 {
+			return []versionComparison{{"=", v}}, nil
+		}
+		return tildeRange(v, given), nil
+	case "^":
+		upper := &Version{Pre: minPre}
+		switch {
+		case v.Major > 0 || given == 1:
+			upper.Major = v.Major + 1
+		case v.Minor > 0 || given == 2:
+			upper.Minor = v.Minor + 1
+		default:
+			upper.Patch = v.Patch + 1
+		}
+		return []versionComparison{{">=", v}, {"<", upper}}, nil
+	case "~":
+		return tildeRange(v, given), nil
+	}
+	return []versionComparison{{op, v}}, nil
+}
+
+// minPre is the lowest pre-release, so upper bounds like <3.0.0-0
+// also exclude pre-releases of the next version.
+var minPre = []string{"0"}
+
+// tildeRange returns a range allowing changes of the parts which were not
+// given, or only patch changes if everything up to patch was given.
+func tildeRange(v *Version, given int) []versionComparison {
+	upper := &Version{Major: v.Major + 1, Pre: minPre}
+	if given >= 2 {
+		upper = &Version{Major: v.Major, Minor: v.Minor + 1, Pre: minPre}
+	}
+	return []versionComparison{{">=", v}, {"<", upper}}
+}
+
+// Check reports whether the version satisfies the constraint.
+func (c *VersionConstraint) Check(v *Version) bool {
+	for _, all := range c.any {
+		ok := true
+		for _, cmp := range all {
+			r := v.Compare(cmp.version)
+			switch cmp.op {
+			case "=":
+				ok = r == 0
+			case ">":
+				ok = r > 0
+			case ">=":
+				ok = r >= 0
+			case "<":
+				ok = r < 0
+			case "<=":
+				ok = r <= 0
+			}
+			if !ok {
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func toVersionConstraint(c interface{}) *VersionConstraint {
+	switch x := c.(type) {
+	case *VersionConstraint:
+		return x
+	case string:
+		constraint, err := ParseVersionConstraint(x)
+		if err != nil {
+			panic(err)
+		}
+		return constraint
+	}
+	panic(fmt.Sprintf("invalid version constraint (type %T)", c))
+}
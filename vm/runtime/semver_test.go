@@ -0,0 +1,109 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input string
+		want  runtime.Version
+		err   string
+	}{
+		{"1.2.3", runtime.Version{Major: 1, Minor: 2, Patch: 3}, ""},
+		{"v2.10.0+build.1", runtime.Version{Major: 2, Minor: 10}, ""},
+		{"3.0.0-rc.1", runtime.Version{Major: 3, Pre: []string{"rc", "1"}}, ""},
+		{"2.10", runtime.Version{}, `invalid version "2.10": expected MAJOR.MINOR.PATCH`},
+		{"2.x.0", runtime.Version{}, `invalid version "2.x.0": invalid number "x"`},
+		{"1.02.0", runtime.Version{}, `invalid version "1.02.0": invalid number "02"`},
+		{"1.0.0-", runtime.Version{}, `invalid version "1.0.0-": empty pre-release identifier`},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		v, err := runtime.ParseVersion(tt.input)
+		if tt.err != "" {
+			is.Msg(tt.input).ErrMsg(err, tt.err)
+			continue
+		}
+		is.Msg(tt.input).NotErr(err)
+		is.Msg(tt.input).Equal(tt.want.Major, v.Major)
+		is.Msg(tt.input).Equal(tt.want.Minor, v.Minor)
+		is.Msg(tt.input).Equal(tt.want.Patch, v.Patch)
+		is.Msg(tt.input).Equal(len(tt.want.Pre), len(v.Pre))
+		is.Msg(tt.input).Equal(tt.input, v.String())
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.10.0", "2.9.1", 1},
+		{"2.10.0", "v2.10.0+build.1", 0},
+		{"3.0.0-rc.1", "3.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		a, b := runtime.ToVersion(tt.a), runtime.ToVersion(tt.b)
+		is.Msg(tt.a, tt.b).Equal(tt.want, a.Compare(b))
+		is.Msg(tt.b, tt.a).Equal(-tt.want, b.Compare(a))
+	}
+}
+
+func TestVersionConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"^2.3", "2.10.0", true},
+		{"^2.3", "3.0.0-rc.1", false},
+		{"^2", "2.4.1", true},
+		{"^0.2.3", "0.2.5", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~2.9", "2.10.0", false},
+		{"~2.9.1", "2.9.7", true},
+		{"~1", "1.9.0", true},
+		{"2.x", "2.10.0", true},
+		{"1.2.*", "1.3.0", false},
+		{"*", "0.0.1", true},
+		{"=2.9.1", "2.9.1", true},
+		{"2.9.1", "2.9.2", false},
+		{">=2.0.0 <2.10.0 || >=3.0.0", "2.10.0", false},
+		{">=2.0.0 <2.10.0 || >=3.0.0", "3.1.0", true},
+		{">=2.0.0, <=2.10.0", "2.10.0", true},
+		{">1.0.0", "1.0.0", false},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		c, err := runtime.ParseVersionConstraint(tt.constraint)
+		is.Msg(tt.constraint).NotErr(err)
+		is.Msg(tt.constraint, tt.version).Equal(tt.want, c.Check(runtime.ToVersion(tt.version)))
+	}
+}
+
+func TestParseVersionConstraint_invalid(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"^2.y", `invalid version constraint "^2.y": invalid number "y"`},
+		{">=1.0.0 ||", `invalid version constraint ">=1.0.0 ||": empty range`},
+		{"^2.x", `invalid version constraint "^2.x": unexpected wildcard in "^2.x"`},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		_, err := runtime.ParseVersionConstraint(tt.input)
+		is.Msg(tt.input).ErrMsg(err, tt.err)
+	}
+}
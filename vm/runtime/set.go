@@ -0,0 +1,191 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Set is an unordered collection of unique values created by the set()
+// builtin. Numbers are normalized, so int8(1), 1.0 and 1 are the same element.
+type Set map[interface{}]struct{}
+
+func setKey(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int, int8, int16, int32, int64:
+		return int(ToInt64(x))
+	case uint, uint8, uint16, uint32, uint64:
+		if toUint64(x) > math.MaxInt64 {
+			return decimalKey(ToDecimal(x))
+		}
+		return ToInt(x)
+	case float32:
+		return floatKey(float64(x))
+	case float64:
+		return floatKey(x)
+	case Decimal:
+		return decimalKey(x)
+	case *big.Int:
+		if x.IsInt64() {
+			return int(x.Int64())
+		}
+		return decimalKey(ToDecimal(x))
+	case nil, bool, string:
+		return x
+	}
+	if !reflect.TypeOf(v).Comparable() {
+		panic(fmt.Sprintf("cannot use %T as set element", v))
+	}
+	return v
+}
+
+// floatKey returns integral floats as int, so 1.0 and 1 are the same
+// element, as they are equal with the == operator.
+func floatKey(f float64) interface{} {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int(f)
+	}
+	return f
+}
+
+// decimalKey returns decimals which are exactly a float as the float key,
+// so 1.5d and 1.5 are the same element. Other decimals are keyed by their
+// digits without trailing zeros.
+func decimalKey(d Decimal) interface{} {
+	f := d.Float64()
+	if !math.IsInf(f, 0) && floatToDecimal(f, 64).Cmp(d) == 0 {
+		return floatKey(f)
+	}
+	s := d.String()
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return decimalString(s)
+}
+
+// decimalString is the set key of a decimal which is not exactly a float.
+type decimalString string
+
+// NewSet creates a set from elements of an array or from another set.
+func NewSet(from interface{}) Set {
+	if s, ok := from.(Set); ok {
+		return s
+	}
+	v := reflect.ValueOf(from)
+	if v.Kind() != reflect.Array && v.Kind() != reflect.Slice {
+		panic(fmt.Sprintf("invalid argument for set (type %T)", from))
+	}
+	s := make(Set, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		s[setKey(v.Index(i).Interface())] = struct{}{}
+	}
+	return s
+}
+
+func toSet(v interface{}, op string) Set {
+	s, ok := v.(Set)
+	if !ok {
+		panic(fmt.Sprintf("invalid operation: %v on %T", op, v))
+	}
+	return s
+}
+
+func (s Set) Has(v interface{}) bool {
+	if v != nil && !reflect.TypeOf(v).Comparable() {
+		return false
+	}
+	_, ok := s[setKey(v)]
+	return ok
+}
+
+// Values returns the elements of the set. Numbers and strings are sorted,
+// so the result is stable for printing and comparisons.
+func (s Set) Values() []interface{} {
+	out := make([]interface{}, 0, len(s))
+	for k := range s {
+		if d, ok := k.(decimalString); ok {
+			out = append(out, ToDecimal(string(d)))
+			continue
+		}
+		out = append(out, k)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if reflect.TypeOf(a) != reflect.TypeOf(b) {
+			return fmt.Sprintf("%T", a) < fmt.Sprintf("%T", b)
+		}
+		switch x := a.(type) {
+		case int:
+			return x < b.(int)
+		case float64:
+			return x < b.(float64)
+		case string:
+			return x < b.(string)
+		case Decimal:
+			return x.Cmp(b.(Decimal)) < 0
+		}
+		return false
+	})
+	return out
+}
+
+func (s Set) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Values())
+}
+
+func Union(a, b interface{}) Set {
+	x, y := toSet(a, "|"), toSet(b, "|")
+	out := make(Set, len(x)+len(y))
+	for k := range x {
+		out[k] = struct{}{}
+	}
+	for k := range y {
+		out[k] = struct{}{}
+	}
+	return out
+}
+
+func Intersect(a, b interface{}) Set {
+	x, y := toSet(a, "&"), toSet(b, "&")
+	if len(x) > len(y) {
+		x, y = y, x
+	}
+	out := make(Set)
+	for k := range x {
+		if _, ok := y[k]; ok {
+			out[k] = struct{}{}
+		}
+	}
+	return out
+}
+
+func (s Set) Difference(o Set) Set {
+	out := make(Set)
+	for k := range s {
+		if _, ok := o[k]; !ok {
+			out[k] = struct{}{}
+		}
+	}
+	return out
+}
+
+func SubsetOf(a, b interface{}) bool {
+	x, y := toSet(a, "subsetOf"), toSet(b, "subsetOf")
+	if len(x) > len(y) {
+		return false
+	}
+	for k := range x {
+		if _, ok := y[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func SupersetOf(a, b interface{}) bool {
+	return SubsetOf(b, a)
+}
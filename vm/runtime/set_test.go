@@ -0,0 +1,85 @@
+package runtime_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func TestNewSet(t *testing.T) {
+	is := is.New(t)
+	s := runtime.NewSet([]interface{}{int8(1), 1.0, 1, uint64(2), 2.5, "a", "a", nil, true})
+	is.Equal(6, len(s))
+	is.True(runtime.NewSet(s) != nil)
+	is.Equal(len(s), len(runtime.NewSet(s)))
+	is.Equal(2, len(runtime.NewSet([2]string{"a", "b"})))
+}
+
+func TestSet_Has(t *testing.T) {
+	s := runtime.NewSet([]interface{}{1, 2.5, "a", nil, runtime.NewDecimal(1, 1), big.NewInt(3)})
+	tests := []struct {
+		value interface{}
+		want  bool
+	}{
+		{1, true},
+		{1.0, true},
+		{int16(1), true},
+		{uint8(1), true},
+		{runtime.NewDecimal(100, 2), true},
+		{2.5, true},
+		{runtime.NewDecimal(25, 1), true},
+		{float32(2.5), true},
+		{0.1, true},
+		{0.2, false},
+		{runtime.NewDecimal(10, 2), true},
+		{3, true},
+		{big.NewInt(1), true},
+		{"a", true},
+		{"1", false},
+		{nil, true},
+		{[]int{1}, false},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%#v", tt.value).Equal(tt.want, s.Has(tt.value))
+	}
+}
+
+func TestSet_Values(t *testing.T) {
+	is := is.New(t)
+	s := runtime.NewSet([]interface{}{"b", 3, "a", 1, 2.5})
+	is.Equal([]interface{}{2.5, 1, 3, "a", "b"}, s.Values())
+	is.Equal(`["a","b"]`, runtime.ToJSON(runtime.NewSet([]string{"b", "a"})))
+}
+
+func TestSet_operations(t *testing.T) {
+	is := is.New(t)
+	a := runtime.NewSet([]string{"admin", "beta", "eu"})
+	b := runtime.NewSet([]string{"beta", "us"})
+
+	is.Equal([]interface{}{"admin", "beta", "eu", "us"}, runtime.Union(a, b).Values())
+	is.Equal([]interface{}{"beta"}, runtime.Intersect(a, b).Values())
+	is.Equal([]interface{}{"admin", "eu"}, a.Difference(b).Values())
+	is.True(runtime.SubsetOf(runtime.NewSet([]string{"beta"}), a))
+	is.True(!runtime.SubsetOf(b, a))
+	is.True(runtime.SupersetOf(a, runtime.NewSet([]string{"eu", "admin"})))
+	is.True(runtime.SubsetOf(runtime.NewSet([]int{}), b))
+}
+
+func TestSet_invalid(t *testing.T) {
+	tests := []struct {
+		fn  func()
+		err string
+	}{
+		{func() { runtime.NewSet("a") }, "invalid argument for set (type string)"},
+		{func() { runtime.NewSet([][]int{{1}}) }, "cannot use []int as set element"},
+		{func() { runtime.Union(runtime.NewSet([]int{}), []int{1}) }, "invalid operation: | on []int"},
+		{func() { runtime.SubsetOf(1, 2) }, "invalid operation: subsetOf on int"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.err).Equal(tt.err, recovered(tt.fn))
+	}
+}
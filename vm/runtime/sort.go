@@ -0,0 +1,106 @@
+package runtime
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// compare returns -1, 0 or 1. Nil is less than any other value.
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case Less(a, b):
+		return -1
+	case Less(b, a):
+		return 1
+	}
+	return 0
+}
+
+func toValues(array interface{}, fn string) []interface{} {
+	if it, ok := array.(Iterable); ok {
+		out := make([]interface{}, it.Len())
+		for i := range out {
+			out[i] = it.Index(i)
+		}
+		return out
+	}
+	v := reflect.ValueOf(array)
+	if v.Kind() != reflect.Array && v.Kind() != reflect.Slice {
+		panic(fmt.Sprintf("invalid argument for %v (type %T)", fn, array))
+	}
+	out := make([]interface{}, v.Len())
+	for i := range out {
+		out[i] = v.Index(i).Interface()
+	}
+	return out
+}
+
+// Sort returns a sorted copy of the array. The sort is stable.
+func Sort(array interface{}, desc bool) []interface{} {
+	out := toValues(array, "sort")
+	sort.SliceStable(out, func(i, j int) bool {
+		if desc {
+			return compare(out[i], out[j]) > 0
+		}
+		return compare(out[i], out[j]) < 0
+	})
+	return out
+}
+
+// SortBy returns a copy of the array sorted by keys. The keys contain n keys
+// for every element, in element order. Elements are ordered by the first key,
+// then equal elements by the second key, and so on. The sort is stable.
+func SortBy(array interface{}, keys []interface{}, n int, desc bool) []interface{} {
+	values := toValues(array, "sortBy")
+	if len(keys) != len(values)*n {
+		panic(fmt.Sprintf("invalid number of sort keys %v", len(keys)))
+	}
+	index := make([]int, len(values))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool {
+		a, b := keys[index[i]*n:], keys[index[j]*n:]
+		for k := 0; k < n; k++ {
+			if c := compare(a[k], b[k]); c != 0 {
+				if desc {
+					return c > 0
+				}
+				return c < 0
+			}
+		}
+		return false
+	})
+	out := make([]interface{}, len(values))
+	for i, x := range index {
+		out[i] = values[x]
+	}
+	return out
+}
+
+// Take returns the first n elements of the array.
+func Take(array, n interface{}) []interface{} {
+	v := reflect.ValueOf(array)
+	if v.Kind() != reflect.Array && v.Kind() != reflect.Slice {
+		panic(fmt.Sprintf("invalid argument for take (type %T)", array))
+	}
+	size := ToInt(n)
+	if size < 0 {
+		size = 0
+	}
+	if size > v.Len() {
+		size = v.Len()
+	}
+	out := make([]interface{}, size)
+	for i := range out {
+		out[i] = v.Index(i).Interface()
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package runtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ilius/expr/vm/runtime"
+	"github.com/ilius/is/v2"
+)
+
+func TestSort(t *testing.T) {
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		array interface{}
+		desc  bool
+		want  []interface{}
+	}{
+		{[]int{3, 1, 2}, false, []interface{}{1, 2, 3}},
+		{[]int{3, 1, 2}, true, []interface{}{3, 2, 1}},
+		{[]string{"b", "c", "a"}, false, []interface{}{"a", "b", "c"}},
+		{[]interface{}{2, 1.5, nil, 1}, false, []interface{}{nil, 1, 1.5, 2}},
+		{[]interface{}{2, nil}, true, []interface{}{2, nil}},
+		{[]time.Time{day.Add(time.Hour), day}, false, []interface{}{day, day.Add(time.Hour)}},
+		{[2]int{2, 1}, false, []interface{}{1, 2}},
+		{[]int{}, false, []interface{}{}},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg("%v", tt.array).Equal(tt.want, runtime.Sort(tt.array, tt.desc))
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	is := is.New(t)
+	names := []string{"b", "a", "c", "a"}
+
+	// One key per element, sorting is stable.
+	got := runtime.SortBy(names, []interface{}{2, 1, 2, 2}, 1, false)
+	is.Equal([]interface{}{"a", "b", "c", "a"}, got)
+	got = runtime.SortBy(names, []interface{}{2, 1, 2, 2}, 1, true)
+	is.Equal([]interface{}{"b", "c", "a", "a"}, got)
+
+	// Equal elements by the first key are ordered by the second key.
+	got = runtime.SortBy(names, []interface{}{2, "b", 1, "a", 2, "c", 2, "a"}, 2, false)
+	is.Equal([]interface{}{"a", "a", "b", "c"}, got)
+}
+
+func TestSort_invalid(t *testing.T) {
+	tests := []struct {
+		fn  func()
+		err string
+	}{
+		{func() { runtime.Sort(1, false) }, "invalid argument for sort (type int)"},
+		{func() { runtime.Sort([]interface{}{1, "a"}, false) }, "invalid operation: string < int"},
+		{func() { runtime.SortBy([]int{1, 2}, []interface{}{1}, 1, false) }, "invalid number of sort keys 1"},
+		{func() { runtime.Take("a", 1) }, "invalid argument for take (type string)"},
+	}
+	for _, tt := range tests {
+		is := is.New(t)
+		is.Msg(tt.err).Equal(tt.err, recovered(tt.fn))
+	}
+}
+
+func TestTake(t *testing.T) {
+	is := is.New(t)
+	is.Equal([]interface{}{1, 2}, runtime.Take([]int{1, 2, 3}, 2))
+	is.Equal([]interface{}{1, 2, 3}, runtime.Take([]int{1, 2, 3}, 5))
+	is.Equal([]interface{}{}, runtime.Take([]int{1, 2, 3}, -1))
+}
@@ -38,13 +38,32 @@ type VM struct {
 	curr         chan int
 	memory       int
 	memoryBudget int
+	tries        []tryFrame
+	errs         []string
+	params       []interface{}
+	depth        int
+	changes      map[string]interface{}
+	// lazy holds results of lazy values called in this run, by their
+	// path, like "User.Profile". It is shared with calls of definitions.
+	lazy map[string]interface{}
+}
+
+// tryFrame is the state of the VM at the start of a try, restored
+// when an error is caught.
+type tryFrame struct {
+	handler int
+	stack   int
+	scopes  int
+	errs    int
 }
 
 type Scope struct {
 	Array reflect.Value
-	It    int
-	Len   int
-	Count int
+	// Iterable is set instead of Array for custom collections.
+	Iterable runtime.Iterable
+	It       int
+	Len      int
+	Count    int
 }
 
 func Debug() *VM {
@@ -63,6 +82,9 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 				Location: program.Locations[vm.ip-1],
 				Message:  fmt.Sprintf("%v", r),
 			}
+			if e, ok := r.(error); ok {
+				f.Err = e
+			}
 			err = f.Bind(program.Source)
 		}
 	}()
@@ -77,10 +99,42 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 		vm.scopes = vm.scopes[0:0]
 	}
 
+	vm.tries = vm.tries[0:0]
+	vm.errs = vm.errs[0:0]
+	vm.changes = nil
+	vm.lazy = nil
+
 	vm.memoryBudget = MemoryBudget
 	vm.memory = 0
 	vm.ip = 0
 
+	for !vm.run(program, env) {
+		// An error was caught by try, continue with its handler.
+	}
+
+	if vm.debug {
+		close(vm.curr)
+		close(vm.step)
+	}
+
+	if len(vm.stack) > 0 {
+		return vm.pop(), nil
+	}
+
+	return nil, nil
+}
+
+// run executes the program until it ends or until an error is caught
+// by try. It reports whether the program ended.
+func (vm *VM) run(program *Program, env interface{}) (done bool) {
+	defer func() {
+		if len(vm.tries) > 0 {
+			if r := recover(); r != nil {
+				vm.catch(r)
+			}
+		}
+	}()
+
 	for vm.ip < len(program.Bytecode) {
 		if vm.debug {
 			<-vm.step
@@ -136,8 +190,11 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 			vm.push(nil)
 
 		case OpNegate:
-			v := runtime.Negate(vm.pop())
-			vm.push(v)
+			if arg&ArithmeticChecked != 0 {
+				vm.push(runtime.NegateChecked(vm.pop()))
+			} else {
+				vm.push(runtime.Negate(vm.pop()))
+			}
 
 		case OpNot:
 			v := vm.pop().(bool)
@@ -190,6 +247,11 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 			a := vm.pop()
 			vm.push(runtime.In(a, b))
 
+		case OpIs:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.Is(a, b.(string)))
+
 		case OpLess:
 			b := vm.pop()
 			a := vm.pop()
@@ -213,22 +275,46 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 		case OpAdd:
 			b := vm.pop()
 			a := vm.pop()
-			vm.push(runtime.Add(a, b))
+			if arg&ArithmeticChecked != 0 {
+				vm.push(runtime.AddChecked(a, b))
+			} else {
+				vm.push(runtime.Add(a, b))
+			}
 
 		case OpSubtract:
 			b := vm.pop()
 			a := vm.pop()
-			vm.push(runtime.Subtract(a, b))
+			if arg&ArithmeticChecked != 0 {
+				vm.push(runtime.SubtractChecked(a, b))
+			} else {
+				vm.push(runtime.Subtract(a, b))
+			}
 
 		case OpMultiply:
 			b := vm.pop()
 			a := vm.pop()
-			vm.push(runtime.Multiply(a, b))
+			if arg&ArithmeticChecked != 0 {
+				vm.push(runtime.MultiplyChecked(a, b))
+			} else {
+				vm.push(runtime.Multiply(a, b))
+			}
 
 		case OpDivide:
 			b := vm.pop()
 			a := vm.pop()
-			vm.push(runtime.Divide(a, b))
+			switch arg {
+			case ArithmeticIntegerDivision:
+				vm.push(runtime.DivideInt(a, b))
+			case ArithmeticIntegerDivision | ArithmeticChecked:
+				vm.push(runtime.DivideIntChecked(a, b))
+			default:
+				vm.push(runtime.Divide(a, b))
+			}
+
+		case OpCallOperator:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(program.Constants[arg].(*runtime.OperatorMethod).Call(a, b))
 
 		case OpModulo:
 			b := vm.pop()
@@ -238,7 +324,14 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 		case OpExponent:
 			b := vm.pop()
 			a := vm.pop()
-			vm.push(runtime.Exponent(a, b))
+			v := runtime.Exponent(a, b)
+			if d, ok := v.(runtime.Decimal); ok {
+				vm.memory += d.Size()
+				if vm.memory >= vm.memoryBudget {
+					panic("memory budget exceeded")
+				}
+			}
+			vm.push(v)
 
 		case OpRange:
 			b := vm.pop()
@@ -282,6 +375,31 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 			a := vm.pop()
 			vm.push(strings.HasSuffix(a.(string), b.(string)))
 
+		case OpUnion:
+			b := vm.pop()
+			a := vm.pop()
+			v := runtime.Union(a, b)
+			vm.memory += len(v)
+			if vm.memory >= vm.memoryBudget {
+				panic("memory budget exceeded")
+			}
+			vm.push(v)
+
+		case OpIntersect:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.Intersect(a, b))
+
+		case OpSubsetOf:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.SubsetOf(a, b))
+
+		case OpSupersetOf:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.SupersetOf(a, b))
+
 		case OpSlice:
 			from := vm.pop()
 			to := vm.pop()
@@ -431,16 +549,118 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 
 		case OpPointer:
 			scope := vm.Scope()
+			if scope.Iterable != nil {
+				vm.push(scope.Iterable.Index(scope.It))
+				break
+			}
 			vm.push(scope.Array.Index(scope.It).Interface())
 
 		case OpBegin:
 			a := vm.pop()
+			if it, ok := a.(runtime.Iterable); ok {
+				vm.scopes = append(vm.scopes, &Scope{
+					Iterable: it,
+					Len:      it.Len(),
+				})
+				break
+			}
 			array := reflect.ValueOf(a)
 			vm.scopes = append(vm.scopes, &Scope{
 				Array: array,
 				Len:   array.Len(),
 			})
 
+		case OpSort:
+			array := runtime.Sort(vm.pop(), arg == 1)
+			vm.memory += len(array)
+			if vm.memory >= vm.memoryBudget {
+				panic("memory budget exceeded")
+			}
+			vm.push(array)
+
+		case OpSortBy:
+			n := vm.pop().(int)
+			scope := vm.Scope()
+			size := scope.Len * n
+			keys := make([]interface{}, size)
+			copy(keys, vm.stack[len(vm.stack)-size:])
+			vm.stack = vm.stack[:len(vm.stack)-size]
+			vm.memory += size + scope.Len
+			if vm.memory >= vm.memoryBudget {
+				panic("memory budget exceeded")
+			}
+			var array interface{} = scope.Iterable
+			if scope.Iterable == nil {
+				array = scope.Array.Interface()
+			}
+			vm.push(runtime.SortBy(array, keys, n, arg == 1))
+
+		case OpLoadParam:
+			vm.push(vm.params[arg])
+
+		case OpCallDefinition:
+			d := program.Definitions[arg]
+			params := make([]interface{}, len(d.Params))
+			for i := len(params) - 1; i >= 0; i-- {
+				params[i] = vm.pop()
+			}
+			vm.push(vm.callDefinition(d, params, env))
+
+		case OpAssign:
+			target := program.Constants[arg].(*runtime.Target)
+			if vm.changes == nil {
+				vm.changes = make(map[string]interface{})
+			}
+			vm.changes[target.Name] = target.Convert(vm.pop())
+
+		case OpLoadChange:
+			vm.push(vm.changes[program.Constants[arg].(string)])
+
+		case OpMutate:
+			for name, value := range vm.changes {
+				runtime.Assign(env, name, value)
+			}
+
+		case OpChanges:
+			if vm.changes == nil {
+				vm.changes = make(map[string]interface{})
+			}
+			vm.push(vm.changes)
+
+		case OpTry:
+			vm.tries = append(vm.tries, tryFrame{
+				handler: vm.ip + arg,
+				stack:   len(vm.stack),
+				scopes:  len(vm.scopes),
+				errs:    len(vm.errs),
+			})
+
+		case OpTryEnd:
+			vm.tries = vm.tries[:len(vm.tries)-1]
+			vm.ip += arg
+
+		case OpError:
+			vm.push(vm.errs[len(vm.errs)-1])
+
+		case OpCatchEnd:
+			vm.errs = vm.errs[:len(vm.errs)-1]
+
+		case OpLazy:
+			fn := vm.pop()
+			key := program.Constants[arg].(string)
+			value, ok := vm.lazy[key]
+			if !ok {
+				value = runtime.Invoke(fn)
+				if vm.lazy == nil {
+					vm.lazy = make(map[string]interface{})
+				}
+				vm.lazy[key] = value
+			}
+			vm.push(value)
+
+		case OpInvoke:
+			vm.push(runtime.Invoke(vm.pop()))
+
 		case OpEnd:
 			vm.scopes = vm.scopes[:len(vm.scopes)-1]
 
@@ -458,6 +678,134 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 			case builtin.Float:
 				vm.push(runtime.ToFloat64(vm.pop()))
 
+			case builtin.Decimal:
+				vm.push(runtime.ToDecimal(vm.pop()))
+
+			case builtin.ToJSON:
+				vm.push(runtime.ToJSON(vm.pop()))
+
+			case builtin.FromJSON:
+				v, size := runtime.FromJSON(vm.pop())
+				vm.memory += size
+				if vm.memory >= vm.memoryBudget {
+					panic("memory budget exceeded")
+				}
+				vm.push(v)
+
+			case builtin.JSONPath:
+				b := vm.pop()
+				a := vm.pop()
+				v, size := runtime.QueryJSON(a, b)
+				vm.memory += size
+				if vm.memory >= vm.memoryBudget {
+					panic("memory budget exceeded")
+				}
+				vm.push(v)
+
+			case builtin.FindAll:
+				b := vm.pop()
+				a := vm.pop()
+				v := runtime.FindAll(a, b)
+				vm.memory += len(v)
+				if vm.memory >= vm.memoryBudget {
+					panic("memory budget exceeded")
+				}
+				vm.push(v)
+
+			case builtin.Capture:
+				b := vm.pop()
+				a := vm.pop()
+				vm.push(runtime.Capture(a, b))
+
+			case builtin.ReplaceRegex:
+				c := vm.pop()
+				b := vm.pop()
+				a := vm.pop()
+				vm.push(runtime.ReplaceRegex(a, b, c))
+
+			case builtin.Split:
+				b := vm.pop()
+				a := vm.pop()
+				v := runtime.Split(a, b)
+				vm.memory += len(v)
+				if vm.memory >= vm.memoryBudget {
+					panic("memory budget exceeded")
+				}
+				vm.push(v)
+
+			case builtin.Type:
+				vm.push(runtime.TypeName(vm.pop()))
+
+			case builtin.IP:
+				vm.push(runtime.ToIP(vm.pop()))
+
+			case builtin.CIDR:
+				vm.push(runtime.ToCIDR(vm.pop()))
+
+			case builtin.Semver:
+				vm.push(runtime.ToVersion(vm.pop()))
+
+			case builtin.SHA256:
+				vm.push(runtime.SHA256(vm.pop()))
+
+			case builtin.MD5:
+				vm.push(runtime.MD5(vm.pop()))
+
+			case builtin.Hash:
+				vm.push(runtime.Hash(vm.pop()))
+
+			case builtin.Bucket:
+				b := vm.pop()
+				a := vm.pop()
+				vm.push(runtime.Bucket(a, b))
+
+			case builtin.Base64Encode:
+				vm.push(runtime.Base64Encode(vm.pop()))
+
+			case builtin.Base64Decode:
+				vm.push(runtime.Base64Decode(vm.pop()))
+
+			case builtin.HexEncode:
+				vm.push(runtime.HexEncode(vm.pop()))
+
+			case builtin.HexDecode:
+				vm.push(runtime.HexDecode(vm.pop()))
+
+			case builtin.URLEncode:
+				vm.push(runtime.URLEncode(vm.pop()))
+
+			case builtin.URLDecode:
+				vm.push(runtime.URLDecode(vm.pop()))
+
+			case builtin.Take:
+				b := vm.pop()
+				a := vm.pop()
+				v := runtime.Take(a, b)
+				vm.memory += len(v)
+				if vm.memory >= vm.memoryBudget {
+					panic("memory budget exceeded")
+				}
+				vm.push(v)
+
+			case builtin.Set:
+				v := runtime.NewSet(vm.pop())
+				vm.memory += len(v)
+				if vm.memory >= vm.memoryBudget {
+					panic("memory budget exceeded")
+				}
+				vm.push(v)
+
+			case builtin.Get:
+				c := vm.pop()
+				b := vm.pop()
+				a := vm.pop()
+				vm.push(runtime.Get(a, b, c))
+
+			case builtin.Has:
+				b := vm.pop()
+				a := vm.pop()
+				vm.push(runtime.Has(a, b))
+
 			default:
 				panic(fmt.Sprintf("unknown builtin %v", arg))
 			}
@@ -470,17 +818,21 @@ func (vm *VM) Run(program *Program, env interface{}) (_ interface{}, err error)
 			vm.curr <- vm.ip
 		}
 	}
+	return true
+}
 
+// catch restores the VM to the state at the start of the innermost try
+// and jumps to its handler.
+func (vm *VM) catch(r interface{}) {
+	frame := vm.tries[len(vm.tries)-1]
+	vm.tries = vm.tries[:len(vm.tries)-1]
+	vm.stack = vm.stack[:frame.stack]
+	vm.scopes = vm.scopes[:frame.scopes]
+	vm.errs = append(vm.errs[:frame.errs], fmt.Sprintf("%v", r))
+	vm.ip = frame.handler
 	if vm.debug {
-		close(vm.curr)
-		close(vm.step)
+		vm.curr <- vm.ip
 	}
-
-	if len(vm.stack) > 0 {
-		return vm.pop(), nil
-	}
-
-	return nil, nil
 }
 
 func (vm *VM) push(value interface{}) {